@@ -0,0 +1,313 @@
+package base
+
+import (
+	"fmt"
+)
+
+// CheckError is a mistake found by Check in the code of a program. Warnings
+// are mistakes that don't stop the program from running
+type CheckError struct {
+	FileName  string
+	FileLine  int
+	Span      Span
+	Code      string
+	Message   string
+	IsWarning bool
+}
+
+func (err CheckError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", err.FileName, err.FileLine, err.Message)
+}
+
+// Check verifies a complete program without running it. It doesn't need the
+// parser, so it also works for programs that were deserialized or mutated by
+// affordances. It checks that:
+//
+//	the identifiers and operators of the expressions are declared,
+//	the arguments of the expressions match the signatures of their operators,
+//	every expression of the functions can be run, and
+//	every output of the functions is assigned.
+func (prgrm *CXProgram) Check() []CheckError {
+	var chk checker
+	for _, pkg := range prgrm.Packages {
+		for _, fn := range pkg.Functions {
+			if fn.IsNative {
+				continue
+			}
+			chk.checkFunction(prgrm, fn)
+		}
+	}
+	return chk.errs
+}
+
+// mustCheck checks a program that was changed while running, e.g. by an
+// affordance or by a deserialization, and panics with the first error found,
+// so the changed program never runs invalid code
+func mustCheck(prgrm *CXProgram) {
+	for _, err := range prgrm.Check() {
+		if !err.IsWarning {
+			panic(fmt.Sprintf("the changed program is invalid: %v", err))
+		}
+	}
+}
+
+type checker struct {
+	errs []CheckError
+}
+
+func (chk *checker) errorf(fileName string, fileLine int, span Span, code string, format string, a ...interface{}) {
+	chk.errs = append(chk.errs, CheckError{FileName: fileName, FileLine: fileLine, Span: span, Code: code, Message: fmt.Sprintf(format, a...)})
+}
+
+func (chk *checker) warningf(fileName string, fileLine int, span Span, code string, format string, a ...interface{}) {
+	chk.errorf(fileName, fileLine, span, code, format, a...)
+	chk.errs[len(chk.errs)-1].IsWarning = true
+}
+
+func (chk *checker) checkFunction(prgrm *CXProgram, fn *CXFunction) {
+	// the names of the parameters and of the variables declared in fn
+	locals := map[string]bool{}
+	for _, param := range fn.Inputs {
+		locals[param.Name] = true
+	}
+	for _, param := range fn.Outputs {
+		locals[param.Name] = true
+	}
+	for _, expr := range fn.Expressions {
+		for _, out := range expr.Outputs {
+			locals[out.Name] = true
+		}
+	}
+
+	for _, expr := range fn.Expressions {
+		chk.checkOperator(prgrm, expr)
+		for _, arg := range expr.Inputs {
+			chk.checkIdentifier(fn, expr, arg, locals)
+		}
+		for _, arg := range expr.Outputs {
+			chk.checkIdentifier(fn, expr, arg, locals)
+		}
+		chk.checkSignature(expr)
+	}
+
+	chk.checkReachability(prgrm, fn)
+	chk.checkOutputs(fn)
+}
+
+// checkOperator checks that the operator of `expr` is a native or a function
+// of one of the packages of the program
+func (chk *checker) checkOperator(prgrm *CXProgram, expr *CXExpression) {
+	op := expr.Operator
+	if op == nil {
+		// a declaration or a literal
+		return
+	}
+	if op.IsNative {
+		if Natives[op.OpCode] == nil {
+			chk.errorf(expr.FileName, expr.FileLine, expr.Span, DIAG_UNDECLARED, "unknown native operator with code %d", op.OpCode)
+		}
+		return
+	}
+	if op.Package != nil {
+		for _, pkg := range prgrm.Packages {
+			if pkg != op.Package {
+				continue
+			}
+			for _, fn := range pkg.Functions {
+				if fn == op {
+					return
+				}
+			}
+		}
+	}
+	chk.errorf(expr.FileName, expr.FileLine, expr.Span, DIAG_UNDECLARED, "function '%s' is not declared", op.Name)
+}
+
+// checkIdentifier checks that the argument `arg` of `expr` was resolved to a
+// local variable of `fn` or to a global
+func (chk *checker) checkIdentifier(fn *CXFunction, expr *CXExpression, arg *CXArgument, locals map[string]bool) {
+	if arg.Name == "" {
+		// a literal
+		return
+	}
+	if arg.Type == TYPE_IDENTIFIER {
+		chk.errorf(arg.FileName, arg.FileLine, arg.Span, DIAG_UNDECLARED, "identifier '%s' was not resolved", arg.Name)
+		return
+	}
+	if locals[arg.Name] || arg.IsCaptured || arg.Type == TYPE_FUNC {
+		return
+	}
+	for _, pkg := range []*CXPackage{arg.Package, fn.Package} {
+		if pkg == nil {
+			continue
+		}
+		if _, err := pkg.GetGlobal(arg.Name); err == nil {
+			return
+		}
+	}
+	chk.errorf(arg.FileName, arg.FileLine, arg.Span, DIAG_UNDECLARED, "identifier '%s' is not declared in function '%s'", arg.Name, fn.Name)
+}
+
+// checkSignature checks that the arguments of `expr` match the inputs and
+// outputs of its operator. The types are compared if both of them are basic
+// types, as the other types were already checked by the compiler
+func (chk *checker) checkSignature(expr *CXExpression) {
+	op := expr.Operator
+	if op == nil || expr.IsConversion || (op.IsNative && op.OpCode == OP_CALL) {
+		// calls through function values are checked when they're run
+		return
+	}
+
+	opName := op.Name
+	if op.IsNative {
+		opName = OpNames[op.OpCode]
+	}
+
+	// the variadic parameter of a function can receive no arguments, and a
+	// variadic native receives arguments after all its inputs
+	variadic := len(op.Inputs) > 0 && op.Inputs[len(op.Inputs)-1].IsVariadic
+	if len(expr.Inputs) < len(op.Inputs) && !(variadic && len(expr.Inputs) == len(op.Inputs)-1) || len(expr.Inputs) > len(op.Inputs) && !variadic && !op.IsVariadic {
+		chk.errorf(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, "operator '%s' expects %d inputs, but it receives %d", opName, len(op.Inputs), len(expr.Inputs))
+		return
+	}
+
+	// a receive can also return whether the channel is open, a type
+	// assertion whether it succeeded, and receives and recover can be run
+	// without receiving their outputs
+	extraOutputs := (op == Natives[OP_RECV] || op == Natives[OP_TYPE_ASSERT]) && len(expr.Outputs) == 2
+	noOutputs := (op == Natives[OP_RECV] || op == Natives[OP_RECOVER]) && len(expr.Outputs) == 0
+	if len(expr.Outputs) != len(op.Outputs) && !extraOutputs && !noOutputs {
+		chk.errorf(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, "operator '%s' returns %d outputs, but %d are received", opName, len(op.Outputs), len(expr.Outputs))
+		return
+	}
+
+	if op.IsNative && op.OpCode == OP_IDENTITY {
+		for i, inp := range expr.Inputs {
+			if i < len(expr.Outputs) && !sameBasicType(GetAssignmentElement(inp), GetAssignmentElement(expr.Outputs[i])) {
+				out := GetAssignmentElement(expr.Outputs[i])
+				chk.errorf(out.FileName, out.FileLine, out.Span, DIAG_TYPE, "cannot assign a value of type '%s' to '%s' of type '%s'", TypeNames[GetAssignmentElement(inp).Type], out.Name, TypeNames[out.Type])
+			}
+		}
+		return
+	}
+
+	for i, param := range op.Inputs {
+		if param.IsVariadic || i >= len(expr.Inputs) {
+			break
+		}
+		arg := GetAssignmentElement(expr.Inputs[i])
+		if !sameBasicType(arg, param) {
+			chk.errorf(arg.FileName, arg.FileLine, arg.Span, DIAG_TYPE, "operator '%s' expects input %d of type '%s', but it receives '%s'", opName, i+1, TypeNames[param.Type], TypeNames[arg.Type])
+		}
+	}
+	for i, param := range op.Outputs {
+		if i >= len(expr.Outputs) {
+			break
+		}
+		arg := GetAssignmentElement(expr.Outputs[i])
+		if !sameBasicType(param, arg) {
+			chk.errorf(arg.FileName, arg.FileLine, arg.Span, DIAG_TYPE, "operator '%s' returns output %d of type '%s', but it's received by '%s' of type '%s'", opName, i+1, TypeNames[param.Type], arg.Name, TypeNames[arg.Type])
+		}
+	}
+}
+
+// sameBasicType tells if two values can have the same type, which is only
+// false if both are of different basic types
+func sameBasicType(a, b *CXArgument) bool {
+	isBasic := func(arg *CXArgument) bool {
+		return arg.Type > TYPE_UNDEFINED && arg.Type < TYPE_FUNC && arg.CustomType == nil
+	}
+	return !isBasic(a) || !isBasic(b) || a.Type == b.Type
+}
+
+// checkReachability warns about the code of `fn` that is never run, e.g.
+// the statements after a return. The expressions are the nodes of a graph
+// whose edges go to the next expression or to the targets of a jump
+func (chk *checker) checkReachability(prgrm *CXProgram, fn *CXFunction) {
+	reachable := make([]bool, len(fn.Expressions))
+	pending := []int{0}
+	for len(pending) > 0 {
+		i := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if i < 0 || i >= len(fn.Expressions) || reachable[i] {
+			continue
+		}
+		reachable[i] = true
+		pending = append(pending, successors(prgrm, fn.Expressions[i], i)...)
+	}
+
+	// the compiler moves some code, e.g. the post statement of a loop after
+	// its body, so unreachable code is only reported if it's written after
+	// all the code that can be run before it
+	maxOffset := -1
+	var inUnreachable bool
+	for i, expr := range fn.Expressions {
+		if reachable[i] {
+			inUnreachable = false
+			if expr.Span.IsValid() && expr.Span.EndOffset > maxOffset {
+				maxOffset = expr.Span.EndOffset
+			}
+			continue
+		}
+		if inUnreachable || !expr.Span.IsValid() || expr.Span.Offset < maxOffset {
+			continue
+		}
+		chk.warningf(expr.FileName, expr.FileLine, expr.Span, DIAG_UNREACHABLE, "unreachable code")
+		inUnreachable = true
+	}
+}
+
+// successors returns the indexes of the expressions that can be run after
+// the i-th expression of a function. An index past the last expression is
+// the end of the function
+func successors(prgrm *CXProgram, expr *CXExpression, i int) []int {
+	if expr.Operator == nil || !expr.Operator.IsNative || expr.Operator.OpCode != OP_JMP {
+		return []int{i + 1}
+	}
+
+	then := i + 1 + expr.ThenLines
+	els := i + 1 + expr.ElseLines
+	if expr.Label != "" {
+		// gotos and returns
+		return []int{then}
+	}
+
+	predicate := expr.Inputs[0]
+	if predicate.Name == "" && len(predicate.Fields) == 0 && len(predicate.Indexes) == 0 && predicate.Offset < len(prgrm.Memory) {
+		// a literal, e.g. the condition of a `for {}` loop
+		if prgrm.Memory[predicate.Offset] != 0 {
+			return []int{then}
+		}
+		return []int{els}
+	}
+	return []int{then, els}
+}
+
+// checkOutputs warns about the outputs of `fn` that no expression assigns
+func (chk *checker) checkOutputs(fn *CXFunction) {
+	for _, out := range fn.Outputs {
+		// the methods of interfaces don't name their outputs
+		if out.Name == "" || out.IsCaptured || isAssigned(fn, out.Name) {
+			continue
+		}
+		chk.warningf(out.FileName, out.FileLine, out.Span, DIAG_UNASSIGNED, "output '%s' of function '%s' is never assigned", out.Name, fn.Name)
+	}
+}
+
+// isAssigned tells if an expression of `fn` assigns the variable `name`, or
+// passes a reference to it
+func isAssigned(fn *CXFunction, name string) bool {
+	for _, expr := range fn.Expressions {
+		for _, out := range expr.Outputs {
+			if out.Name == name {
+				return true
+			}
+		}
+		for _, inp := range expr.Inputs {
+			if inp.Name == name && (inp.PassBy == PASSBY_REFERENCE || inp.IsReference || inp.IsCaptured) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -16,7 +16,40 @@ var SRCPATH string = CXPATH + "src/"
 var COREPATH string
 
 const STACK_OVERFLOW_ERROR = "stack overflow"
-const HEAP_EXHAUSTED_ERROR = "stack exhausted"
+const CALLSTACK_OVERFLOW_ERROR = "call stack overflow"
+const HEAP_EXHAUSTED_ERROR = "heap exhausted"
+const THREAD_STACK_ERROR = "no stack left for a new thread"
+const DEADLOCK_ERROR = "all threads are asleep - deadlock!"
+const CLOSED_CHAN_SEND_ERROR = "send on closed channel"
+const CLOSED_CHAN_CLOSE_ERROR = "close of closed channel"
+const NIL_CHAN_CLOSE_ERROR = "close of nil channel"
+const NIL_FUNC_CALL_ERROR = "call of nil function value"
+const NIL_INTERFACE_CALL_ERROR = "method call on nil interface value"
+const TYPE_ASSERTION_ERROR = "interface conversion"
+const INDEX_OUT_OF_RANGE_ERROR = "index out of range"
+const NIL_POINTER_ERROR = "invalid memory address or nil pointer dereference"
+const SLICE_BOUNDS_ERROR = "slice bounds out of range"
+
+// codes of the diagnostics of the compiler, Check and Vet, which group
+// them by the kind of mistake
+const (
+	DIAG_SYNTAX      = "syntax"      // the code can't be parsed
+	DIAG_LITERAL     = "literal"     // malformed literals
+	DIAG_UNDECLARED  = "undeclared"  // unknown identifiers, fields, packages or types
+	DIAG_REDECLARED  = "redeclared"  // names declared twice in the same scope
+	DIAG_TYPE        = "type"        // mismatched or invalid types
+	DIAG_ARGUMENTS   = "arguments"   // wrong number of arguments or results
+	DIAG_INVALID     = "invalid"     // invalid operations and statements
+	DIAG_LIMIT       = "limit"       // programs exceeding the memory limits
+	DIAG_INTERNAL    = "internal"    // the compiler failed while compiling valid code
+	DIAG_UNREACHABLE = "unreachable" // code that is never run
+	DIAG_UNASSIGNED  = "unassigned"  // outputs of functions that are never assigned
+	DIAG_UNUSED      = "unused"      // unused variables, imports and results of calls
+	DIAG_UNREAD      = "unread"      // values assigned to variables that are never read
+	DIAG_PRINTF      = "printf"      // format strings that don't match their arguments
+	DIAG_SHADOW      = "shadow"      // declarations hiding a variable of an outer scope
+)
+
 const MAIN_FUNC = "main"
 const SYS_INIT_FUNC = "*init"
 const MAIN_PKG = "main"
@@ -26,6 +59,7 @@ const OS_ARGS = "Args"
 const NON_ASSIGN_PREFIX = "nonAssign"
 const LOCAL_PREFIX = "*lcl"
 const LABEL_PREFIX = "*lbl"
+const LAMBDA_PREFIX = "*lambda"
 // const CORE_MODULE = "core"
 const ID_FN = "identity"
 const INIT_FN = "initDef"
@@ -39,16 +73,24 @@ const OBJECT_HEADER_SIZE = 9
 const OBJECT_GC_HEADER_SIZE = 5
 const FORWARDING_ADDRESS_SIZE = 4
 const OBJECT_SIZE = 4
+// default memory sizes, see MakeProgramWithSizes
 const CALLSTACK_SIZE = 1000
 const STACK_SIZE = 500000
 const INIT_HEAP_SIZE = 1000000
-const NULL_ADDRESS = STACK_SIZE
+const MAX_HEAP_SIZE = 1 << 30
+const THREAD_STACK_SIZE = 32768
+const THREAD_QUANTUM = 100 // expressions a thread runs before yielding
 const NULL_HEAP_ADDRESS_OFFSET = 4
 const NULL_HEAP_ADDRESS = 0
+const NULL_STACK_ADDRESS_OFFSET = 4 // the stack starts after it, so a pointer to the stack is never nil
 const STR_HEADER_SIZE = 4
 const TYPE_POINTER_SIZE = 4
-const SLICE_HEADER_SIZE = 8
-const MEMORY_SIZE = STACK_SIZE + INIT_HEAP_SIZE + TYPE_POINTER_SIZE
+const SLICE_HEADER_SIZE = 12 // length, capacity and offset of the elements, see sliceElements
+const SLICE_BOUND_OMITTED = -1 << 31 // a bound left out of a slice expression, e.g. the high bound of s[1:]
+const MAP_HEADER_SIZE = 24
+const CHAN_HEADER_SIZE = 28
+const CLOSURE_HEADER_SIZE = 8
+const INTERFACE_SIZE = 8 // type code and pointer
 
 const MAX_UINT32 = ^uint32(0)
 const MIN_UINT32 = 0
@@ -75,6 +117,8 @@ const (
 	DECL_SLICE          // 2
 	DECL_STRUCT         // 3
 	DECL_BASIC          // 4
+	DECL_MAP            // 5
+	DECL_CHAN           // 6
 )
 
 // what to write
@@ -88,6 +132,10 @@ const (
 	DEREF_FIELD
 	DEREF_POINTER
 	DEREF_DEREF
+	DEREF_MAP           // lookup by key
+	DEREF_MAP_INSERT    // lookup by key, inserting the key if it's not found
+	DEREF_MAP_KEY       // key of the nth entry
+	DEREF_MAP_VALUE     // value of the nth entry
 )
 
 const (
@@ -106,6 +154,7 @@ const (
 	TYPE_UI16
 	TYPE_UI32
 	TYPE_UI64
+	TYPE_FUNC
 
 	TYPE_THRESHOLD
 
@@ -131,6 +180,7 @@ var TypeCodes map[string]int = map[string]int{
 	"ui16":       TYPE_UI16,
 	"ui32":       TYPE_UI32,
 	"ui64":       TYPE_UI64,
+	"func":       TYPE_FUNC,
 	"und":        TYPE_UNDEFINED,
 }
 
@@ -150,6 +200,7 @@ var TypeNames map[int]string = map[int]string{
 	TYPE_UI16:       "ui16",
 	TYPE_UI32:       "ui32",
 	TYPE_UI64:       "ui64",
+	TYPE_FUNC:       "func",
 	TYPE_UNDEFINED:  "und",
 }
 
@@ -17,6 +17,7 @@ type CXExpression struct {
 	Operator *CXFunction
 	// debugging
 	FileLine int
+	Span     Span
 	// used for jmp statements
 	ThenLines       int
 	ElseLines       int
@@ -29,6 +30,11 @@ type CXExpression struct {
 	IsUndType       bool
 	IsBreak         bool
 	IsContinue      bool
+	IsGoCall        bool
+	IsDeferCall     bool
+	IsSpreadCall    bool // its last input is passed as the variadic parameter, e.g. f(xs...)
+	IsConversion    bool // converts its input to the type of its output, e.g. Meters(x)
+	IsSwitchCase    bool // compares a case value, its second input, with the tag of a switch
 }
 
 func MakeExpression(op *CXFunction, fileName string, fileLine int) *CXExpression {
@@ -22,6 +22,7 @@ type CXFunction struct {
 	Package           *CXPackage
 	ElementID         UUID
 	IsNative          bool
+	IsVariadic        bool // a native receiving any number of arguments after its inputs
 }
 
 func MakeFunction(name string) *CXFunction {
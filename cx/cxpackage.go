@@ -11,20 +11,31 @@ import (
 
 type CXPackage struct {
 	Imports         []*CXPackage
+	ImportDecls     map[string]*CXImport // by the name of the imported package
 	Functions       []*CXFunction
 	Structs         []*CXStruct
 	Globals         []*CXArgument
+	Constants       []*CXConstant
 	Name            string
 	CurrentFunction *CXFunction
 	CurrentStruct   *CXStruct
 	ElementID       UUID
 }
 
+// CXImport is where a package imports another package, which is used if
+// the code of the package refers to it
+type CXImport struct {
+	FileName string
+	FileLine int
+	IsUsed   bool
+}
+
 func MakePackage(name string) *CXPackage {
 	return &CXPackage{
 		ElementID: MakeElementID(),
 		Name:      name,
 		Globals:   make([]*CXArgument, 0, 10),
+		Constants: make([]*CXConstant, 0),
 		Imports:   make([]*CXPackage, 0),
 		Functions: make([]*CXFunction, 0, 10),
 		Structs:   make([]*CXStruct, 0),
@@ -76,7 +87,7 @@ func (pkg *CXPackage) GetFunction(fnName string) (*CXFunction, error) {
 
 func (pkg *CXPackage) GetMethod(fnName string, receiverType string) (*CXFunction, error) {
 	for _, fn := range pkg.Functions {
-		if fn.Name == fnName && len(fn.Inputs) > 0 && fn.Inputs[0].CustomType != nil && fn.Inputs[0].CustomType.Name == receiverType {
+		if fn.Name == fnName && len(fn.Inputs) > 0 && ReceiverType(fn.Inputs[0]) != nil && ReceiverType(fn.Inputs[0]).Name == receiverType {
 			return fn, nil
 		}
 	}
@@ -137,6 +148,16 @@ func (pkg *CXPackage) GetGlobal(defName string) (*CXArgument, error) {
 	}
 }
 
+func (pkg *CXPackage) GetConstant(cnstName string) (*CXConstant, error) {
+	for _, cnst := range pkg.Constants {
+		if cnst.Name == cnstName {
+			return cnst, nil
+		}
+	}
+
+	return nil, fmt.Errorf("constant '%s' not found in package '%s'", cnstName, pkg.Name)
+}
+
 func (pkg *CXPackage) GetCurrentFunction() (*CXFunction, error) {
 	if pkg.CurrentFunction != nil {
 		return pkg.CurrentFunction, nil
@@ -257,6 +278,21 @@ func (pkg *CXPackage) RemoveStruct(strctName string) {
 	}
 }
 
+func (pkg *CXPackage) AddConstant(cnst *CXConstant) *CXPackage {
+	found := false
+	for i, c := range pkg.Constants {
+		if c.Name == cnst.Name {
+			pkg.Constants[i] = cnst
+			found = true
+			break
+		}
+	}
+	if !found {
+		pkg.Constants = append(pkg.Constants, cnst)
+	}
+	return pkg
+}
+
 func (pkg *CXPackage) AddGlobal(def *CXArgument) *CXPackage {
 	// def.Program = pkg.Program
 	def.Package = pkg
@@ -26,16 +26,49 @@ type CXProgram struct {
 	HeapPointer    int
 	StackPointer   int
 	HeapStartsAt   int
+	StackSize      int
+	MaxHeapSize    int
+	NilMapValue    int // zeroed value in the data segment that reading a nil map returns
 	ElementID      UUID
 	Terminated     bool
+
+	// threads started by `go` statements, see threads.go
+	Threads         []*CXThread
+	CurrentThread   int
+	ThreadStackSize int
+
+	gcThreshold int
+	gcRequested bool
+	gcOverdraft int // size of the first allocation that didn't fit in the heap since the last collection
+	threadSteps int
 }
 
 func MakeProgram() *CXProgram {
+	return MakeProgramWithSizes(CALLSTACK_SIZE, STACK_SIZE, INIT_HEAP_SIZE, MAX_HEAP_SIZE)
+}
+
+// MakeProgramWithSizes creates a program that can nest `callStackSize` calls
+// and whose stack has `stackSize` bytes. Its heap, which also holds the data
+// segment, starts with `initHeapSize` bytes and can grow up to `maxHeapSize`.
+// The initial size is lowered to the maximum size if it's larger
+func MakeProgramWithSizes(callStackSize, stackSize, initHeapSize, maxHeapSize int) *CXProgram {
+	// heap addresses need to fit in 32 bits
+	if stackSize+TYPE_POINTER_SIZE+maxHeapSize > MAX_INT32 {
+		maxHeapSize = MAX_INT32 - stackSize - TYPE_POINTER_SIZE
+	}
+	if initHeapSize > maxHeapSize {
+		initHeapSize = maxHeapSize
+	}
+
 	newPrgrm := &CXProgram{
-		ElementID: MakeElementID(),
-		Packages:  make([]*CXPackage, 0),
-		CallStack: make([]CXCall, CALLSTACK_SIZE),
-		Memory:    make([]byte, STACK_SIZE+TYPE_POINTER_SIZE+INIT_HEAP_SIZE),
+		ElementID:   MakeElementID(),
+		Packages:    make([]*CXPackage, 0),
+		CallStack:   make([]CXCall, callStackSize),
+		Memory:      make([]byte, stackSize+TYPE_POINTER_SIZE+initHeapSize),
+		StackSize:   stackSize,
+		MaxHeapSize: maxHeapSize,
+
+		ThreadStackSize: THREAD_STACK_SIZE,
 	}
 
 	return newPrgrm
@@ -48,7 +81,7 @@ func (prgrm *CXProgram) GetCurrentPackage() (*CXPackage, error) {
 	if prgrm.CurrentPackage != nil {
 		return prgrm.CurrentPackage, nil
 	} else {
-		return nil, errors.New("current package is nil")
+		return nil, errors.New("no package was declared")
 	}
 }
 
@@ -60,7 +93,7 @@ func (prgrm *CXProgram) GetCurrentStruct() (*CXStruct, error) {
 			return nil, errors.New("current struct is nil")
 		}
 	} else {
-		return nil, errors.New("current package is nil")
+		return nil, errors.New("no package was declared")
 	}
 }
 
@@ -72,7 +105,7 @@ func (prgrm *CXProgram) GetCurrentFunction() (*CXFunction, error) {
 			return nil, errors.New("current function is nil")
 		}
 	} else {
-		return nil, errors.New("current package is nil")
+		return nil, errors.New("no package was declared")
 	}
 }
 
@@ -116,6 +149,25 @@ func (prgrm *CXProgram) GetGlobal(name string) (*CXArgument, error) {
 	}
 }
 
+func (prgrm *CXProgram) GetConstant(name string) (*CXConstant, error) {
+	mod, err := prgrm.GetCurrentPackage()
+	if err != nil {
+		return nil, err
+	}
+
+	if cnst, err := mod.GetConstant(name); err == nil {
+		return cnst, nil
+	}
+
+	for _, imp := range mod.Imports {
+		if cnst, err := imp.GetConstant(name); err == nil {
+			return cnst, nil
+		}
+	}
+
+	return nil, fmt.Errorf("constant '%s' not found", name)
+}
+
 func (prgrm *CXProgram) GetPackage(modName string) (*CXPackage, error) {
 	if prgrm.Packages != nil {
 		var found *CXPackage
@@ -10,11 +10,17 @@ import (
  */
 
 type CXStruct struct {
-	Fields    []*CXArgument
-	Name      string
-	Size      int
-	Package   *CXPackage
-	ElementID UUID
+	Fields      []*CXArgument
+	Name        string
+	Size        int
+	Package     *CXPackage
+	ElementID   UUID
+	IsInterface bool // its methods are declared as functions without expressions
+
+	// the type of a named type, e.g. `type Meters f64`, which has the
+	// values of its underlying type but its own methods
+	Underlying *CXArgument
+	IsAlias    bool // declared as `type Name = T`, it's the same type as T
 }
 
 func MakeStruct(name string) *CXStruct {
@@ -44,6 +50,96 @@ func (strct *CXStruct) GetField(name string) (*CXArgument, error) {
 	return nil, fmt.Errorf("field '%s' not found in struct '%s'", name, strct.Name)
 }
 
+// FieldOffset returns the offset of the field `name` in the struct
+func (strct *CXStruct) FieldOffset(name string) int {
+	var offset int
+	for _, fld := range strct.Fields {
+		if fld.Name == name {
+			break
+		}
+		offset += fld.TotalSize
+	}
+	return offset
+}
+
+// checks if the struct declares the field or method `name` itself
+func (strct *CXStruct) declares(name string) bool {
+	if _, err := strct.GetField(name); err == nil {
+		return true
+	}
+	_, err := strct.Package.GetMethod(strct.Name+"."+name, strct.Name)
+	return err == nil
+}
+
+// GetPromoted returns the embedded fields leading to the struct declaring
+// the field or method `name`, which is empty if the struct declares it
+// itself. The shallowest embedded struct declaring it is used, and it's an
+// error if there's more than one at that depth. It returns nil if `name` is
+// not found
+func (strct *CXStruct) GetPromoted(name string) ([]*CXArgument, error) {
+	if strct.declares(name) {
+		return []*CXArgument{}, nil
+	}
+
+	type embedding struct {
+		strct *CXStruct
+		path  []*CXArgument
+	}
+
+	visited := map[*CXStruct]bool{strct: true}
+	depth := []embedding{{strct: strct}}
+	for len(depth) > 0 {
+		var found []*CXArgument
+		var next []embedding
+		for _, emb := range depth {
+			for _, fld := range emb.strct.Fields {
+				if !fld.IsEmbedded || fld.CustomType == nil || fld.CustomType.IsInterface || visited[fld.CustomType] {
+					continue
+				}
+				path := append(append([]*CXArgument{}, emb.path...), fld)
+				if fld.CustomType.declares(name) {
+					if found != nil {
+						return nil, fmt.Errorf("ambiguous selector '%s' in struct '%s'", name, strct.Name)
+					}
+					found = path
+				}
+				next = append(next, embedding{strct: fld.CustomType, path: path})
+			}
+		}
+		if found != nil {
+			return found, nil
+		}
+		for _, emb := range next {
+			visited[emb.strct] = true
+		}
+		depth = next
+	}
+
+	return nil, nil
+}
+
+// GetMethod returns the method `name` of the struct, which can be promoted
+// from an embedded field, and the offset of its receiver in the struct
+func (strct *CXStruct) GetMethod(name string) (*CXFunction, int, error) {
+	path, err := strct.GetPromoted(name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var offset int
+	rcvr := strct
+	for _, fld := range path {
+		offset += rcvr.FieldOffset(fld.Name)
+		rcvr = fld.CustomType
+	}
+
+	fn, err := rcvr.Package.GetMethod(rcvr.Name+"."+name, rcvr.Name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("method '%s' not found in struct '%s'", name, strct.Name)
+	}
+	return fn, offset, nil
+}
+
 // ----------------------------------------------------------------
 //                     Member handling
 
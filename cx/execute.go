@@ -57,15 +57,45 @@ func (prgrm *CXProgram) ToCall () *CXExpression {
 }
 
 func (prgrm *CXProgram) Run (untilEnd bool, nCalls *int, untilCall int) error {
-	defer RuntimeError()
-	var err error
+	for {
+		unwinding, err := prgrm.run(untilEnd, nCalls, untilCall)
+		if !unwinding {
+			return err
+		}
+	}
+}
+
+// runs the program until it stops or it panics. It returns true if the
+// program panicked and it has to continue running to unwind the panic
+func (prgrm *CXProgram) run (untilEnd bool, nCalls *int, untilCall int) (unwinding bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if !prgrm.startPanic(r) {
+				reportRuntimeError(r)
+			}
+			unwinding = true
+		}
+	}()
 
 	for !prgrm.Terminated && (untilEnd || *nCalls != 0) && prgrm.CallCounter > untilCall {
+		// nested runs (callbacks) don't switch threads
+		if untilCall < 0 && len(prgrm.Threads) > 1 {
+			prgrm.threadSteps++
+			if prgrm.threadSteps > THREAD_QUANTUM {
+				prgrm.Yield()
+			}
+		}
+
 		call := &prgrm.CallStack[prgrm.CallCounter]
 
 		// checking if enough memory in stack
-		if prgrm.StackPointer > STACK_SIZE {
-			panic(STACK_OVERFLOW_ERROR)
+		if stackStart, stackLimit := prgrm.stackBounds(); prgrm.StackPointer > stackLimit {
+			panic(MemoryError{
+				Kind:      STACK_OVERFLOW_ERROR,
+				Requested: call.Operator.Size,
+				Used:      call.FramePointer - stackStart,
+				Limit:     stackLimit - stackStart,
+			})
 		}
 		
 		if !untilEnd {
@@ -78,7 +108,7 @@ func (prgrm *CXProgram) Run (untilEnd bool, nCalls *int, untilCall int) error {
 				prgrm.CallStack[0].Operator = nil
 				prgrm.CallCounter = 0
 				fmt.Println("in:terminated")
-				return err
+				return false, err
 			}
 
 			if call.Line >= call.Operator.Length && prgrm.CallCounter != 0 {
@@ -104,7 +134,7 @@ func (prgrm *CXProgram) Run (untilEnd bool, nCalls *int, untilCall int) error {
 					prgrm.CallStack[0].Operator = nil
 					prgrm.CallCounter = 0
 					fmt.Println("in:terminated")
-					return err
+					return false, err
 				}
 			}
 
@@ -114,11 +144,11 @@ func (prgrm *CXProgram) Run (untilEnd bool, nCalls *int, untilCall int) error {
 
 		err = call.ccall(prgrm)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 
-	return nil
+	return false, nil
 }
 
 func (prgrm *CXProgram) RunCompiled(nCalls int, args []string) error {
@@ -140,8 +170,12 @@ func (prgrm *CXProgram) RunCompiled(nCalls int, args []string) error {
 			if fn, err := mod.SelectFunction(SYS_INIT_FUNC); err == nil {
 				// *init function
 				mainCall := MakeCall(fn)
+				// the frame starts after the null address, so the
+				// address of its first variable isn't read as a nil
+				// pointer when it's dereferenced
+				mainCall.FramePointer = NULL_STACK_ADDRESS_OFFSET
 				prgrm.CallStack[0] = mainCall
-				prgrm.StackPointer = fn.Size
+				prgrm.StackPointer = mainCall.FramePointer + fn.Size
 
 				var err error
 
@@ -156,6 +190,7 @@ func (prgrm *CXProgram) RunCompiled(nCalls int, args []string) error {
 				prgrm.Terminated = false
 				prgrm.CallCounter = 0
 				prgrm.CallStack[0].Operator = nil
+				prgrm.resetThreads()
 			} else {
 				return err
 			}
@@ -169,12 +204,15 @@ func (prgrm *CXProgram) RunCompiled(nCalls int, args []string) error {
 			if prgrm.CallStack[0].Operator == nil {
 				// main function
 				mainCall := MakeCall(fn)
+				// the frame starts after the null address, like the
+				// frame of *init
+				mainCall.FramePointer = NULL_STACK_ADDRESS_OFFSET
 
 				// initializing program resources
 				prgrm.CallStack[0] = mainCall
 
 				// prgrm.Stacks = append(prgrm.Stacks, MakeStack(1024))
-				prgrm.StackPointer = fn.Size
+				prgrm.StackPointer = mainCall.FramePointer + fn.Size
 
 				// feeding os.Args
 				if osPkg, err := PROGRAM.SelectPackage(OS_PKG); err == nil {
@@ -201,6 +239,7 @@ func (prgrm *CXProgram) RunCompiled(nCalls int, args []string) error {
 				prgrm.Terminated = false
 				prgrm.CallCounter = 0
 				prgrm.CallStack[0].Operator = nil
+				prgrm.resetThreads()
 			}
 
 			// debugging memory
@@ -219,66 +258,109 @@ func (prgrm *CXProgram) RunCompiled(nCalls int, args []string) error {
 
 func (prgrm *CXProgram) ccallback(expr *CXExpression, functionName string, packageName string, inputs [][]byte)() {
 	if fn, err := prgrm.GetFunction(functionName, packageName); err == nil {
-		line := prgrm.CallStack[prgrm.CallCounter].Line
-		previousCall := prgrm.CallCounter
-		prgrm.CallCounter++
-		newCall := &prgrm.CallStack[prgrm.CallCounter]
-		newCall.Operator = fn
-		newCall.Line = 0
-		newCall.FramePointer = prgrm.StackPointer
-		prgrm.StackPointer += newCall.Operator.Size
-		newFP := newCall.FramePointer
-
-		// wiping next mem frame (removing garbage)
-		for c := 0; c < expr.Operator.Size; c++ {
-			prgrm.Memory[newFP+c] = 0
-		}
+		prgrm.runCallback(fn, -1, inputs)
+	}
+}
 
-		for i, inp := range inputs {
-			WriteMemory(GetFinalOffset(newFP, newCall.Operator.Inputs[i]), inp)
-		}
+// calls the function value pinned at `idx` (see PinFunction)
+func (prgrm *CXProgram) ccallbackValue(idx int, inputs [][]byte) {
+	closure := PinnedFunction(idx)
+	prgrm.runCallback(closureFunc(closure), closure, inputs)
+}
 
+func (prgrm *CXProgram) runCallback(fn *CXFunction, closure int, inputs [][]byte) {
+	line := prgrm.CallStack[prgrm.CallCounter].Line
+	previousCall := prgrm.CallCounter
+	prgrm.CallCounter++
+	newCall := &prgrm.CallStack[prgrm.CallCounter]
+	newCall.Operator = fn
+	newCall.Line = 0
+	newCall.FramePointer = prgrm.StackPointer
+	newCall.Deferred = nil
+	newCall.Panic = nil
+	prgrm.StackPointer += newCall.Operator.Size
+	newFP := newCall.FramePointer
+
+	// wiping next mem frame (removing garbage)
+	for c := 0; c < fn.Size; c++ {
+		prgrm.Memory[newFP+c] = 0
+	}
 
-		var nCalls = 0
-		if err := prgrm.Run(true, &nCalls, previousCall); err != nil {
-			os.Exit(CX_INTERNAL_ERROR)
-		}
+	for i, inp := range inputs {
+		WriteMemory(GetFinalOffset(newFP, newCall.Operator.Inputs[i]), inp)
+	}
+	if closure >= 0 {
+		writeCaptures(fn, closure, newFP)
+	}
 
-		prgrm.CallCounter = previousCall
-		prgrm.CallStack[prgrm.CallCounter].Line = line
+	var nCalls = 0
+	if err := prgrm.Run(true, &nCalls, previousCall); err != nil {
+		os.Exit(CX_INTERNAL_ERROR)
+	}
+
+	prgrm.CallCounter = previousCall
+	prgrm.CallStack[prgrm.CallCounter].Line = line
+
+	if p := prgrm.CallStack[prgrm.CallCounter].Panic; p != nil {
+		// the callback panicked, so the panic continues from the
+		// native that called it
+		panic(p)
 	}
 }
 
 func (call *CXCall) ccall(prgrm *CXProgram) error {
-	// CX is still single-threaded, so only one stack
+	// `call` belongs to the running thread; see threads.go
 	if call.Line >= call.Operator.Length {
+		if len(call.Deferred) > 0 {
+			prgrm.runDeferred(call)
+			return nil
+		}
+		if call.Panic != nil {
+			prgrm.unwindPanic(call)
+			return nil
+		}
+
 		/*
 		   popping the stack
 		*/
-		// going back to the previous call
-		prgrm.CallCounter--
-		if prgrm.CallCounter < 0 {
-			// then the program finished
-			prgrm.Terminated = true
-		} else {
-			// copying the outputs to the previous stack frame
-			returnAddr := &prgrm.CallStack[prgrm.CallCounter]
+		if prgrm.CallCounter > 0 {
+			// copying the outputs to the previous stack frame, before
+			// popping the call so its frame is still a root if converting
+			// an output to an interface value allocates
+			returnAddr := &prgrm.CallStack[prgrm.CallCounter-1]
 			returnOp := returnAddr.Operator
 			returnLine := returnAddr.Line
 			returnFP := returnAddr.FramePointer
 			fp := call.FramePointer
 
-			expr := returnOp.Expressions[returnLine]
+			// if the previous call already reached its end, this
+			// is a deferred call, whose outputs are discarded
+			if returnLine < returnOp.Length {
+				expr := returnOp.Expressions[returnLine]
 
-			// lenOuts := len(expr.Outputs)
-			for i, out := range call.Operator.Outputs {
-				WriteMemory(
-					GetFinalOffset(returnFP, expr.Outputs[i]),
-					ReadMemory(
-						GetFinalOffset(fp, out),
-						out))
+				// lenOuts := len(expr.Outputs)
+				for i, out := range call.Operator.Outputs {
+					if writeInterfaceValue(fp, out, returnFP, expr.Outputs[i]) {
+						continue
+					}
+					WriteMemory(
+						GetFinalOffset(returnFP, expr.Outputs[i]),
+						ReadMemory(
+							GetFinalOffset(fp, out),
+							out))
+				}
 			}
+		}
 
+		// going back to the previous call
+		prgrm.CallCounter--
+		if prgrm.CallCounter < 0 && prgrm.CurrentThread > 0 {
+			// then the thread finished
+			prgrm.finishThread()
+		} else if prgrm.CallCounter < 0 {
+			// then the program finished
+			prgrm.Terminated = true
+		} else {
 			// return the stack pointer to its previous state
 			prgrm.StackPointer = call.FramePointer
 			// we'll now execute the next command
@@ -296,61 +378,129 @@ func (call *CXCall) ccall(prgrm *CXProgram) error {
 		if expr.Operator == nil {
 		// then it's a declaration
 		call.Line++
-		} else if expr.Operator.IsNative {
+		} else if expr.IsDeferCall {
+			// the call runs when the function reaches its end
+			prgrm.deferCall(call)
+			call.Line++
+		} else if expr.Operator.IsNative && expr.Operator.OpCode != OP_CALL {
+			// in between expressions every reference is in
+			// a frame or in a global, so it's safe to collect
+			CollectGarbage()
 			execNative(prgrm)
+			if prgrm.gcOverdraft > 0 {
+				// the native overdrew the heap, so it's
+				// reported here if it's exhausted
+				MarkAndCompact()
+			}
+			if len(prgrm.Threads) > 0 && prgrm.Threads[prgrm.CurrentThread].Blocked {
+				// the thread runs the expression again once it's woken up
+				prgrm.Yield()
+			} else {
+				call.Line++
+			}
+		} else if expr.IsGoCall {
+			// the call runs in a new thread, and this one continues
+			prgrm.SpawnThread(expr, call.FramePointer)
 			call.Line++
 		} else {
 			/*
 			   It was not a native, so we need to create another call
-			   with the current expression's operator
+			   with the current expression's operator, or with the
+			   function value it calls
 			*/
-			// we're going to use the next call in the callstack
-			prgrm.CallCounter++
-			newCall := &prgrm.CallStack[prgrm.CallCounter]
-			// setting the new call
-			newCall.Operator = expr.Operator
-			newCall.Line = 0
-			newCall.FramePointer = prgrm.StackPointer
-			// the stack pointer is moved to create room for the next call
-			// prgrm.MemoryPointer += fn.Size
-			prgrm.StackPointer += newCall.Operator.Size
-
-			// checking if enough memory in stack
-			if prgrm.StackPointer > STACK_SIZE {
-				panic(STACK_OVERFLOW_ERROR)
-			}
+			prgrm.pushCall(expr, call.FramePointer)
+		}
+	}
+	return nil
+}
 
-			fp := call.FramePointer
-			newFP := newCall.FramePointer
+// pushCall creates the call of the function called by `expr`, taking its
+// inputs from the stack frame at `fp`
+func (prgrm *CXProgram) pushCall(expr *CXExpression, fp int) {
+	fn, closure := calledFunction(expr, fp)
+
+	// we're going to use the next call in the callstack
+	if prgrm.CallCounter+1 >= len(prgrm.CallStack) {
+		panic(MemoryError{
+			Kind:  CALLSTACK_OVERFLOW_ERROR,
+			Used:  prgrm.CallCounter + 1,
+			Limit: len(prgrm.CallStack),
+		})
+	}
+	prgrm.CallCounter++
+	newCall := &prgrm.CallStack[prgrm.CallCounter]
+	// setting the new call
+	newCall.Operator = fn
+	newCall.Line = 0
+	newCall.FramePointer = prgrm.StackPointer
+	newCall.Deferred = nil
+	newCall.Panic = nil
+	// the stack pointer is moved to create room for the next call
+	// prgrm.MemoryPointer += fn.Size
+	prgrm.StackPointer += newCall.Operator.Size
+
+	// checking if enough memory in stack
+	if stackStart, stackLimit := prgrm.stackBounds(); prgrm.StackPointer > stackLimit {
+		panic(MemoryError{
+			Kind:      STACK_OVERFLOW_ERROR,
+			Requested: newCall.Operator.Size,
+			Used:      newCall.FramePointer - stackStart,
+			Limit:     stackLimit - stackStart,
+		})
+	}
 
-			// wiping next stack frame (removing garbage)
-			for c := 0; c < expr.Operator.Size; c++ {
-				prgrm.Memory[newFP+c] = 0
-			}
+	newFP := newCall.FramePointer
 
-			for i, inp := range expr.Inputs {
-				var byts []byte
-				// finalOffset := inp.Offset
-				finalOffset := GetFinalOffset(fp, inp)
-				// finalOffset := fp + inp.Offset
-
-				// if inp.Indexes != nil {
-				// 	finalOffset = GetFinalOffset(&prgrm.Stacks[0], fp, inp)
-				// }
-				if inp.PassBy == PASSBY_REFERENCE {
-					byts = encoder.Serialize(int32(finalOffset))
-				} else {
-					byts = prgrm.Memory[finalOffset : finalOffset+inp.TotalSize]
-				}
+	// wiping next stack frame (removing garbage)
+	for c := 0; c < fn.Size; c++ {
+		prgrm.Memory[newFP+c] = 0
+	}
 
-				// writing inputs to new stack frame
-				WriteMemory(
-					GetFinalOffset(newFP, newCall.Operator.Inputs[i]),
-					// newFP + newCall.Operator.Inputs[i].Offset,
-					// GetFinalOffset(prgrm.Memory, newFP, newCall.Operator.Inputs[i], MEM_WRITE),
-					byts)
-			}
+	writeInputs(prgrm, expr, fn, closure, fp, newFP)
+}
+
+// writes the inputs of `expr`, read from the frame at `fp`, to the frame of
+// `fn` at `newFP`, which is the function called by `expr`. If it's called
+// through the function value at `closure`, the first input is the value and
+// the variables it captured are written too
+func writeInputs(prgrm *CXProgram, expr *CXExpression, fn *CXFunction, closure int, fp int, newFP int) {
+	inputs := expr.Inputs
+	if closure >= 0 {
+		inputs = inputs[1:]
+		writeCaptures(fn, closure, newFP)
+	}
+
+	for i, inp := range inputs {
+		if fn.Inputs[i].IsVariadic && !expr.IsSpreadCall {
+			// the rest of the inputs are passed as a slice
+			WriteMemory(GetFinalOffset(newFP, fn.Inputs[i]), encoder.SerializeAtomic(variadicSlice(fp, inputs[i:], fn.Inputs[i])))
+			break
+		}
+
+		var byts []byte
+		// finalOffset := inp.Offset
+		finalOffset := GetFinalOffset(fp, inp)
+		// finalOffset := fp + inp.Offset
+
+		// if inp.Indexes != nil {
+		// 	finalOffset = GetFinalOffset(&prgrm.Stacks[0], fp, inp)
+		// }
+		if i == 0 && fn != expr.Operator && IsInterfaceMethod(expr.Operator) {
+			// the receiver is the value held by the interface value
+			byts = interfaceReceiver(finalOffset, expr.Operator, fn.Inputs[0])
+		} else if writeInterfaceValue(fp, inp, newFP, fn.Inputs[i]) {
+			continue
+		} else if inp.PassBy == PASSBY_REFERENCE {
+			byts = encoder.Serialize(int32(finalOffset))
+		} else {
+			byts = prgrm.Memory[finalOffset : finalOffset+inp.TotalSize]
 		}
+
+		// writing inputs to new stack frame
+		WriteMemory(
+			GetFinalOffset(newFP, fn.Inputs[i]),
+			// newFP + expr.Operator.Inputs[i].Offset,
+			// GetFinalOffset(prgrm.Memory, newFP, expr.Operator.Inputs[i], MEM_WRITE),
+			byts)
 	}
-	return nil
 }
@@ -0,0 +1,435 @@
+package base
+
+import (
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// The garbage collector is a precise mark-compact collector. The roots are
+// the globals of every package and the symbols in `ListOfPointers` of every
+// call in the call stack of every thread. From these roots, the collector follows the
+// references stored in heap objects (pointed values, slice elements, map
+// entries, buffered channel elements, struct fields, strings, closures and
+// the variables they captured, and the values held by interface values),
+// using the declaration specifiers of each argument to know what every heap
+// object contains.
+//
+// Collections happen in between expressions (see `ccall`), where every
+// reference lives either in a stack frame or in a global. `AllocateSeq`
+// only requests them: if the heap can't grow, the memory is overdrawn until
+// the collection, which reports the heap as exhausted if the live objects
+// still don't fit in it.
+
+const (
+	gcMarked  = 1 // reached while marking
+	gcUpdated = 2 // its references were already updated
+)
+
+// starting offsets of the objects in the heap, in ascending order
+var heapObjects []int
+
+// HasPointers checks if a value of the type of `arg` can hold a reference
+// to a heap object
+func HasPointers(arg *CXArgument) bool {
+	if arg.IsPointer || arg.IsSlice || arg.IsMap || arg.IsChan || arg.IsCaptured || arg.Type == TYPE_STR || arg.Type == TYPE_AFF || arg.Type == TYPE_FUNC {
+		return true
+	}
+	if arg.CustomType != nil && arg.CustomType.IsInterface {
+		return true
+	}
+	if arg.CustomType != nil {
+		for _, fld := range arg.CustomType.Fields {
+			if fld.CustomType != arg.CustomType && HasPointers(fld) {
+				return true
+			}
+			if fld.CustomType == arg.CustomType && fld.IsPointer {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// returns the declaration specifiers of `arg`, reconstructing them from
+// the rest of its fields if `arg` was not declared using a type (e.g. the
+// temporary variables created by the parser). A captured variable is
+// treated as a pointer to its value
+func gcDeclarationSpecifiers(arg *CXArgument) []int {
+	if arg.IsCaptured {
+		specs := append([]int{}, gcValueSpecifiers(arg)...)
+		return append(specs, DECL_POINTER)
+	}
+	return gcValueSpecifiers(arg)
+}
+
+func gcValueSpecifiers(arg *CXArgument) []int {
+	if len(arg.DeclarationSpecifiers) > 0 {
+		return arg.DeclarationSpecifiers
+	}
+
+	var specs []int
+	if arg.CustomType != nil && arg.Type == TYPE_CUSTOM {
+		specs = append(specs, DECL_STRUCT)
+	} else {
+		specs = append(specs, DECL_BASIC)
+	}
+
+	arrays := len(arg.Lengths)
+	if arg.IsSlice {
+		arrays--
+	}
+	for c := 0; c < arrays; c++ {
+		specs = append(specs, DECL_ARRAY)
+	}
+
+	if arg.IsPointer && (arg.Type != TYPE_STR || arg.IndirectionLevels > 0) {
+		specs = append(specs, DECL_POINTER)
+		for c := 1; c < arg.IndirectionLevels; c++ {
+			specs = append(specs, DECL_POINTER)
+		}
+	}
+	if arg.IsSlice {
+		specs = append(specs, DECL_SLICE)
+	}
+	if arg.IsMap {
+		specs = append(specs, DECL_MAP)
+	}
+	if arg.IsChan {
+		specs = append(specs, DECL_CHAN)
+	}
+
+	return specs
+}
+
+// returns the length of the array declared by `specs[i]`
+func gcArrayLength(arg *CXArgument, specs []int, i int) int {
+	var k int
+	for _, spec := range specs[i+1:] {
+		if spec == DECL_ARRAY || spec == DECL_SLICE {
+			k++
+		}
+	}
+	if k < len(arg.Lengths) {
+		return arg.Lengths[k]
+	}
+	return 0
+}
+
+// returns the size of a value of the type declared by `specs[:i+1]`
+func gcSizeOf(arg *CXArgument, specs []int, i int) int {
+	if i < 0 {
+		return GetArgSize(arg.Type)
+	}
+
+	switch specs[i] {
+	case DECL_POINTER, DECL_SLICE, DECL_MAP, DECL_CHAN:
+		return TYPE_POINTER_SIZE
+	case DECL_ARRAY:
+		return gcArrayLength(arg, specs, i) * gcSizeOf(arg, specs, i-1)
+	case DECL_STRUCT:
+		return arg.CustomType.Size
+	default:
+		return GetArgSize(arg.Type)
+	}
+}
+
+func objectSize(obj int) int {
+	var size int32
+	off := obj + MARK_SIZE + FORWARDING_ADDRESS_SIZE
+	encoder.DeserializeAtomic(PROGRAM.Memory[off:off+OBJECT_SIZE], &size)
+	return int(size)
+}
+
+func forwardingAddress(obj int) int {
+	return readPointer(obj + MARK_SIZE)
+}
+
+func indexHeapObjects() {
+	heapObjects = heapObjects[:0]
+	for c := PROGRAM.HeapStartsAt; c < PROGRAM.HeapStartsAt+PROGRAM.HeapPointer; c += OBJECT_HEADER_SIZE + objectSize(c) {
+		heapObjects = append(heapObjects, c)
+	}
+}
+
+// returns the starting offset of the heap object that contains `ptr`, or
+// -1 if `ptr` does not point to the heap (e.g. nil, or a string literal in
+// the data segment)
+func findObject(ptr int) int {
+	if ptr < PROGRAM.HeapStartsAt || ptr >= PROGRAM.HeapStartsAt+PROGRAM.HeapPointer {
+		return -1
+	}
+	i := sort.Search(len(heapObjects), func(i int) bool {
+		return heapObjects[i] > ptr
+	})
+	return heapObjects[i-1]
+}
+
+// visits the reference stored at `offset`. When updating, the reference is
+// replaced by the address its object is going to be moved to. It returns the
+// object it references if the object has not been visited before, or -1
+func gcVisitReference(offset int, update bool) int {
+	ptr, obj := gcVisitPointer(readPointer(offset), update)
+	if update {
+		WriteMemory(offset, encoder.SerializeAtomic(int32(ptr)))
+	}
+	return obj
+}
+
+// visits the reference `ptr`, returning the address it has to be updated to
+// and, like gcVisitReference, the object it references
+func gcVisitPointer(ptr int, update bool) (int, int) {
+	obj := findObject(ptr)
+	if obj < 0 {
+		return ptr, -1
+	}
+
+	if update {
+		ptr = forwardingAddress(obj) + ptr - obj
+		if PROGRAM.Memory[obj] == gcUpdated {
+			return ptr, -1
+		}
+		PROGRAM.Memory[obj] = gcUpdated
+	} else {
+		if PROGRAM.Memory[obj] == gcMarked {
+			return ptr, -1
+		}
+		PROGRAM.Memory[obj] = gcMarked
+	}
+
+	return ptr, obj
+}
+
+// visits the variables captured by the closure at `obj`, which are the last
+// inputs of its function
+func gcVisitCaptures(obj int, update bool) {
+	fn := closureFunc(obj)
+	captures := closureCaptures(obj)
+	for c, capt := range fn.Inputs[len(fn.Inputs)-captures:] {
+		specs := gcDeclarationSpecifiers(capt)
+		gcVisitValue(closureCapture(obj, c), capt, specs, len(specs)-1, update)
+	}
+}
+
+// visits the value stored at `offset`, which is of the type declared by
+// `specs[:i+1]`, and everything it references
+func gcVisitValue(offset int, arg *CXArgument, specs []int, i int, update bool) {
+	if i < 0 || specs[i] == DECL_BASIC {
+		if arg.Type == TYPE_STR || arg.Type == TYPE_AFF {
+			gcVisitReference(offset, update)
+		}
+		if arg.Type == TYPE_FUNC {
+			if obj := gcVisitReference(offset, update); obj >= 0 {
+				gcVisitCaptures(obj, update)
+			}
+		}
+		return
+	}
+
+	switch specs[i] {
+	case DECL_STRUCT:
+		if arg.CustomType == nil {
+			return
+		}
+		if arg.CustomType.IsInterface {
+			gcVisitInterface(offset, update)
+			return
+		}
+		off := offset
+		for _, fld := range arg.CustomType.Fields {
+			fldSpecs := gcDeclarationSpecifiers(fld)
+			gcVisitValue(off, fld, fldSpecs, len(fldSpecs)-1, update)
+			off += fld.TotalSize
+		}
+	case DECL_ARRAY:
+		size := gcSizeOf(arg, specs, i-1)
+		for c := 0; c < gcArrayLength(arg, specs, i); c++ {
+			gcVisitValue(offset+c*size, arg, specs, i-1, update)
+		}
+	case DECL_POINTER:
+		if obj := gcVisitReference(offset, update); obj >= 0 {
+			gcVisitValue(obj+OBJECT_HEADER_SIZE, arg, specs, i-1, update)
+		}
+	case DECL_SLICE:
+		if obj := gcVisitReference(offset, update); obj >= 0 {
+			if readSliceField(obj, sliceElts) != NULL_HEAP_ADDRESS {
+				// the elements are shared with the slice whose
+				// object they're stored in, which is visited instead
+				gcVisitValue(obj+OBJECT_HEADER_SIZE+sliceElts*I32_SIZE, arg, specs, i, update)
+				return
+			}
+
+			// the size of the elements is taken from the object, as
+			// `append` uses the size of the appended argument. Every
+			// element up to the capacity is visited, as the slices
+			// sharing them can have a greater length
+			_, capacity := readSliceHeader(obj)
+			size := gcSizeOf(arg, specs, i-1)
+			if capacity > 0 {
+				size = (objectSize(obj) - SLICE_HEADER_SIZE) / capacity
+			}
+			for c := 0; c < capacity; c++ {
+				gcVisitValue(obj+OBJECT_HEADER_SIZE+SLICE_HEADER_SIZE+c*size, arg, specs, i-1, update)
+			}
+		}
+	case DECL_MAP:
+		if obj := gcVisitReference(offset, update); obj >= 0 {
+			_, _, keyType, _ := readMapHeader(obj)
+			// deleted entries have zeroed values, but their keys are kept
+			for c := 0; c < mapUsed(obj); c++ {
+				if keyType == TYPE_STR {
+					gcVisitReference(mapEntry(obj, c), update)
+				}
+				gcVisitValue(mapEntry(obj, c)+GetArgSize(keyType), arg, specs, i-1, update)
+			}
+		}
+	case DECL_CHAN:
+		if obj := gcVisitReference(offset, update); obj >= 0 {
+			for c := 0; c < readChanField(obj, chanLen); c++ {
+				gcVisitValue(chanSlot(obj, c), arg, specs, i-1, update)
+			}
+		}
+	}
+}
+
+func gcVisitRoots(update bool) {
+	for _, pkg := range PROGRAM.Packages {
+		for _, glbl := range pkg.Globals {
+			specs := gcDeclarationSpecifiers(glbl)
+			gcVisitValue(glbl.Offset, glbl, specs, len(specs)-1, update)
+		}
+	}
+
+	for i, ptr := range pinnedFunctions {
+		var obj int
+		pinnedFunctions[i], obj = gcVisitPointer(ptr, update)
+		if obj >= 0 {
+			gcVisitCaptures(obj, update)
+		}
+	}
+
+	if len(PROGRAM.Threads) == 0 {
+		gcVisitCallStack(PROGRAM.CallStack, PROGRAM.CallCounter, update)
+		return
+	}
+	PROGRAM.saveThread()
+	for _, thread := range PROGRAM.Threads {
+		gcVisitCallStack(thread.CallStack, thread.CallCounter, update)
+	}
+}
+
+func gcVisitCallStack(callStack []CXCall, callCounter int, update bool) {
+	for c := 0; c <= callCounter; c++ {
+		call := &callStack[c]
+		if call.Operator == nil {
+			continue
+		}
+		for _, ptr := range call.Operator.ListOfPointers {
+			specs := gcDeclarationSpecifiers(ptr)
+			gcVisitValue(call.FramePointer+ptr.Offset, ptr, specs, len(specs)-1, update)
+		}
+		for i := range call.Deferred {
+			gcVisitDeferredCall(call, &call.Deferred[i], update)
+		}
+	}
+}
+
+// visits the arguments saved for a deferred call, see op_panic.go
+func gcVisitDeferredCall(call *CXCall, deferred *DeferredCall, update bool) {
+	var obj int
+	deferred.Args, obj = gcVisitPointer(deferred.Args, update)
+	if obj < 0 {
+		return
+	}
+
+	off := obj + OBJECT_HEADER_SIZE
+	for _, arg := range deferredArguments(call.Operator.Expressions[deferred.Line]) {
+		specs := gcDeclarationSpecifiers(arg)
+		gcVisitValue(off, arg, specs, len(specs)-1, update)
+		off += arg.TotalSize
+	}
+}
+
+// MarkAndCompact marks the heap objects reachable from the roots, and then
+// slides them to the start of the heap, updating every reference to them
+func MarkAndCompact() {
+	indexHeapObjects()
+
+	// marking
+	gcVisitRoots(false)
+
+	// setting forwarding addresses
+	var newHeapPointer int
+	for _, obj := range heapObjects {
+		if PROGRAM.Memory[obj] == 0 {
+			continue
+		}
+		WriteMemory(obj+MARK_SIZE, encoder.SerializeAtomic(int32(PROGRAM.HeapStartsAt+newHeapPointer)))
+		newHeapPointer += OBJECT_HEADER_SIZE + objectSize(obj)
+	}
+
+	// updating references
+	gcVisitRoots(true)
+
+	// relocation of live objects
+	for _, obj := range heapObjects {
+		if PROGRAM.Memory[obj] == 0 {
+			continue
+		}
+		fwd := forwardingAddress(obj)
+		size := OBJECT_HEADER_SIZE + objectSize(obj)
+		copy(PROGRAM.Memory[fwd:fwd+size], PROGRAM.Memory[obj:obj+size])
+		PROGRAM.Memory[fwd] = 0
+	}
+
+	// wiping the freed memory
+	heapEnd := PROGRAM.HeapStartsAt + PROGRAM.HeapPointer
+	for c := PROGRAM.HeapStartsAt + newHeapPointer; c < heapEnd; c++ {
+		PROGRAM.Memory[c] = 0
+	}
+
+	PROGRAM.HeapPointer = newHeapPointer
+
+	// giving back the memory overdrawn by AllocateSeq
+	if maxMemSize := PROGRAM.StackSize + TYPE_POINTER_SIZE + PROGRAM.MaxHeapSize; len(PROGRAM.Memory) > maxMemSize {
+		if PROGRAM.HeapStartsAt+newHeapPointer > maxMemSize {
+			panic(MemoryError{
+				Kind:      HEAP_EXHAUSTED_ERROR,
+				Requested: PROGRAM.gcOverdraft,
+				Used:      PROGRAM.HeapStartsAt + newHeapPointer - PROGRAM.gcOverdraft - PROGRAM.StackSize - TYPE_POINTER_SIZE,
+				Limit:     PROGRAM.MaxHeapSize,
+			})
+		}
+		PROGRAM.Memory = PROGRAM.Memory[:maxMemSize]
+	}
+	PROGRAM.gcOverdraft = 0
+
+	// if less than half of the heap is free, we make it bigger
+	heapSize := len(PROGRAM.Memory) - PROGRAM.HeapStartsAt
+	if newHeapPointer > heapSize/2 {
+		PROGRAM.GrowMemory(PROGRAM.HeapStartsAt + 2*newHeapPointer)
+		heapSize = len(PROGRAM.Memory) - PROGRAM.HeapStartsAt
+	}
+
+	// the next collection is requested when half of the free heap is used
+	PROGRAM.gcThreshold = newHeapPointer + (heapSize-newHeapPointer)/2
+	PROGRAM.gcRequested = false
+}
+
+// requests a collection at the next safe point if half of the free heap
+// was used since the last one
+func requestCollection() {
+	if PROGRAM.gcThreshold == 0 {
+		PROGRAM.gcThreshold = (len(PROGRAM.Memory) - PROGRAM.HeapStartsAt) / 2
+	}
+	if PROGRAM.HeapPointer > PROGRAM.gcThreshold {
+		PROGRAM.gcRequested = true
+	}
+}
+
+// CollectGarbage runs a collection if `AllocateSeq` requested one
+func CollectGarbage() {
+	if PROGRAM.gcRequested {
+		MarkAndCompact()
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"time"
 	"net/http"
 	"io/ioutil"
-	"github.com/skycoin/skycoin/src/cipher/encoder"
 )
 
 const (
@@ -18,47 +17,26 @@ const (
 )
 
 func op_http_get(expr *CXExpression, fp int) {
-	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
-	out1Offset := GetFinalOffset(fp, out1)
-
-	var err error
-	var resp *http.Response
-	var contents []byte
+	inp1, out1, out2 := expr.Inputs[0], expr.Outputs[0], expr.Outputs[1]
 
 	var netClient = &http.Client{
 		Timeout: time.Second * 10,
 	}
-	resp, err = netClient.Get(ReadStr(fp, inp1))
-	
-	// resp, err = http.Get(ReadStr(mem, fp, inp1))
 
+	contents, err := httpGet(netClient, ReadStr(fp, inp1))
+	writeString(expr, fp, string(contents), out1)
+	writeError(expr, fp, err, out2)
+}
+
+// returns the body of the response to a GET request to `url`
+func httpGet(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-
 	defer resp.Body.Close()
-        contents, err = ioutil.ReadAll(resp.Body)
-        if err != nil {
-		panic(err)
-        }
-
-	byts := encoder.Serialize(string(contents))
-	length := len(byts)
-	heapOffset := AllocateSeq(length+OBJECT_HEADER_SIZE)
-	size := encoder.Serialize(int32(len(byts)))
-
-	var header []byte = make([]byte, OBJECT_HEADER_SIZE, OBJECT_HEADER_SIZE)
-	for c := 5; c < OBJECT_HEADER_SIZE; c++ {
-		header[c] = size[c-5]
-	}
-
-	obj := append(header, byts...)
-
-	WriteMemory(heapOffset, obj)
-
-	off := encoder.SerializeAtomic(int32(heapOffset))
 
-	WriteMemory(out1Offset, off)
+	return ioutil.ReadAll(resp.Body)
 }
 
 
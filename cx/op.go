@@ -5,19 +5,58 @@ import (
 	"github.com/skycoin/skycoin/src/cipher/encoder"
 )
 
+// IndexError is raised when an array or a slice is indexed out of its range
+type IndexError struct {
+	Index  int
+	Length int
+}
+
+func (err IndexError) Error() string {
+	return fmt.Sprintf("%s [%d] with length %d", INDEX_OUT_OF_RANGE_ERROR, err.Index, err.Length)
+}
+
+// returns the length of the dimension `i` of the array or slice `arg`. The
+// length of a slice, or of a slice nested in it, is read from the header of
+// its heap object `sliceObj`, and it's false if the length is unknown
+func indexLength (arg *CXArgument, i int, sliceObj int, isPointer bool) (int, bool) {
+	if arg.IsSlice && isPointer && arg.Lengths[i] == 0 {
+		if sliceObj == NULL_HEAP_ADDRESS {
+			// nil slice
+			return 0, true
+		}
+		var sliceLen int32
+		encoder.DeserializeAtomic(PROGRAM.Memory[sliceObj + OBJECT_HEADER_SIZE : sliceObj + OBJECT_HEADER_SIZE + TYPE_POINTER_SIZE], &sliceLen)
+		return int(sliceLen), true
+	}
+	if i < len(arg.Lengths) && arg.Lengths[i] > 0 {
+		return arg.Lengths[i], true
+	}
+	return 0, false
+}
+
 func CalculateDereferences (arg *CXArgument, finalOffset *int, fp int, dbg bool) {
 	var isPointer bool
+	// the heap object of the slice being indexed
+	var sliceObj int
+	// if an element was indexed, and if it is a pointer that was dereferenced
+	var indexed, eltPointer bool
 	for _, op := range arg.DereferenceOperations {
 		switch op {
 		case DEREF_ARRAY:
+			indexed = true
 			for i, idxArg := range arg.Indexes {
-				var subSize int = 1
-				for _, len := range arg.Lengths[i+1:] {
-					subSize *= len
+				if i > 0 && arg.IsSlice && arg.Lengths[i] == 0 {
+					// then the element of the previous dimension is a
+					// nested slice, holding the address of its heap object
+					sliceObj = readPointer(*finalOffset)
+					*finalOffset = sliceElements(sliceObj)
 				}
 
 				var sizeToUse int
-				if arg.CustomType != nil {
+				if isPointerElement(arg, len(arg.Lengths)) {
+					// the elements are pointers, e.g. those of a `[]*Circle`
+					sizeToUse = TYPE_POINTER_SIZE
+				} else if arg.CustomType != nil {
 					sizeToUse = arg.CustomType.Size
 				} else if arg.IsSlice {
 					sizeToUse = arg.TotalSize
@@ -25,8 +64,28 @@ func CalculateDereferences (arg *CXArgument, finalOffset *int, fp int, dbg bool)
 					sizeToUse = arg.Size
 				}
 
-				*finalOffset += int(ReadI32(fp, idxArg)) * subSize * sizeToUse
+				var subSize int = 1
+				for _, len := range arg.Lengths[i+1:] {
+					if arg.IsSlice && len == 0 {
+						// the elements hold nested slices, which are
+						// the addresses of their heap objects
+						sizeToUse = TYPE_POINTER_SIZE
+						break
+					}
+					subSize *= len
+				}
+
+				idx := int(ReadI32(fp, idxArg))
+				if length, ok := indexLength(arg, i, sliceObj, isPointer); ok && (idx < 0 || idx >= length) {
+					panic(IndexError{Index: idx, Length: length})
+				}
+
+				*finalOffset += idx * subSize * sizeToUse
 			}
+		case DEREF_MAP, DEREF_MAP_INSERT:
+			*finalOffset = MapIndex(arg, *finalOffset, fp, op == DEREF_MAP_INSERT)
+		case DEREF_MAP_KEY, DEREF_MAP_VALUE:
+			*finalOffset = MapEntry(arg, *finalOffset, fp, op == DEREF_MAP_KEY)
 		case DEREF_POINTER:
 			isPointer = true
 			var offset int32
@@ -36,6 +95,21 @@ func CalculateDereferences (arg *CXArgument, finalOffset *int, fp int, dbg bool)
 
 			encoder.DeserializeAtomic(byts, &offset)
 			*finalOffset = int(offset)
+
+			if indexed {
+				// an element of a slice or array of pointers, e.g. `ps[0].r`
+				eltPointer = true
+				if *finalOffset == NULL_HEAP_ADDRESS {
+					panic(NIL_POINTER_ERROR)
+				}
+			} else if arg.IsSlice {
+				// the slice is indexed from its elements
+				sliceObj = *finalOffset
+				*finalOffset = sliceElements(sliceObj)
+			} else if *finalOffset == NULL_HEAP_ADDRESS {
+				// nil slices are handled by the bounds check
+				panic(NIL_POINTER_ERROR)
+			}
 		}
 		if dbg {
 			fmt.Println("\tupdate", arg.Name, arg.DereferenceOperations, *finalOffset, PROGRAM.Memory[*finalOffset:*finalOffset+10])
@@ -46,12 +120,9 @@ func CalculateDereferences (arg *CXArgument, finalOffset *int, fp int, dbg bool)
 	}
 
 	// if *finalOffset >= PROGRAM.HeapStartsAt {
-	if *finalOffset >= PROGRAM.HeapStartsAt && isPointer {
+	if *finalOffset >= PROGRAM.HeapStartsAt && isPointer && (!arg.IsSlice || eltPointer) {
 		// then it's an object
 		*finalOffset += OBJECT_HEADER_SIZE
-		if arg.IsSlice {
-			*finalOffset += SLICE_HEADER_SIZE
-		}
 	}
 }
 
@@ -68,10 +139,15 @@ func GetFinalOffset(fp int, arg *CXArgument) int {
 		dbg = false
 	}
 
-	if finalOffset < STACK_SIZE {
+	if finalOffset < PROGRAM.StackSize {
 		// then it's in the stack, not in data or heap
 		finalOffset += fp
 	}
+
+	if arg.IsCaptured {
+		// then the stack only holds the address of the variable's heap object
+		finalOffset = readPointer(finalOffset) + OBJECT_HEADER_SIZE
+	}
 	
 	if dbg {
 		fmt.Println("(start", arg.Name, fmt.Sprintf("%s:%d", arg.FileName, arg.FileLine), finalOffset, arg.DereferenceOperations)
@@ -92,108 +168,75 @@ func GetFinalOffset(fp int, arg *CXArgument) int {
 	return finalOffset
 }
 
+// reads the pointer stored at `offset`
+func readPointer(offset int) int {
+	var ptr int32
+	encoder.DeserializeAtomic(PROGRAM.Memory[offset:offset+TYPE_POINTER_SIZE], &ptr)
+	return int(ptr)
+}
+
 func ReadMemory(offset int, arg *CXArgument) []byte {
 	return PROGRAM.Memory[offset : offset+arg.TotalSize]
 }
 
-// marks all the alive objects in the heap
-func Mark(prgrm *CXProgram) {
-	fp := 0
-	for c := 0; c <= prgrm.CallCounter; c++ {
-		op := prgrm.CallStack[c].Operator
-
-		for _, ptr := range op.ListOfPointers {
-			var heapOffset int32
-			encoder.DeserializeAtomic(prgrm.Memory[fp+ptr.Offset:fp+ptr.Offset+TYPE_POINTER_SIZE], &heapOffset)
+// allocates memory in the heap. It never collects garbage, as natives keep
+// working with the offsets they already computed; the collection is requested
+// for the next safe point instead
+func AllocateSeq(size int) (offset int) {
+	result := PROGRAM.HeapStartsAt + PROGRAM.HeapPointer
 
-			prgrm.Memory[heapOffset] = 1
+	if result+size > len(PROGRAM.Memory) && !PROGRAM.GrowMemory(result+size) {
+		if size > PROGRAM.MaxHeapSize {
+			// it wouldn't fit even in an empty heap
+			panic(MemoryError{
+				Kind:      HEAP_EXHAUSTED_ERROR,
+				Requested: size,
+				Used:      result - PROGRAM.StackSize - TYPE_POINTER_SIZE,
+				Limit:     PROGRAM.MaxHeapSize,
+			})
 		}
-
-		fp += op.Size
+		// the heap can't grow anymore, so the memory is overdrawn until
+		// the collection, which reports the heap as exhausted if the live
+		// objects still don't fit in it
+		if PROGRAM.gcOverdraft == 0 {
+			PROGRAM.gcOverdraft = size
+		}
+		PROGRAM.Memory = append(PROGRAM.Memory, make([]byte, result+size-len(PROGRAM.Memory))...)
+		PROGRAM.gcRequested = true
 	}
-}
-
-func MarkAndCompact() {
-	var fp int
-	var faddr int32 = NULL_HEAP_ADDRESS_OFFSET
 
-	// marking, setting forward addresses and updating references
-	for c := 0; c <= PROGRAM.CallCounter; c++ {
-		op := PROGRAM.CallStack[c].Operator
+	PROGRAM.HeapPointer += size
 
-		for _, ptr := range op.ListOfPointers {
-			var heapOffset int32
-			encoder.DeserializeAtomic(PROGRAM.Memory[fp+ptr.Offset:fp+ptr.Offset+TYPE_POINTER_SIZE], &heapOffset)
+	requestCollection()
 
-			if heapOffset == NULL_HEAP_ADDRESS {
-				continue
-			}
-
-			// marking as alive
-			PROGRAM.Memory[heapOffset] = 1
-
-			for i, byt := range encoder.SerializeAtomic(faddr) {
-				// setting forwarding address
-				PROGRAM.Memory[int(heapOffset)+MARK_SIZE+i] = byt
-				// updating reference
-				PROGRAM.Memory[fp+ptr.Offset+i] = byt
-			}
-
-			var objSize int32
-			encoder.DeserializeAtomic(PROGRAM.Memory[int(heapOffset)+MARK_SIZE+TYPE_POINTER_SIZE:int(heapOffset)+MARK_SIZE+TYPE_POINTER_SIZE+OBJECT_SIZE], &objSize)
-
-			faddr += int32(OBJECT_HEADER_SIZE) + objSize
-		}
+	return result
+}
 
-		fp += op.Size
+// GrowMemory makes memory big enough to hold `memSize` bytes. The heap grows
+// to at least twice its size, without going over `MaxHeapSize`. It returns
+// false if `memSize` bytes don't fit in the biggest heap allowed
+func (prgrm *CXProgram) GrowMemory(memSize int) bool {
+	if memSize <= len(prgrm.Memory) {
+		return true
 	}
 
-	// relocation of live objects
-	newHeapPointer := NULL_HEAP_ADDRESS_OFFSET
-	for c := NULL_HEAP_ADDRESS_OFFSET; c < PROGRAM.HeapPointer; {
-		var forwardingAddress int32
-		encoder.DeserializeAtomic(PROGRAM.Memory[PROGRAM.HeapStartsAt + c + MARK_SIZE : PROGRAM.HeapStartsAt + c + MARK_SIZE + FORWARDING_ADDRESS_SIZE], &forwardingAddress)
-
-		var objSize int32
-		encoder.DeserializeAtomic(PROGRAM.Memory[PROGRAM.HeapStartsAt + c + MARK_SIZE + FORWARDING_ADDRESS_SIZE : PROGRAM.HeapStartsAt + c + MARK_SIZE + FORWARDING_ADDRESS_SIZE + OBJECT_SIZE], &objSize)
-
-		if PROGRAM.Memory[c] == 1 {
-			// setting the mark back to 0
-			PROGRAM.Memory[c] = 0
-			// then it's alive and we'll relocate the object
-			for i := int32(0); i < OBJECT_HEADER_SIZE+objSize; i++ {
-				PROGRAM.Memory[forwardingAddress+i] = PROGRAM.Memory[int32(c)+i]
-			}
-			newHeapPointer += OBJECT_HEADER_SIZE + int(objSize)
-		}
-
-		c += OBJECT_HEADER_SIZE + int(objSize)
+	heapStart := prgrm.StackSize + TYPE_POINTER_SIZE
+	maxMemSize := heapStart + prgrm.MaxHeapSize
+	if memSize > maxMemSize {
+		return false
 	}
 
-	PROGRAM.HeapPointer = newHeapPointer
-}
-
-// allocates memory in the heap
-func AllocateSeq(size int) (offset int) {
-	result := PROGRAM.HeapStartsAt + PROGRAM.HeapPointer
-	newFree := PROGRAM.HeapPointer + size
-
-	// if newFree > MEMORY_SIZE {
-	if result + size > MEMORY_SIZE {
-		// call GC
-		MarkAndCompact()
-		result = PROGRAM.HeapStartsAt + PROGRAM.HeapPointer
-		newFree = PROGRAM.HeapPointer + size
-
-		if result + size > MEMORY_SIZE {
-			// heap exhausted
-			panic(HEAP_EXHAUSTED_ERROR)
-		}
+	newMemSize := heapStart + 2*(len(prgrm.Memory)-heapStart)
+	if newMemSize < memSize {
+		newMemSize = memSize
+	}
+	if newMemSize > maxMemSize {
+		newMemSize = maxMemSize
 	}
 
-	PROGRAM.HeapPointer = newFree
+	prgrm.Memory = append(prgrm.Memory, make([]byte, newMemSize-len(prgrm.Memory))...)
 
-	return result
+	return true
 }
 
 func WriteMemory(offset int, byts []byte) {
@@ -224,14 +267,30 @@ func FromI8 (in int8) []byte {
 	return encoder.SerializeAtomic(in)
 }
 
+func FromI16 (in int16) []byte {
+	return encoder.SerializeAtomic(in)
+}
+
 func FromI32(in int32) []byte {
 	return encoder.SerializeAtomic(in)
 }
 
+func FromUI8 (in uint8) []byte {
+	return encoder.SerializeAtomic(in)
+}
+
+func FromUI16 (in uint16) []byte {
+	return encoder.SerializeAtomic(in)
+}
+
 func FromUI32(in uint32) []byte {
 	return encoder.SerializeAtomic(in)
 }
 
+func FromUI64 (in uint64) []byte {
+	return encoder.SerializeAtomic(in)
+}
+
 func FromI64(in int64) []byte {
 	return encoder.Serialize(in)
 }
@@ -287,6 +346,11 @@ func ReadStr(fp int, inp *CXArgument) (out string) {
 		encoder.DeserializeAtomic(PROGRAM.Memory[off : off + TYPE_POINTER_SIZE], &offset)
 	}
 
+	return readStrAt(offset)
+}
+
+// reads the string stored at `offset`
+func readStrAt(offset int32) (out string) {
 	if offset == 0 {
 		// then it's nil string
 		out = ""
@@ -308,6 +372,12 @@ func ReadI8 (fp int, inp *CXArgument) (out int8) {
 	return
 }
 
+func ReadI16 (fp int, inp *CXArgument) (out int16) {
+	offset := GetFinalOffset(fp, inp)
+	encoder.DeserializeAtomic(ReadMemory(offset, inp), &out)
+	return
+}
+
 func ReadI32(fp int, inp *CXArgument) (out int32) {
 	offset := GetFinalOffset(fp, inp)
 	encoder.DeserializeAtomic(ReadMemory(offset, inp), &out)
@@ -320,6 +390,30 @@ func ReadI64(fp int, inp *CXArgument) (out int64) {
 	return
 }
 
+func ReadUI8 (fp int, inp *CXArgument) (out uint8) {
+	offset := GetFinalOffset(fp, inp)
+	encoder.DeserializeAtomic(ReadMemory(offset, inp), &out)
+	return
+}
+
+func ReadUI16 (fp int, inp *CXArgument) (out uint16) {
+	offset := GetFinalOffset(fp, inp)
+	encoder.DeserializeAtomic(ReadMemory(offset, inp), &out)
+	return
+}
+
+func ReadUI32 (fp int, inp *CXArgument) (out uint32) {
+	offset := GetFinalOffset(fp, inp)
+	encoder.DeserializeAtomic(ReadMemory(offset, inp), &out)
+	return
+}
+
+func ReadUI64 (fp int, inp *CXArgument) (out uint64) {
+	offset := GetFinalOffset(fp, inp)
+	encoder.DeserializeAtomic(ReadMemory(offset, inp), &out)
+	return
+}
+
 func ReadF32(fp int, inp *CXArgument) (out float32) {
 	offset := GetFinalOffset(fp, inp)
 	encoder.DeserializeRaw(ReadMemory(offset, inp), &out)
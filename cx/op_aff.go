@@ -28,9 +28,8 @@ func GetInferActions (inp *CXArgument, fp int) []string {
 	var off int32
 	encoder.DeserializeAtomic(PROGRAM.Memory[inpOffset : inpOffset + TYPE_POINTER_SIZE], &off)
 
-	var l int32
-	_l := PROGRAM.Memory[off + OBJECT_HEADER_SIZE : off + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE]
-	encoder.DeserializeAtomic(_l[:4], &l)
+	l, _ := readSliceHeader(int(off))
+	elts := sliceElements(int(off))
 
 	result := make([]string, l)
 
@@ -38,7 +37,7 @@ func GetInferActions (inp *CXArgument, fp int) []string {
 	for c := 0; c < int(l); c++ {
 		var elOff int32
 		// encoder.DeserializeAtomic(PROGRAM.Memory[int(off) + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + (c - 1) * TYPE_POINTER_SIZE : int(off) + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + c * STR_HEADER_SIZE], &elOff)
-		encoder.DeserializeAtomic(PROGRAM.Memory[int(off) + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + c * TYPE_POINTER_SIZE : int(off) + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + (c + 1) * STR_HEADER_SIZE], &elOff)
+		encoder.DeserializeAtomic(PROGRAM.Memory[elts + c * TYPE_POINTER_SIZE : elts + (c + 1) * STR_HEADER_SIZE], &elOff)
 
 		var size int32
 		encoder.DeserializeAtomic(PROGRAM.Memory[elOff : elOff + STR_HEADER_SIZE], &size)
@@ -144,9 +143,9 @@ func queryParam (fn *CXFunction, args []*CXArgument, exprLbl string, argOffsetB
 
 		var typOffset int
 		elt := GetAssignmentElement(arg)
-		if elt.CustomType != nil {
+		if strct := ReceiverType(arg); strct != nil {
 			// then it's custom type
-			typOffset = WriteObjectRetOff(encoder.Serialize(elt.CustomType.Package.Name + "." + elt.CustomType.Name))
+			typOffset = WriteObjectRetOff(encoder.Serialize(strct.Package.Name + "." + strct.Name))
 		} else {
 			// then it's native type
 			typOffset = WriteObjectRetOff(encoder.Serialize(TypeNames[elt.Type]))
@@ -224,9 +223,9 @@ func getSignatureSlice (params []*CXArgument) int {
 	for _, param := range params {
 		
 		var typOffset int
-		if param.CustomType != nil {
+		if strct := ReceiverType(param); strct != nil {
 			// then it's custom type
-			typOffset = WriteObjectRetOff(encoder.Serialize(param.CustomType.Package.Name + "." + param.CustomType.Name))
+			typOffset = WriteObjectRetOff(encoder.Serialize(strct.Package.Name + "." + strct.Name))
 		} else {
 			// then it's native type
 			typOffset = WriteObjectRetOff(encoder.Serialize(TypeNames[param.Type]))
@@ -812,6 +811,9 @@ func op_aff_inform (expr *CXExpression, fp int) {
 	PROGRAM.CurrentPackage = prevPkg
 	PROGRAM.CurrentPackage.CurrentFunction = prevFn
 	PROGRAM.CurrentPackage.CurrentFunction.CurrentExpression = prevExpr
+
+	// the affordance could have replaced an argument with a wrong one
+	mustCheck(PROGRAM)
 }
 
 func op_aff_request (expr *CXExpression, fp int) {
@@ -932,6 +934,9 @@ func op_aff_request (expr *CXExpression, fp int) {
 	PROGRAM.CurrentPackage = prevPkg
 	PROGRAM.CurrentPackage.CurrentFunction = prevFn
 	PROGRAM.CurrentPackage.CurrentFunction.CurrentExpression = prevExpr
+
+	// the affordance could have changed an argument or a struct
+	mustCheck(PROGRAM)
 }
 
 func op_aff_query (expr *CXExpression, fp int) {
@@ -0,0 +1,323 @@
+package base
+
+import (
+	"math/rand"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// Channels are heap objects. After the object header, a channel stores its
+// length, its capacity, its id, the index of its first element in the
+// buffer, whether it's closed, the size of its elements and the number of
+// elements received from it so far (4 bytes each), and finally its buffer,
+// which is a ring of `cap` elements. Unbuffered channels have room for one
+// element, which a sender leaves there until a receiver takes it.
+//
+// Channels are identified by their id, as the garbage collector can move
+// them around. A thread that can't complete a channel operation calls
+// `BlockThread` and runs the operation again once it's woken up, which
+// happens every time another thread sends, receives or closes a channel.
+
+const (
+	chanLen = iota
+	chanCap
+	chanID
+	chanHead
+	chanClosed
+	chanElemSize
+	chanReceived
+)
+
+// number of channels made so far, used to give them their ids
+var chanCount int
+
+// allocates an empty channel with room for `capacity` elements
+func makeChan(elemSize int, capacity int) int {
+	slots := capacity
+	if slots < 1 {
+		slots = 1
+	}
+	size := CHAN_HEADER_SIZE + slots*elemSize
+	heapOffset := AllocateSeq(OBJECT_HEADER_SIZE + size)
+
+	chanCount++
+	obj := make([]byte, OBJECT_HEADER_SIZE+size)
+	copy(obj[OBJECT_GC_HEADER_SIZE:], encoder.SerializeAtomic(int32(size)))
+	WriteMemory(heapOffset, obj)
+
+	writeChanField(heapOffset, chanCap, capacity)
+	writeChanField(heapOffset, chanID, chanCount)
+	writeChanField(heapOffset, chanElemSize, elemSize)
+
+	return heapOffset
+}
+
+func readChanField(chanOffset int, field int) int {
+	var val int32
+	off := chanOffset + OBJECT_HEADER_SIZE + field*I32_SIZE
+	encoder.DeserializeAtomic(PROGRAM.Memory[off:off+I32_SIZE], &val)
+	return int(val)
+}
+
+func writeChanField(chanOffset int, field int, val int) {
+	WriteMemory(chanOffset+OBJECT_HEADER_SIZE+field*I32_SIZE, encoder.SerializeAtomic(int32(val)))
+}
+
+// offset of the element at index `idx` of the buffer of a channel
+func chanSlot(chanOffset int, idx int) int {
+	slots := readChanField(chanOffset, chanCap)
+	if slots < 1 {
+		slots = 1
+	}
+	idx = (readChanField(chanOffset, chanHead) + idx) % slots
+	return chanOffset + OBJECT_HEADER_SIZE + CHAN_HEADER_SIZE + idx*readChanField(chanOffset, chanElemSize)
+}
+
+// gets the bytes that represent `val` in the buffer of a channel
+func chanValue(fp int, val *CXArgument, elemSize int) []byte {
+	valOffset := GetFinalOffset(fp, val)
+
+	if val.Type == TYPE_STR && val.Name == "" {
+		// then it's a literal and the element is its address
+		return encoder.SerializeAtomic(int32(valOffset))
+	}
+
+	return PROGRAM.Memory[valOffset : valOffset+elemSize]
+}
+
+func chanPush(chanOffset int, val []byte) {
+	l := readChanField(chanOffset, chanLen)
+	WriteMemory(chanSlot(chanOffset, l), val)
+	writeChanField(chanOffset, chanLen, l+1)
+}
+
+// removes the first element of a channel, returning a copy of it
+func chanPop(chanOffset int) []byte {
+	elemSize := readChanField(chanOffset, chanElemSize)
+	slot := chanSlot(chanOffset, 0)
+
+	val := append([]byte{}, PROGRAM.Memory[slot:slot+elemSize]...)
+	// the element could be referencing a heap object
+	WriteMemory(slot, make([]byte, elemSize))
+
+	slots := readChanField(chanOffset, chanCap)
+	if slots < 1 {
+		slots = 1
+	}
+	writeChanField(chanOffset, chanHead, (readChanField(chanOffset, chanHead)+1)%slots)
+	writeChanField(chanOffset, chanLen, readChanField(chanOffset, chanLen)-1)
+	writeChanField(chanOffset, chanReceived, readChanField(chanOffset, chanReceived)+1)
+
+	return val
+}
+
+// checks if receiving from a channel would not block
+func chanCanReceive(chanOffset int) bool {
+	if chanOffset == NULL_HEAP_ADDRESS {
+		return false
+	}
+	return readChanField(chanOffset, chanLen) > 0 || readChanField(chanOffset, chanClosed) != 0
+}
+
+// checks if a select statement can send to a channel without blocking.
+// Sending to an unbuffered channel only completes when another thread is
+// waiting to receive from it
+func chanCanSelectSend(chanOffset int) bool {
+	if chanOffset == NULL_HEAP_ADDRESS {
+		return false
+	}
+	if readChanField(chanOffset, chanClosed) != 0 {
+		// then the send is going to panic
+		return true
+	}
+
+	l, c := readChanField(chanOffset, chanLen), readChanField(chanOffset, chanCap)
+	if c > 0 {
+		return l < c
+	}
+	if l > 0 {
+		return false
+	}
+
+	id := readChanField(chanOffset, chanID)
+	for i, thread := range PROGRAM.Threads {
+		if i == PROGRAM.CurrentThread {
+			continue
+		}
+		for _, receiving := range thread.receiving {
+			if receiving == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// registers the running thread as a receiver of a channel, so senders in
+// select statements know it's waiting
+func chanWaitReceive(chanOffset int) {
+	thread := PROGRAM.runningThread()
+	id := readChanField(chanOffset, chanID)
+	for _, receiving := range thread.receiving {
+		if receiving == id {
+			return
+		}
+	}
+	thread.receiving = append(thread.receiving, id)
+	// a sender in a select statement could be waiting for a receiver
+	PROGRAM.wakeThreads()
+	thread.Blocked = false
+}
+
+func op_make(expr *CXExpression, fp int) {
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+
+	elemSize := GetArgSize(out1.Type)
+	if out1.CustomType != nil && out1.Type == TYPE_CUSTOM {
+		elemSize = out1.CustomType.Size
+	}
+
+	capacity := int(ReadI32(fp, inp1))
+	if capacity < 0 {
+		panic("makechan: size out of range")
+	}
+
+	chanOffset := makeChan(elemSize, capacity)
+	WriteMemory(GetFinalOffset(fp, out1), encoder.SerializeAtomic(int32(chanOffset)))
+}
+
+func op_send(expr *CXExpression, fp int) {
+	inp1, inp2 := expr.Inputs[0], expr.Inputs[1]
+	thread := PROGRAM.runningThread()
+	chanOffset := readPointer(GetFinalOffset(fp, inp1))
+
+	if thread.sendSeq > 0 {
+		// then it already sent to an unbuffered channel, and it's
+		// waiting for a receiver to take the value
+		if chanOffset != NULL_HEAP_ADDRESS && readChanField(chanOffset, chanID) == thread.sendChan &&
+			readChanField(chanOffset, chanReceived) < thread.sendSeq {
+			PROGRAM.BlockThread("chan send")
+			return
+		}
+		thread.sendSeq = 0
+		return
+	}
+
+	if chanOffset == NULL_HEAP_ADDRESS {
+		PROGRAM.BlockThread("chan send (nil chan)")
+		return
+	}
+	if readChanField(chanOffset, chanClosed) != 0 {
+		panic(CLOSED_CHAN_SEND_ERROR)
+	}
+
+	l, c := readChanField(chanOffset, chanLen), readChanField(chanOffset, chanCap)
+	if c > 0 && l < c {
+		chanPush(chanOffset, chanValue(fp, inp2, readChanField(chanOffset, chanElemSize)))
+		PROGRAM.wakeThreads()
+		return
+	}
+	if c == 0 && l == 0 {
+		chanPush(chanOffset, chanValue(fp, inp2, readChanField(chanOffset, chanElemSize)))
+		PROGRAM.wakeThreads()
+		thread.sendChan = readChanField(chanOffset, chanID)
+		thread.sendSeq = readChanField(chanOffset, chanReceived) + 1
+	}
+
+	PROGRAM.BlockThread("chan send")
+}
+
+func op_recv(expr *CXExpression, fp int) {
+	inp1 := expr.Inputs[0]
+	thread := PROGRAM.runningThread()
+	chanOffset := readPointer(GetFinalOffset(fp, inp1))
+
+	if !chanCanReceive(chanOffset) {
+		if chanOffset == NULL_HEAP_ADDRESS {
+			PROGRAM.BlockThread("chan receive (nil chan)")
+			return
+		}
+		chanWaitReceive(chanOffset)
+		PROGRAM.BlockThread("chan receive")
+		return
+	}
+	thread.receiving = thread.receiving[:0]
+
+	var ok bool
+	var val []byte
+	if readChanField(chanOffset, chanLen) > 0 {
+		val = chanPop(chanOffset)
+		PROGRAM.wakeThreads()
+		ok = true
+	} else {
+		// then it's closed and empty, and we get the zero value
+		val = make([]byte, readChanField(chanOffset, chanElemSize))
+	}
+
+	// the value is discarded if it's not assigned
+	if len(expr.Outputs) > 0 {
+		WriteMemory(GetFinalOffset(fp, expr.Outputs[0]), val)
+	}
+	if len(expr.Outputs) > 1 {
+		WriteMemory(GetFinalOffset(fp, expr.Outputs[1]), FromBool(ok))
+	}
+}
+
+func op_close(expr *CXExpression, fp int) {
+	chanOffset := readPointer(GetFinalOffset(fp, expr.Inputs[0]))
+
+	if chanOffset == NULL_HEAP_ADDRESS {
+		panic(NIL_CHAN_CLOSE_ERROR)
+	}
+	if readChanField(chanOffset, chanClosed) != 0 {
+		panic(CLOSED_CHAN_CLOSE_ERROR)
+	}
+
+	writeChanField(chanOffset, chanClosed, 1)
+	PROGRAM.wakeThreads()
+}
+
+// op_select chooses a case of a select statement. Its inputs are whether the
+// statement has a default case, followed by a pair of inputs (is it a send,
+// channel) for every case. The chosen case, or the number of cases if the
+// default case is chosen, is written to its output, and its send or
+// receive is run right after this expression
+func op_select(expr *CXExpression, fp int) {
+	thread := PROGRAM.runningThread()
+	hasDefault := ReadBool(fp, expr.Inputs[0])
+	cases := (len(expr.Inputs) - 1) / 2
+
+	var ready []int
+	for c := 0; c < cases; c++ {
+		isSend := ReadBool(fp, expr.Inputs[1+2*c])
+		chanOffset := readPointer(GetFinalOffset(fp, expr.Inputs[2+2*c]))
+
+		if (isSend && chanCanSelectSend(chanOffset)) || (!isSend && chanCanReceive(chanOffset)) {
+			ready = append(ready, c)
+		}
+	}
+
+	chosen := -1
+	if len(ready) > 0 {
+		chosen = ready[rand.Intn(len(ready))]
+		// the case's send or receive needs to run before any other
+		// thread can change the channel
+		PROGRAM.threadSteps = 0
+	} else if hasDefault {
+		chosen = cases
+	}
+
+	if chosen < 0 {
+		for c := 0; c < cases; c++ {
+			chanOffset := readPointer(GetFinalOffset(fp, expr.Inputs[2+2*c]))
+			if !ReadBool(fp, expr.Inputs[1+2*c]) && chanOffset != NULL_HEAP_ADDRESS {
+				chanWaitReceive(chanOffset)
+			}
+		}
+		PROGRAM.BlockThread("select")
+		return
+	}
+
+	thread.receiving = thread.receiving[:0]
+	WriteMemory(GetFinalOffset(fp, expr.Outputs[0]), FromI32(int32(chosen)))
+}
@@ -0,0 +1,45 @@
+package base
+
+import (
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// An `error` is a `str` holding the message of the error, and an empty
+// string means that there's no error. Natives that can fail return an error
+// as their last output instead of panicking, e.g.
+//
+//	var data str
+//	var err error
+//	data, err = os.ReadFile("config.txt")
+//	if err != "" {
+//		err = errors.Wrap(err, "reading the configuration")
+//	}
+
+// writes the message of `err` to `out`, or a nil string if `err` is nil
+func writeError(expr *CXExpression, fp int, err error, out *CXArgument) {
+	if err == nil {
+		WriteMemory(GetFinalOffset(fp, out), encoder.SerializeAtomic(int32(0)))
+		return
+	}
+
+	writeString(expr, fp, err.Error(), out)
+}
+
+func op_errors_New(expr *CXExpression, fp int) {
+	writeString(expr, fp, ReadStr(fp, expr.Inputs[0]), expr.Outputs[0])
+}
+
+// errors.Wrap adds `message` as context to `err`, which is returned as is if
+// there's no error
+func op_errors_Wrap(expr *CXExpression, fp int) {
+	err := ReadStr(fp, expr.Inputs[0])
+	if err != "" {
+		err = ReadStr(fp, expr.Inputs[1]) + ": " + err
+	}
+
+	writeString(expr, fp, err, expr.Outputs[0])
+}
+
+func op_errors_Message(expr *CXExpression, fp int) {
+	writeString(expr, fp, ReadStr(fp, expr.Inputs[0]), expr.Outputs[0])
+}
@@ -20,6 +20,18 @@ func op_f32_f32(expr *CXExpression, fp int) {
 		WriteMemory(out1Offset, FromI32(int32(ReadF32(fp, inp1))))
 	case TYPE_I64:
 		WriteMemory(out1Offset, FromI64(int64(ReadF32(fp, inp1))))
+	case TYPE_I8:
+		WriteMemory(out1Offset, FromI8(int8(ReadF32(fp, inp1))))
+	case TYPE_I16:
+		WriteMemory(out1Offset, FromI16(int16(ReadF32(fp, inp1))))
+	case TYPE_UI8:
+		WriteMemory(out1Offset, FromUI8(uint8(ReadF32(fp, inp1))))
+	case TYPE_UI16:
+		WriteMemory(out1Offset, FromUI16(uint16(ReadF32(fp, inp1))))
+	case TYPE_UI32:
+		WriteMemory(out1Offset, FromUI32(uint32(ReadF32(fp, inp1))))
+	case TYPE_UI64:
+		WriteMemory(out1Offset, FromUI64(uint64(ReadF32(fp, inp1))))
 	case TYPE_F32:
 		WriteMemory(out1Offset, FromF32(float32(ReadF32(fp, inp1))))
 	case TYPE_F64:
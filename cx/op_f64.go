@@ -20,6 +20,18 @@ func op_f64_f64(expr *CXExpression, fp int) {
 		WriteMemory(out1Offset, FromI32(int32(ReadF64(fp, inp1))))
 	case TYPE_I64:
 		WriteMemory(out1Offset, FromI64(int64(ReadF64(fp, inp1))))
+	case TYPE_I8:
+		WriteMemory(out1Offset, FromI8(int8(ReadF64(fp, inp1))))
+	case TYPE_I16:
+		WriteMemory(out1Offset, FromI16(int16(ReadF64(fp, inp1))))
+	case TYPE_UI8:
+		WriteMemory(out1Offset, FromUI8(uint8(ReadF64(fp, inp1))))
+	case TYPE_UI16:
+		WriteMemory(out1Offset, FromUI16(uint16(ReadF64(fp, inp1))))
+	case TYPE_UI32:
+		WriteMemory(out1Offset, FromUI32(uint32(ReadF64(fp, inp1))))
+	case TYPE_UI64:
+		WriteMemory(out1Offset, FromUI64(uint64(ReadF64(fp, inp1))))
 	case TYPE_F32:
 		WriteMemory(out1Offset, FromF32(float32(ReadF64(fp, inp1))))
 	case TYPE_F64:
@@ -0,0 +1,161 @@
+package base
+
+import (
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// Function values are pointers to closure objects. After the object header,
+// a closure stores the index of the package of its function and the index of
+// the function in that package (4 bytes each), followed by the addresses of
+// the heap objects of the variables it captured. These addresses are passed
+// to the function in its last inputs, which are added to the function for
+// every variable it captures (see `IsCaptured`).
+//
+// The values of named functions, and the ones the closures of a function
+// literal are made from, capture nothing and are kept in the data segment.
+
+const (
+	closurePackage = iota
+	closureFunction
+)
+
+// function values that are referenced from outside the memory of the
+// program, such as glfw callbacks. These are roots for the garbage collector
+var pinnedFunctions []int
+
+// FunctionObject returns the bytes of a closure of `fn` that captures
+// nothing, including its object header
+func (prgrm *CXProgram) FunctionObject(fn *CXFunction) []byte {
+	obj := make([]byte, OBJECT_HEADER_SIZE+CLOSURE_HEADER_SIZE)
+	copy(obj[OBJECT_GC_HEADER_SIZE:], encoder.SerializeAtomic(int32(CLOSURE_HEADER_SIZE)))
+
+	for i, pkg := range prgrm.Packages {
+		if pkg != fn.Package {
+			continue
+		}
+		for j, f := range pkg.Functions {
+			if f == fn {
+				copy(obj[OBJECT_HEADER_SIZE+closurePackage*I32_SIZE:], encoder.SerializeAtomic(int32(i)))
+				copy(obj[OBJECT_HEADER_SIZE+closureFunction*I32_SIZE:], encoder.SerializeAtomic(int32(j)))
+				return obj
+			}
+		}
+	}
+
+	panic("function '" + fn.Name + "' not found in its package")
+}
+
+func readClosureField(obj int, field int) int {
+	var val int32
+	off := obj + OBJECT_HEADER_SIZE + field*I32_SIZE
+	encoder.DeserializeAtomic(PROGRAM.Memory[off:off+I32_SIZE], &val)
+	return int(val)
+}
+
+// returns the function of the closure at `obj`
+func closureFunc(obj int) *CXFunction {
+	return PROGRAM.Packages[readClosureField(obj, closurePackage)].Functions[readClosureField(obj, closureFunction)]
+}
+
+// returns the number of variables captured by the closure at `obj`
+func closureCaptures(obj int) int {
+	return (objectSize(obj) - CLOSURE_HEADER_SIZE) / TYPE_POINTER_SIZE
+}
+
+// offset of the address of the `idx`th variable captured by the closure at `obj`
+func closureCapture(obj int, idx int) int {
+	return obj + OBJECT_HEADER_SIZE + CLOSURE_HEADER_SIZE + idx*TYPE_POINTER_SIZE
+}
+
+// calledFunction returns the function called by `expr` and the closure it's
+// called through, which is -1 if `expr` doesn't call a function value
+func calledFunction(expr *CXExpression, fp int) (*CXFunction, int) {
+	if IsInterfaceMethod(expr.Operator) {
+		return dispatchedMethod(expr.Operator, GetFinalOffset(fp, expr.Inputs[0])).fn, -1
+	}
+	if expr.Operator != Natives[OP_CALL] {
+		return expr.Operator, -1
+	}
+
+	obj := readPointer(GetFinalOffset(fp, expr.Inputs[0]))
+	if obj == NULL_HEAP_ADDRESS {
+		panic(NIL_FUNC_CALL_ERROR)
+	}
+
+	return closureFunc(obj), obj
+}
+
+// writes the addresses captured by the closure at `obj` to the last inputs
+// of its function, in the frame at `newFP`
+func writeCaptures(fn *CXFunction, obj int, newFP int) {
+	captures := closureCaptures(obj)
+	for c := 0; c < captures; c++ {
+		inp := fn.Inputs[len(fn.Inputs)-captures+c]
+		// not using GetFinalOffset, as the input holds the address itself
+		WriteMemory(newFP+inp.Offset, PROGRAM.Memory[closureCapture(obj, c):closureCapture(obj, c)+TYPE_POINTER_SIZE])
+	}
+}
+
+// returns the address of a new slice holding the values of `args`, which
+// are passed to the variadic parameter `param`
+func variadicSlice(fp int, args []*CXArgument, param *CXArgument) int32 {
+	eltSize := param.TotalSize
+	toInterface := param.CustomType != nil && param.CustomType.IsInterface
+	size := OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + len(args)*eltSize
+
+	obj := make([]byte, OBJECT_HEADER_SIZE, size)
+	copy(obj[OBJECT_GC_HEADER_SIZE:], encoder.SerializeAtomic(int32(size-OBJECT_HEADER_SIZE)))
+	obj = append(obj, sliceHeader(len(args), len(args))...)
+
+	for _, arg := range args {
+		if toInterface {
+			obj = append(obj, interfaceValue(fp, arg)...)
+			continue
+		}
+
+		offset := GetFinalOffset(fp, arg)
+		if arg.PassBy == PASSBY_REFERENCE {
+			obj = append(obj, encoder.SerializeAtomic(int32(offset))...)
+		} else {
+			obj = append(obj, PROGRAM.Memory[offset:offset+eltSize]...)
+		}
+	}
+
+	heapOffset := AllocateSeq(size)
+	WriteMemory(heapOffset, obj)
+
+	return int32(heapOffset)
+}
+
+// PinFunction keeps the function value in `arg` alive while it's used from
+// outside the program. The returned index gives its current address
+// through `PinnedFunction`
+func PinFunction(fp int, arg *CXArgument) int {
+	pinnedFunctions = append(pinnedFunctions, readPointer(GetFinalOffset(fp, arg)))
+	return len(pinnedFunctions) - 1
+}
+
+func PinnedFunction(idx int) int {
+	return pinnedFunctions[idx]
+}
+
+func op_closure(expr *CXExpression, fp int) {
+	captures := expr.Inputs[1:]
+	size := CLOSURE_HEADER_SIZE + len(captures)*TYPE_POINTER_SIZE
+	heapOffset := AllocateSeq(OBJECT_HEADER_SIZE + size)
+
+	// the closure is made from the value of the function literal, which
+	// captures nothing
+	fnObj := readPointer(GetFinalOffset(fp, expr.Inputs[0]))
+
+	obj := make([]byte, OBJECT_HEADER_SIZE+size)
+	copy(obj[OBJECT_GC_HEADER_SIZE:], encoder.SerializeAtomic(int32(size)))
+	copy(obj[OBJECT_HEADER_SIZE:], PROGRAM.Memory[fnObj+OBJECT_HEADER_SIZE:fnObj+OBJECT_HEADER_SIZE+CLOSURE_HEADER_SIZE])
+	for c, capt := range captures {
+		// these are the addresses of the captured variables' objects
+		copy(obj[OBJECT_HEADER_SIZE+CLOSURE_HEADER_SIZE+c*TYPE_POINTER_SIZE:], ReadMemory(GetFinalOffset(fp, capt), capt))
+	}
+	WriteMemory(heapOffset, obj)
+
+	WriteMemory(GetFinalOffset(fp, expr.Outputs[0]), encoder.SerializeAtomic(int32(heapOffset)))
+}
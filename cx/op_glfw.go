@@ -91,7 +91,24 @@ func op_glfw_GetTime(expr *CXExpression, fp int) {
 	WriteMemory(GetFinalOffset(fp, out1), FromF64(glfw.GetTime()))
 }
 
-func glfw_SetKeyCallback(expr *CXExpression, window string, functionName string, packageName string) {
+// returns a function that calls the callback given to a glfw function in
+// `inp`, which is either a function value or the name of a function in
+// the package `packageName`
+func glfwCallback(expr *CXExpression, fp int, inp *CXArgument, packageName string) func([][]byte) {
+	if GetAssignmentElement(inp).Type == TYPE_FUNC {
+		idx := PinFunction(fp, inp)
+		return func(inps [][]byte) {
+			PROGRAM.ccallbackValue(idx, inps)
+		}
+	}
+
+	functionName := ReadStr(fp, inp)
+	return func(inps [][]byte) {
+		PROGRAM.ccallback(expr, functionName, packageName, inps)
+	}
+}
+
+func glfw_SetKeyCallback(window string, call func([][]byte)) {
 	callback := func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
 		var inps [][]byte = make([][]byte, 5)
 		inps[0] = GetWindowName(w)
@@ -99,7 +116,7 @@ func glfw_SetKeyCallback(expr *CXExpression, window string, functionName string,
 		inps[2] = FromI32(int32(scancode))
 		inps[3] = FromI32(int32(action))
 		inps[4] = FromI32(int32(mods))
-		PROGRAM.ccallback(expr, functionName, packageName, inps)
+		call(inps)
 	}
 
 	windows[window].SetKeyCallback(callback)
@@ -107,12 +124,12 @@ func glfw_SetKeyCallback(expr *CXExpression, window string, functionName string,
 
 func op_glfw_SetKeyCallback(expr *CXExpression, fp int) {
 	inp0, inp1 := expr.Inputs[0], expr.Inputs[1]
-	glfw_SetKeyCallback(expr, ReadStr(fp, inp0), ReadStr(fp, inp1), expr.Package.Name)
+	glfw_SetKeyCallback(ReadStr(fp, inp0), glfwCallback(expr, fp, inp1, expr.Package.Name))
 }
 
 func op_glfw_SetKeyCallbackEx(expr *CXExpression, fp int) {
 	inp0, inp1, inp2  := expr.Inputs[0], expr.Inputs[1], expr.Inputs[2]
-	glfw_SetKeyCallback(expr, ReadStr(fp, inp0), ReadStr(fp, inp1), ReadStr(fp, inp2))
+	glfw_SetKeyCallback(ReadStr(fp, inp0), glfwCallback(expr, fp, inp1, ReadStr(fp, inp2)))
 }
 
 func GetWindowName(w *glfw.Window) []byte {
@@ -125,13 +142,13 @@ func GetWindowName(w *glfw.Window) []byte {
 	return nil
 }
 
-func glfw_SetCursorPosCallback(expr *CXExpression, window string, functionName string, packageName string) {
+func glfw_SetCursorPosCallback(window string, call func([][]byte)) {
 	callback := func(w *glfw.Window, xpos float64, ypos float64) {
 		var inps [][]byte = make([][]byte, 3)
 		inps[0] = GetWindowName(w)
 		inps[1] = FromF64(xpos)
 		inps[2] = FromF64(ypos)
-		PROGRAM.ccallback(expr, functionName, packageName, inps)
+		call(inps)
 	}
 
 	windows[window].SetCursorPosCallback(callback)
@@ -139,12 +156,12 @@ func glfw_SetCursorPosCallback(expr *CXExpression, window string, functionName s
 
 func op_glfw_SetCursorPosCallback(expr *CXExpression, fp int) {
 	inp0, inp1 := expr.Inputs[0], expr.Inputs[1]
-	glfw_SetCursorPosCallback(expr, ReadStr(fp, inp0), ReadStr(fp, inp1), expr.Package.Name)
+	glfw_SetCursorPosCallback(ReadStr(fp, inp0), glfwCallback(expr, fp, inp1, expr.Package.Name))
 }
 
 func op_glfw_SetCursorPosCallbackEx(expr *CXExpression, fp int) {
 	inp0, inp1, inp2 := expr.Inputs[0], expr.Inputs[1], expr.Inputs[2]
-	glfw_SetCursorPosCallback(expr, ReadStr(fp, inp0), ReadStr(fp, inp1), ReadStr(fp, inp2))
+	glfw_SetCursorPosCallback(ReadStr(fp, inp0), glfwCallback(expr, fp, inp1, ReadStr(fp, inp2)))
 }
 
 func op_glfw_SetShouldClose(expr *CXExpression, fp int) {
@@ -156,14 +173,14 @@ func op_glfw_SetShouldClose(expr *CXExpression, fp int) {
 	}
 }
 
-func glfw_SetMouseButtonCallback(expr *CXExpression, window string, functionName string, packageName string) {
+func glfw_SetMouseButtonCallback(window string, call func([][]byte)) {
 	callback := func(w *glfw.Window, key glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
 		var inps [][]byte = make([][]byte, 4)
 		inps[0] = GetWindowName(w)
 		inps[1] = FromI32(int32(key))
 		inps[2] = FromI32(int32(action))
 		inps[3] = FromI32(int32(mods))
-		PROGRAM.ccallback(expr, functionName, packageName, inps)
+		call(inps)
 	}
 
 	windows[window].SetMouseButtonCallback(callback)
@@ -171,12 +188,12 @@ func glfw_SetMouseButtonCallback(expr *CXExpression, window string, functionName
 
 func op_glfw_SetMouseButtonCallback(expr *CXExpression, fp int) {
 	inp0, inp1 := expr.Inputs[0], expr.Inputs[1]
-	glfw_SetMouseButtonCallback(expr, ReadStr(fp, inp0), ReadStr(fp, inp1), expr.Package.Name)
+	glfw_SetMouseButtonCallback(ReadStr(fp, inp0), glfwCallback(expr, fp, inp1, expr.Package.Name))
 }
 
 func op_glfw_SetMouseButtonCallbackEx(expr *CXExpression, fp int) {
 	inp0, inp1, inp2 := expr.Inputs[0], expr.Inputs[1], expr.Inputs[2]
-	glfw_SetMouseButtonCallback(expr, ReadStr(fp, inp0), ReadStr(fp ,inp1), ReadStr(fp, inp2))
+	glfw_SetMouseButtonCallback(ReadStr(fp, inp0), glfwCallback(expr, fp, inp1, ReadStr(fp, inp2)))
 }
 
 type Func_i32_i32 func(a int32, b int32)
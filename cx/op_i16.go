@@ -0,0 +1,164 @@
+package base
+
+import (
+	"fmt"
+	"strconv"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+func op_i16_i16 (expr *CXExpression, fp int) {
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+	out1Offset := GetFinalOffset(fp, out1)
+
+	switch out1.Type {
+	case TYPE_STR:
+		WriteObject(out1Offset, encoder.Serialize(strconv.FormatInt(int64(ReadI16(fp, inp1)), 10)))
+	case TYPE_BYTE:
+		WriteMemory(out1Offset, FromByte(byte(ReadI16(fp, inp1))))
+	case TYPE_I8:
+		WriteMemory(out1Offset, FromI8(int8(ReadI16(fp, inp1))))
+	case TYPE_I16:
+		WriteMemory(out1Offset, FromI16(ReadI16(fp, inp1)))
+	case TYPE_I32:
+		WriteMemory(out1Offset, FromI32(int32(ReadI16(fp, inp1))))
+	case TYPE_I64:
+		WriteMemory(out1Offset, FromI64(int64(ReadI16(fp, inp1))))
+	case TYPE_UI8:
+		WriteMemory(out1Offset, FromUI8(uint8(ReadI16(fp, inp1))))
+	case TYPE_UI16:
+		WriteMemory(out1Offset, FromUI16(uint16(ReadI16(fp, inp1))))
+	case TYPE_UI32:
+		WriteMemory(out1Offset, FromUI32(uint32(ReadI16(fp, inp1))))
+	case TYPE_UI64:
+		WriteMemory(out1Offset, FromUI64(uint64(ReadI16(fp, inp1))))
+	case TYPE_F32:
+		WriteMemory(out1Offset, FromF32(float32(ReadI16(fp, inp1))))
+	case TYPE_F64:
+		WriteMemory(out1Offset, FromF64(float64(ReadI16(fp, inp1))))
+	}
+}
+
+func op_i16_print (expr *CXExpression, fp int) {
+	inp1 := expr.Inputs[0]
+	fmt.Println(ReadI16(fp, inp1))
+}
+
+func op_i16_add (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(ReadI16(fp, inp1) + ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_sub (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(ReadI16(fp, inp1) - ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_mul (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(ReadI16(fp, inp1) * ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_div (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(ReadI16(fp, inp1) / ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_mod (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(ReadI16(fp, inp1) % ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_gt (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadI16(fp, inp1) > ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_gteq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadI16(fp, inp1) >= ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_lt (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadI16(fp, inp1) < ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_lteq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadI16(fp, inp1) <= ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_eq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadI16(fp, inp1) == ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_uneq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadI16(fp, inp1) != ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_bitand (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(ReadI16(fp, inp1) & ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_bitor (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(ReadI16(fp, inp1) | ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_bitxor (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(ReadI16(fp, inp1) ^ ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_bitclear (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(ReadI16(fp, inp1) &^ ReadI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_bitshl (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(int16(uint16(ReadI16(fp, inp1)) << uint16(ReadI16(fp, inp2))))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_bitshr (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI16(int16(uint16(ReadI16(fp, inp1)) >> uint16(ReadI16(fp, inp2))))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i16_max (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	x, y := ReadI16(fp, inp1), ReadI16(fp, inp2)
+	if y > x {
+		x = y
+	}
+	WriteMemory(GetFinalOffset(fp, out1), FromI16(x))
+}
+
+func op_i16_min (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	x, y := ReadI16(fp, inp1), ReadI16(fp, inp2)
+	if y < x {
+		x = y
+	}
+	WriteMemory(GetFinalOffset(fp, out1), FromI16(x))
+}
@@ -21,6 +21,18 @@ func op_i64_i64(expr *CXExpression, fp int) {
 		WriteMemory(out1Offset, FromI32(int32(ReadI64(fp, inp1))))
 	case TYPE_I64:
 		WriteMemory(out1Offset, FromI64(ReadI64(fp, inp1)))
+	case TYPE_I8:
+		WriteMemory(out1Offset, FromI8(int8(ReadI64(fp, inp1))))
+	case TYPE_I16:
+		WriteMemory(out1Offset, FromI16(int16(ReadI64(fp, inp1))))
+	case TYPE_UI8:
+		WriteMemory(out1Offset, FromUI8(uint8(ReadI64(fp, inp1))))
+	case TYPE_UI16:
+		WriteMemory(out1Offset, FromUI16(uint16(ReadI64(fp, inp1))))
+	case TYPE_UI32:
+		WriteMemory(out1Offset, FromUI32(uint32(ReadI64(fp, inp1))))
+	case TYPE_UI64:
+		WriteMemory(out1Offset, FromUI64(uint64(ReadI64(fp, inp1))))
 	case TYPE_F32:
 		WriteMemory(out1Offset, FromF32(float32(ReadI64(fp, inp1))))
 	case TYPE_F64:
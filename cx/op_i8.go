@@ -1,7 +1,42 @@
 package base
 
-import "fmt"
-
+import (
+	"fmt"
+	"strconv"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+func op_i8_i8 (expr *CXExpression, fp int) {
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+	out1Offset := GetFinalOffset(fp, out1)
+
+	switch out1.Type {
+	case TYPE_STR:
+		WriteObject(out1Offset, encoder.Serialize(strconv.FormatInt(int64(ReadI8(fp, inp1)), 10)))
+	case TYPE_BYTE:
+		WriteMemory(out1Offset, FromByte(byte(ReadI8(fp, inp1))))
+	case TYPE_I8:
+		WriteMemory(out1Offset, FromI8(ReadI8(fp, inp1)))
+	case TYPE_I16:
+		WriteMemory(out1Offset, FromI16(int16(ReadI8(fp, inp1))))
+	case TYPE_I32:
+		WriteMemory(out1Offset, FromI32(int32(ReadI8(fp, inp1))))
+	case TYPE_I64:
+		WriteMemory(out1Offset, FromI64(int64(ReadI8(fp, inp1))))
+	case TYPE_UI8:
+		WriteMemory(out1Offset, FromUI8(uint8(ReadI8(fp, inp1))))
+	case TYPE_UI16:
+		WriteMemory(out1Offset, FromUI16(uint16(ReadI8(fp, inp1))))
+	case TYPE_UI32:
+		WriteMemory(out1Offset, FromUI32(uint32(ReadI8(fp, inp1))))
+	case TYPE_UI64:
+		WriteMemory(out1Offset, FromUI64(uint64(ReadI8(fp, inp1))))
+	case TYPE_F32:
+		WriteMemory(out1Offset, FromF32(float32(ReadI8(fp, inp1))))
+	case TYPE_F64:
+		WriteMemory(out1Offset, FromF64(float64(ReadI8(fp, inp1))))
+	}
+}
 
 func op_i8_print (expr *CXExpression, fp int) {
 	inp1 :=	expr.Inputs[0]
@@ -91,3 +126,39 @@ func op_i8_bitclear (expr *CXExpression, fp int) {
 	outB1 := FromI8(ReadI8(fp, inp1) &^ ReadI8(fp, inp2))
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
 }
+
+func op_i8_mod (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI8(ReadI8(fp, inp1) % ReadI8(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i8_bitshl (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI8(int8(uint8(ReadI8(fp, inp1)) << uint8(ReadI8(fp, inp2))))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i8_bitshr (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromI8(int8(uint8(ReadI8(fp, inp1)) >> uint8(ReadI8(fp, inp2))))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_i8_max (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	x, y := ReadI8(fp, inp1), ReadI8(fp, inp2)
+	if y > x {
+		x = y
+	}
+	WriteMemory(GetFinalOffset(fp, out1), FromI8(x))
+}
+
+func op_i8_min (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	x, y := ReadI8(fp, inp1), ReadI8(fp, inp2)
+	if y < x {
+		x = y
+	}
+	WriteMemory(GetFinalOffset(fp, out1), FromI8(x))
+}
@@ -0,0 +1,454 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// Interface values are a type code followed by the address of the value they
+// hold (INTERFACE_SIZE bytes). The type code tells the type of the value:
+// its basic type, the struct and package indexes if it's a struct, and if
+// it's a pointer. Values which are not pointers are copied to a new heap
+// object when they're converted to interface values, so the address always
+// points to the object of the value (see `interfaceValueOffset`). The type
+// code of nil interface values is 0.
+//
+// The methods of an interface are functions without expressions whose
+// receiver is the interface. Calling one of them calls the method of the
+// type of the value held by the receiver (see `calledFunction`).
+
+const (
+	typeCodeTypeMask     = 1<<8 - 1
+	typeCodePointer      = 1 << 8
+	typeCodeStructShift  = 9
+	typeCodePackageShift = 20
+	typeCodeIndexMask    = 1<<11 - 1
+)
+
+// the types of the type codes, built the first time they're needed
+var codeTypes = make(map[int32]*CXArgument)
+
+// the methods called through interface methods, by the type code of the receiver
+type dispatchKey struct {
+	method *CXFunction
+	code   int32
+}
+
+// a method called through an interface method, and the offset of its
+// receiver in the value held by the interface value, which is not 0 if the
+// method is promoted from an embedded field
+type dispatchedCall struct {
+	fn         *CXFunction
+	rcvrOffset int
+}
+
+var dispatchedMethods = make(map[dispatchKey]dispatchedCall)
+
+// IsInterfaceMethod checks if `fn` is the method of an interface
+func IsInterfaceMethod(fn *CXFunction) bool {
+	if fn == nil || fn.IsNative || len(fn.Inputs) == 0 || len(fn.Expressions) > 0 {
+		return false
+	}
+	rcvr := fn.Inputs[0]
+	return rcvr.CustomType != nil && rcvr.CustomType.IsInterface && strings.HasPrefix(fn.Name, rcvr.CustomType.Name+".")
+}
+
+// InterfaceMethods returns the methods of the interface `iface`
+func InterfaceMethods(iface *CXStruct) []*CXFunction {
+	var methods []*CXFunction
+	for _, fn := range iface.Package.Functions {
+		if IsInterfaceMethod(fn) && fn.Inputs[0].CustomType == iface {
+			methods = append(methods, fn)
+		}
+	}
+	return methods
+}
+
+// returns the name of the method `fn` without its receiver's type
+func methodName(fn *CXFunction) string {
+	return fn.Name[strings.Index(fn.Name, ".")+1:]
+}
+
+// IsInterfaceValue checks if the value of `arg` is an interface value
+func IsInterfaceValue(arg *CXArgument) bool {
+	return IsInterfaceVariable(arg) && arg.PassBy != PASSBY_REFERENCE
+}
+
+// IsInterfaceVariable checks if `arg` holds interface values. Unlike
+// `IsInterfaceValue`, it ignores if `arg` is passed by reference, as the
+// outputs assigned a pointer are, e.g. `foo = &bar`
+func IsInterfaceVariable(arg *CXArgument) bool {
+	elt := GetAssignmentElement(arg)
+	return elt.CustomType != nil && elt.CustomType.IsInterface && !elt.IsMap && !elt.IsChan &&
+		len(elt.Indexes) == len(elt.Lengths) && !isPointerType(elt)
+}
+
+// IsPointerValue checks if the value of `arg` is a pointer, e.g. `&foo`
+func IsPointerValue(arg *CXArgument) bool {
+	elt := GetAssignmentElement(arg)
+	if arg.PassBy == PASSBY_REFERENCE && elt.Type != TYPE_STR && !elt.IsSlice {
+		return true
+	}
+	return isPointerType(elt)
+}
+
+// IsIndexedPointer checks if `arg` is a pointer once it's indexed, e.g.
+// `ps[0]` if `ps` is a `[]*Circle`, without looking at how it's
+// dereferenced, so it can be used before offsets are given
+func IsIndexedPointer(arg *CXArgument) bool {
+	return isPointerElement(arg, len(arg.Indexes))
+}
+
+// checks if the type of `arg` is a pointer once `indexes` of its array and
+// slice dimensions are indexed
+func isPointerElement(arg *CXArgument, indexes int) bool {
+	specs := gcValueSpecifiers(arg)
+	if indexes > len(specs) {
+		return false
+	}
+	specs = specs[:len(specs)-indexes]
+	return len(specs) > 0 && specs[len(specs)-1] == DECL_POINTER
+}
+
+// checks if the type of `elt` is a pointer after its dereferences
+func isPointerType(elt *CXArgument) bool {
+	var pointers int
+	for _, spec := range gcValueSpecifiers(elt) {
+		if spec == DECL_POINTER {
+			pointers++
+		}
+	}
+	return pointers > elt.DereferenceLevels
+}
+
+// returns the struct and package indexes of `strct`
+func structIndexes(strct *CXStruct) (int32, int32) {
+	for i, pkg := range PROGRAM.Packages {
+		if pkg != strct.Package {
+			continue
+		}
+		for j, s := range pkg.Structs {
+			if s == strct {
+				return int32(j), int32(i)
+			}
+		}
+	}
+	panic("struct '" + strct.Name + "' not found in its package")
+}
+
+// TypeCode returns the type code of the value of `arg`, which is not an
+// interface value
+func TypeCode(arg *CXArgument) int32 {
+	elt := GetAssignmentElement(arg)
+	code := int32(elt.Type)
+	if strct := ReceiverType(arg); strct != nil {
+		strctIdx, pkgIdx := structIndexes(strct)
+		code = int32(TYPE_CUSTOM) | strctIdx<<typeCodeStructShift | pkgIdx<<typeCodePackageShift
+	}
+	if IsPointerValue(arg) {
+		code |= typeCodePointer
+	}
+	return code
+}
+
+// returns an argument of the type of the type code `code`
+func codeType(code int32) *CXArgument {
+	if typ, found := codeTypes[code]; found {
+		return typ
+	}
+
+	typ := MakeArgument("", "", 0)
+	typ.Type = int(code & typeCodeTypeMask)
+	if typ.Type == TYPE_CUSTOM {
+		pkg := PROGRAM.Packages[code>>typeCodePackageShift&typeCodeIndexMask]
+		strct := pkg.Structs[code>>typeCodeStructShift&typeCodeIndexMask]
+		if strct.Underlying != nil {
+			// a named type has the values of its underlying type
+			named := *strct.Underlying
+			typ = &named
+			typ.NamedType = strct
+			typ.DeclarationSpecifiers = append([]int{}, named.DeclarationSpecifiers...)
+		} else {
+			typ.CustomType = strct
+			typ.Package = pkg
+			typ.Size = typ.CustomType.Size
+			typ.DeclarationSpecifiers = []int{DECL_STRUCT}
+		}
+	} else {
+		typ.Size = GetArgSize(typ.Type)
+		typ.DeclarationSpecifiers = []int{DECL_BASIC}
+	}
+	if code&typeCodePointer != 0 {
+		typ.IsPointer = true
+		typ.IndirectionLevels = 1
+		typ.Size = TYPE_POINTER_SIZE
+		typ.DeclarationSpecifiers = append(typ.DeclarationSpecifiers, DECL_POINTER)
+	}
+	if typ.NamedType == nil || typ.IsPointer {
+		typ.TotalSize = typ.Size
+	}
+
+	codeTypes[code] = typ
+	return typ
+}
+
+// returns the size of the values of the type code `code`, which is the size
+// of all the elements of an array
+func codeValueSize(code int32) int {
+	typ := codeType(code)
+	if typ.IsSlice {
+		return typ.Size
+	}
+	return typ.TotalSize
+}
+
+// returns the name of the type of the type code `code`
+func codeTypeName(code int32) string {
+	if code == 0 {
+		return "nil"
+	}
+	typ := codeType(code)
+	name := TypeNames[typ.Type]
+	if strct := ReceiverType(typ); strct != nil {
+		name = strct.Package.Name + "." + strct.Name
+	}
+	if typ.IsPointer {
+		name = "*" + name
+	}
+	return name
+}
+
+func readInterface(offset int) (code int32, ptr int32) {
+	encoder.DeserializeAtomic(PROGRAM.Memory[offset:offset+I32_SIZE], &code)
+	encoder.DeserializeAtomic(PROGRAM.Memory[offset+I32_SIZE:offset+INTERFACE_SIZE], &ptr)
+	return code, ptr
+}
+
+// returns the offset of the value pointed by the address of an interface value
+func interfaceValueOffset(ptr int32) int {
+	if int(ptr) >= PROGRAM.HeapStartsAt {
+		return int(ptr) + OBJECT_HEADER_SIZE
+	}
+	return int(ptr)
+}
+
+// returns the interface value holding the value of `arg`, which is copied to
+// a new heap object if it's not a pointer
+func interfaceValue(fp int, arg *CXArgument) []byte {
+	if IsInterfaceValue(arg) {
+		offset := GetFinalOffset(fp, arg)
+		return append([]byte{}, PROGRAM.Memory[offset:offset+INTERFACE_SIZE]...)
+	}
+
+	code := TypeCode(arg)
+	if code&typeCodePointer != 0 {
+		offset := GetFinalOffset(fp, arg)
+		ptr := int32(offset)
+		if arg.PassBy != PASSBY_REFERENCE {
+			encoder.DeserializeAtomic(PROGRAM.Memory[offset:offset+TYPE_POINTER_SIZE], &ptr)
+		}
+		return append(encoder.SerializeAtomic(code), encoder.SerializeAtomic(ptr)...)
+	}
+
+	size := codeValueSize(code)
+	obj := AllocateSeq(OBJECT_HEADER_SIZE + size)
+	WriteMemory(obj+OBJECT_GC_HEADER_SIZE, encoder.SerializeAtomic(int32(size)))
+
+	offset := GetFinalOffset(fp, arg)
+	if arg.PassBy == PASSBY_REFERENCE {
+		// e.g. a string literal
+		WriteMemory(obj+OBJECT_HEADER_SIZE, encoder.SerializeAtomic(int32(offset)))
+	} else {
+		WriteMemory(obj+OBJECT_HEADER_SIZE, PROGRAM.Memory[offset:offset+size])
+	}
+
+	return append(encoder.SerializeAtomic(code), encoder.SerializeAtomic(int32(obj))...)
+}
+
+// writes the value of `arg` to `out`, converting it to an interface value if
+// `out` holds interface values and `arg` doesn't. It returns false if there
+// was nothing to convert
+func writeInterfaceValue(fp int, arg *CXArgument, outFP int, out *CXArgument) bool {
+	if !IsInterfaceVariable(out) || IsInterfaceValue(arg) {
+		return false
+	}
+	val := interfaceValue(fp, arg)
+	WriteMemory(GetFinalOffset(outFP, out), val)
+	return true
+}
+
+// returns the method called by the interface method `method` for the
+// interface value at `offset`
+func dispatchedMethod(method *CXFunction, offset int) dispatchedCall {
+	code, _ := readInterface(offset)
+	if code == 0 {
+		panic(NIL_INTERFACE_CALL_ERROR)
+	}
+
+	key := dispatchKey{method, code}
+	if call, found := dispatchedMethods[key]; found {
+		return call
+	}
+
+	fn, rcvrOffset, err := ReceiverType(codeType(code)).GetMethod(methodName(method))
+	if err != nil {
+		// the compiler checks that the values of an interface implement its methods
+		panic(err)
+	}
+
+	call := dispatchedCall{fn: fn, rcvrOffset: rcvrOffset}
+	dispatchedMethods[key] = call
+	return call
+}
+
+// returns the receiver `rcvr` of the method called by the interface method
+// `method` through the interface value at `offset`
+func interfaceReceiver(offset int, method *CXFunction, rcvr *CXArgument) []byte {
+	_, ptr := readInterface(offset)
+	rcvrOffset := dispatchedMethod(method, offset).rcvrOffset
+	if rcvr.IsPointer {
+		return encoder.SerializeAtomic(ptr + int32(rcvrOffset))
+	}
+	valOffset := interfaceValueOffset(ptr) + rcvrOffset
+	return PROGRAM.Memory[valOffset : valOffset+rcvr.TotalSize]
+}
+
+// checks if `x` and `y` are of the same type
+func sameType(x, y *CXArgument) bool {
+	// strings can be marked as pointers
+	isPointer := x.IsPointer == y.IsPointer || x.Type == TYPE_STR
+	return x.Type == y.Type && x.CustomType == y.CustomType && isPointer &&
+		x.IsSlice == y.IsSlice && x.IsMap == y.IsMap && x.IsChan == y.IsChan &&
+		len(x.Lengths) == len(y.Lengths)
+}
+
+// checks if the methods `x` and `y` have the same parameters and outputs
+func sameSignature(x, y *CXFunction) bool {
+	if len(x.Inputs) != len(y.Inputs) || len(x.Outputs) != len(y.Outputs) {
+		return false
+	}
+	for i := 1; i < len(x.Inputs); i++ {
+		if !sameType(x.Inputs[i], y.Inputs[i]) {
+			return false
+		}
+	}
+	for i := range x.Outputs {
+		if !sameType(x.Outputs[i], y.Outputs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Implements returns why the value of `arg` can't be used as a value of
+// the interface `iface`, or an empty string if it can
+func Implements(arg *CXArgument, iface *CXStruct) string {
+	elt := GetAssignmentElement(arg)
+	isPointer := IsPointerValue(arg)
+
+	strct := ReceiverType(arg)
+
+	for _, method := range InterfaceMethods(iface) {
+		name := methodName(method)
+		if strct == nil || (elt.Type != TYPE_CUSTOM && !strct.IsInterface && strct.Underlying == nil) {
+			return fmt.Sprintf("missing method '%s'", name)
+		}
+
+		fn, _, err := strct.GetMethod(name)
+		if err != nil {
+			return fmt.Sprintf("missing method '%s'", name)
+		}
+		if fn.Inputs[0].IsPointer && !isPointer {
+			return fmt.Sprintf("method '%s' has a pointer receiver", name)
+		}
+		if !sameSignature(fn, method) {
+			return fmt.Sprintf("wrong type for method '%s'", name)
+		}
+	}
+
+	return ""
+}
+
+// asserts that the interface value of the second input holds a value of the
+// type of the first input, which is written to the first output. If there
+// is a second output, it tells if the assertion succeeded instead of
+// panicking if it didn't
+func op_type_assert(expr *CXExpression, fp int) {
+	typ, inp1, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	code, ptr := readInterface(GetFinalOffset(fp, inp1))
+
+	var ok bool
+	var val []byte
+	if IsInterfaceValue(typ) {
+		ok = code != 0 && Implements(codeType(code), typ.CustomType) == ""
+		val = append(encoder.SerializeAtomic(code), encoder.SerializeAtomic(ptr)...)
+	} else {
+		ok = code == TypeCode(typ)
+		if IsPointerValue(typ) {
+			val = encoder.SerializeAtomic(ptr)
+		} else if ok {
+			valOffset := interfaceValueOffset(ptr)
+			val = PROGRAM.Memory[valOffset : valOffset+typ.TotalSize]
+		}
+	}
+
+	if !ok {
+		if len(expr.Outputs) < 2 {
+			panic(fmt.Sprintf("%s: interface holds %s, not %s", TYPE_ASSERTION_ERROR, codeTypeName(code), typeName(typ)))
+		}
+		// the zero value of the type
+		val = make([]byte, typ.TotalSize)
+	}
+
+	WriteMemory(GetFinalOffset(fp, out1), val)
+	if len(expr.Outputs) > 1 {
+		WriteMemory(GetFinalOffset(fp, expr.Outputs[1]), FromBool(ok))
+	}
+}
+
+// returns the name of the type of `arg`
+func typeName(arg *CXArgument) string {
+	elt := GetAssignmentElement(arg)
+	name := TypeNames[elt.Type]
+	if strct := ReceiverType(arg); strct != nil {
+		name = strct.Package.Name + "." + strct.Name
+	}
+	if IsPointerValue(arg) {
+		name = "*" + name
+	}
+	return name
+}
+
+// returns the printable value of the interface value in `byts`
+func getInterfacePrintableValue(byts []byte) string {
+	var code, ptr int32
+	encoder.DeserializeAtomic(byts[:I32_SIZE], &code)
+	encoder.DeserializeAtomic(byts[I32_SIZE:INTERFACE_SIZE], &ptr)
+	if code == 0 {
+		return "nil"
+	}
+
+	typ := codeType(code)
+	if typ.IsPointer {
+		return fmt.Sprintf("&%s(%d)", codeTypeName(code&^typeCodePointer), ptr)
+	}
+	valOffset := interfaceValueOffset(ptr)
+	return getPrintableBytes(typ.Type, typ.CustomType, PROGRAM.Memory[valOffset:valOffset+typ.Size])
+}
+
+// visits the value held by the interface value at `offset`
+func gcVisitInterface(offset int, update bool) {
+	code, _ := readInterface(offset)
+	if code == 0 {
+		return
+	}
+	if obj := gcVisitReference(offset+I32_SIZE, update); obj >= 0 {
+		// the address points to the object of a value of the type without
+		// the pointer
+		typ := codeType(code &^ typeCodePointer)
+		specs := gcDeclarationSpecifiers(typ)
+		gcVisitValue(obj+OBJECT_HEADER_SIZE, typ, specs, len(specs)-1, update)
+	}
+}
@@ -0,0 +1,281 @@
+package base
+
+import (
+	"bytes"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// Maps are heap objects. After the object header, a map stores its length,
+// its capacity, the type of its keys, the size of its values, the number
+// of its buckets and the number of its used entries (4 bytes each), then a
+// zeroed value, which is what a lookup returns when the key is not in the
+// map, its entries (key followed by value) in insertion order, which is the
+// order they're iterated and printed in, and finally its buckets. String keys
+// are stored as pointers and compared by their contents.
+//
+// The buckets are a hash table of the entries, using linear probing. Each
+// bucket holds the index of an entry plus one, 0 if it's empty, or -1 if its
+// entry was deleted. A map has at least twice as many buckets as entries, so
+// a probe always reaches an empty bucket.
+//
+// Deleting an entry leaves it in place, so the following entries keep their
+// indexes while a range loop iterates the map. Deleted entries are dropped
+// when the map is relocated to insert more entries than it has room for.
+
+// allocates an empty map with room for `capacity` entries
+func makeMap(keyType int, valSize int, capacity int) int {
+	buckets := 2
+	for buckets < 2*capacity {
+		buckets *= 2
+	}
+
+	size := MAP_HEADER_SIZE + valSize + capacity*(GetArgSize(keyType)+valSize) + buckets*I32_SIZE
+	heapOffset := AllocateSeq(OBJECT_HEADER_SIZE + size)
+
+	obj := make([]byte, OBJECT_HEADER_SIZE + size)
+	copy(obj[OBJECT_GC_HEADER_SIZE:], encoder.SerializeAtomic(int32(size)))
+	copy(obj[OBJECT_HEADER_SIZE+I32_SIZE:], encoder.SerializeAtomic(int32(capacity)))
+	copy(obj[OBJECT_HEADER_SIZE+2*I32_SIZE:], encoder.SerializeAtomic(int32(keyType)))
+	copy(obj[OBJECT_HEADER_SIZE+3*I32_SIZE:], encoder.SerializeAtomic(int32(valSize)))
+	copy(obj[OBJECT_HEADER_SIZE+4*I32_SIZE:], encoder.SerializeAtomic(int32(buckets)))
+
+	WriteMemory(heapOffset, obj)
+
+	return heapOffset
+}
+
+func readMapField(mapOffset int, field int) int {
+	var val int32
+	off := mapOffset + OBJECT_HEADER_SIZE + field*I32_SIZE
+	encoder.DeserializeAtomic(PROGRAM.Memory[off:off+I32_SIZE], &val)
+	return int(val)
+}
+
+func writeMapField(mapOffset int, field int, val int) {
+	WriteMemory(mapOffset+OBJECT_HEADER_SIZE+field*I32_SIZE, encoder.SerializeAtomic(int32(val)))
+}
+
+// returns the length, capacity, key type and value size of a map
+func readMapHeader(mapOffset int) (l int, c int, keyType int, valSize int) {
+	return readMapField(mapOffset, 0), readMapField(mapOffset, 1), readMapField(mapOffset, 2), readMapField(mapOffset, 3)
+}
+
+// number of entries used by a map, deleted or not
+func mapUsed(mapOffset int) int {
+	return readMapField(mapOffset, 5)
+}
+
+// offset of the zeroed value of a map
+func mapZeroValue(mapOffset int) int {
+	return mapOffset + OBJECT_HEADER_SIZE + MAP_HEADER_SIZE
+}
+
+// offset of the key of the entry at index `idx`
+func mapEntry(mapOffset int, idx int) int {
+	_, _, keyType, valSize := readMapHeader(mapOffset)
+	return mapZeroValue(mapOffset) + valSize + idx*(GetArgSize(keyType)+valSize)
+}
+
+// offset of the bucket at index `idx`
+func mapBucket(mapOffset int, idx int) int {
+	_, c, _, _ := readMapHeader(mapOffset)
+	return mapEntry(mapOffset, c) + idx*I32_SIZE
+}
+
+// gets the bytes that represent `key` in a map
+func mapKey(fp int, key *CXArgument, keyType int) []byte {
+	keyOffset := GetFinalOffset(fp, key)
+
+	if keyType == TYPE_STR && key.Name == "" {
+		// then it's a literal and the key is its address
+		return encoder.SerializeAtomic(int32(keyOffset))
+	}
+
+	return PROGRAM.Memory[keyOffset : keyOffset+GetArgSize(keyType)]
+}
+
+func mapKeysEqual(keyType int, key1 []byte, key2 []byte) bool {
+	if keyType == TYPE_STR {
+		var off1, off2 int32
+		encoder.DeserializeAtomic(key1, &off1)
+		encoder.DeserializeAtomic(key2, &off2)
+		return readStrAt(off1) == readStrAt(off2)
+	}
+
+	return bytes.Equal(key1, key2)
+}
+
+// FNV-1a hash of a key. Strings are hashed by their contents, as they're
+// compared by them
+func mapHash(keyType int, key []byte) uint32 {
+	if keyType == TYPE_STR {
+		var off int32
+		encoder.DeserializeAtomic(key, &off)
+		key = []byte(readStrAt(off))
+	}
+
+	hash := uint32(2166136261)
+	for _, b := range key {
+		hash ^= uint32(b)
+		hash *= 16777619
+	}
+	return hash
+}
+
+// returns the offset of the bucket of the entry with key `key`, and the
+// index of the entry. If there's no such entry, the index is -1 and the
+// bucket is the empty one where its index would be stored
+func mapFind(mapOffset int, key []byte) (bucket int, idx int) {
+	_, _, keyType, _ := readMapHeader(mapOffset)
+	keySize := GetArgSize(keyType)
+	buckets := readMapField(mapOffset, 4)
+
+	for b := int(mapHash(keyType, key)) & (buckets - 1); ; b = (b + 1) & (buckets - 1) {
+		bucket = mapBucket(mapOffset, b)
+		var entryIdx int32
+		encoder.DeserializeAtomic(PROGRAM.Memory[bucket:bucket+I32_SIZE], &entryIdx)
+		if entryIdx == 0 {
+			return bucket, -1
+		}
+		if entryIdx < 0 {
+			// then its entry was deleted
+			continue
+		}
+
+		entry := mapEntry(mapOffset, int(entryIdx)-1)
+		if mapKeysEqual(keyType, PROGRAM.Memory[entry:entry+keySize], key) {
+			return bucket, int(entryIdx) - 1
+		}
+	}
+}
+
+// fills the buckets of a map with its entries, none of which is deleted
+func mapRehash(mapOffset int) {
+	l, _, keyType, _ := readMapHeader(mapOffset)
+	keySize := GetArgSize(keyType)
+	buckets := mapBucket(mapOffset, 0)
+	WriteMemory(buckets, make([]byte, readMapField(mapOffset, 4)*I32_SIZE))
+
+	for idx := 0; idx < l; idx++ {
+		entry := mapEntry(mapOffset, idx)
+		bucket, _ := mapFind(mapOffset, PROGRAM.Memory[entry:entry+keySize])
+		WriteMemory(bucket, encoder.SerializeAtomic(int32(idx+1)))
+	}
+}
+
+// MapIndex returns the offset of the value associated to the key arg.Indexes[0]
+// in the map pointed by `offset`. If the key is not in the map, the offset of a
+// zeroed value is returned, unless `insert` is true, in which case the key is
+// added to the map. Nil maps are allocated the first time a key is inserted,
+// and reading them returns the program's `NilMapValue`.
+func MapIndex(arg *CXArgument, offset int, fp int, insert bool) int {
+	mapOffset := readPointer(offset)
+
+	if mapOffset == NULL_HEAP_ADDRESS {
+		if !insert {
+			return PROGRAM.NilMapValue
+		}
+		// arg.Size is the size of the map's values. Allocating never
+		// collects garbage, so `offset` still holds the map
+		mapOffset = makeMap(arg.MapKeyType, arg.Size, 1)
+		WriteMemory(offset, encoder.SerializeAtomic(int32(mapOffset)))
+	}
+
+	l, c, keyType, valSize := readMapHeader(mapOffset)
+	keySize := GetArgSize(keyType)
+	key := mapKey(fp, arg.Indexes[0], keyType)
+
+	bucket, idx := mapFind(mapOffset, key)
+	if idx >= 0 {
+		return mapEntry(mapOffset, idx) + keySize
+	}
+
+	if !insert {
+		return mapZeroValue(mapOffset)
+	}
+
+	used := mapUsed(mapOffset)
+	if used >= c {
+		// then we need to relocate the map without its deleted entries,
+		// increasing its capacity unless they make enough room
+		newCap := c
+		if l >= c/2 {
+			newCap = c * 2
+		}
+		newMapOffset := makeMap(keyType, valSize, newCap)
+
+		entrySize := keySize + valSize
+		var copied int
+		for i := 0; i < used; i++ {
+			if mapDeleted(mapOffset, i) {
+				continue
+			}
+			entry := mapEntry(mapOffset, i)
+			WriteMemory(mapEntry(newMapOffset, copied), PROGRAM.Memory[entry:entry+entrySize])
+			copied++
+		}
+		writeMapField(newMapOffset, 0, l)
+		writeMapField(newMapOffset, 5, l)
+		mapRehash(newMapOffset)
+		WriteMemory(offset, encoder.SerializeAtomic(int32(newMapOffset)))
+
+		mapOffset = newMapOffset
+		used = l
+		bucket, _ = mapFind(mapOffset, key)
+	}
+
+	entry := mapEntry(mapOffset, used)
+	WriteMemory(entry, key)
+	WriteMemory(entry+keySize, make([]byte, valSize))
+	WriteMemory(bucket, encoder.SerializeAtomic(int32(used+1)))
+	writeMapField(mapOffset, 0, l+1)
+	writeMapField(mapOffset, 5, used+1)
+
+	return entry + keySize
+}
+
+// tells if the entry at index `idx` of a map was deleted, in which case its
+// key leads to another entry, or to none
+func mapDeleted(mapOffset int, idx int) bool {
+	_, _, keyType, _ := readMapHeader(mapOffset)
+	entry := mapEntry(mapOffset, idx)
+	_, found := mapFind(mapOffset, PROGRAM.Memory[entry:entry+GetArgSize(keyType)])
+	return found != idx
+}
+
+// MapEntry returns the offset of the key or the value of the entry at index
+// arg.Indexes[0] in the map pointed by `offset`. It's used to iterate maps.
+func MapEntry(arg *CXArgument, offset int, fp int, isKey bool) int {
+	mapOffset := readPointer(offset)
+	_, _, keyType, _ := readMapHeader(mapOffset)
+
+	entry := mapEntry(mapOffset, int(ReadI32(fp, arg.Indexes[0])))
+	if isKey {
+		return entry
+	}
+
+	return entry + GetArgSize(keyType)
+}
+
+func op_delete(expr *CXExpression, fp int) {
+	inp1, inp2 := expr.Inputs[0], expr.Inputs[1]
+
+	mapOffset := readPointer(GetFinalOffset(fp, inp1))
+	if mapOffset == NULL_HEAP_ADDRESS {
+		// then it's nil
+		return
+	}
+
+	l, _, keyType, valSize := readMapHeader(mapOffset)
+
+	bucket, idx := mapFind(mapOffset, mapKey(fp, inp2, keyType))
+	if idx < 0 {
+		return
+	}
+
+	// the entry stays in place, so the following ones keep their indexes.
+	// Its value is cleared, so the objects it references can be collected
+	WriteMemory(bucket, encoder.SerializeAtomic(int32(-1)))
+	WriteMemory(mapEntry(mapOffset, idx)+GetArgSize(keyType), make([]byte, valSize))
+	writeMapField(mapOffset, 0, l-1)
+}
@@ -29,9 +29,14 @@ func EscapeAnalysis (fp int, inpOffset, outOffset int, arg *CXArgument) {
 
 func op_identity(expr *CXExpression, fp int) {
 	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+	if writeInterfaceValue(fp, inp1, fp, out1) {
+		return
+	}
+
 	inp1Offset := GetFinalOffset(fp, inp1)
 	out1Offset := GetFinalOffset(fp, out1)
 
+
 	var elt *CXArgument
 	if len(out1.Fields) > 0 {
 		elt = out1.Fields[len(out1.Fields) - 1]
@@ -39,11 +44,22 @@ func op_identity(expr *CXExpression, fp int) {
 		elt = out1
 	}
 
+	passBy := elt.PassBy
+	if out1.PassBy == PASSBY_REFERENCE {
+		// then an address is assigned to a field, e.g. `h.next = &n`
+		passBy = PASSBY_REFERENCE
+	}
+
 	if elt.DoesEscape {
 		EscapeAnalysis(fp, inp1Offset, out1Offset, inp1)
 	} else {
-		switch elt.PassBy {
+		switch passBy {
 		case PASSBY_VALUE:
+			if elt.IsSlice && len(elt.Indexes) == 0 {
+				// only the address of the slice's heap object is copied
+				WriteMemory(out1Offset, PROGRAM.Memory[inp1Offset : inp1Offset + TYPE_POINTER_SIZE])
+				return
+			}
 			WriteMemory(out1Offset, ReadMemory(inp1Offset, inp1))
 		case PASSBY_REFERENCE:
 			WriteMemory(out1Offset, encoder.SerializeAtomic(int32(inp1Offset)))
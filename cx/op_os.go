@@ -27,37 +27,34 @@ const (
 var openFiles map[string]*os.File = make(map[string]*os.File, 0)
 
 func op_os_ReadFile(expr *CXExpression, fp int) {
-	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
-
-	_ = out1
+	inp1, out1, out2 := expr.Inputs[0], expr.Outputs[0], expr.Outputs[1]
 
-	if byts, err := ioutil.ReadFile(ReadStr(fp, inp1)); err == nil {
-		_ = byts
-		// sByts := encoder.Serialize(byts)
-		// assignOutput(0, sByts, "[]byte", expr, call)
-	} else {
-		panic(err)
-	}
+	byts, err := ioutil.ReadFile(ReadStr(fp, inp1))
+	writeString(expr, fp, string(byts), out1)
+	writeError(expr, fp, err, out2)
 }
 
 func op_os_Open(expr *CXExpression, fp int) {
-	inp1 := expr.Inputs[0]
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
 	name := ReadStr(fp, inp1)
-	if file, err := os.Open(name); err == nil {
+	file, err := os.Open(name)
+	if err == nil {
 		openFiles[name] = file
-	} else {
-		panic(err)
 	}
+	writeError(expr, fp, err, out1)
 }
 
 func op_os_Close(expr *CXExpression, fp int) {
-	inp1 := expr.Inputs[0]
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
 	name := ReadStr(fp, inp1)
+	var err error
 	if file, ok := openFiles[name]; ok {
-		if err := file.Close(); err != nil {
-			panic(err)
-		}
+		err = file.Close()
+		delete(openFiles, name)
+	} else {
+		err = &os.PathError{Op: "close", Path: name, Err: os.ErrClosed}
 	}
+	writeError(expr, fp, err, out1)
 }
 
 func op_os_GetWorkingDirectory(expr *CXExpression, fp int) {
@@ -0,0 +1,243 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// A `defer` statement marks its call expression with `IsDeferCall`. When the
+// expression is reached, the call is added to the `Deferred` calls of the
+// running call instead of being run, and the deferred calls are run in LIFO
+// order once the function reaches its end, before its frame is popped.
+//
+// The arguments of a deferred call are evaluated by the defer statement,
+// which copies them to temporary variables (see `DeferExpressions`). Their
+// values are saved in a heap object, and written back to the temporary
+// variables when the call is run, so a call deferred in a loop sees the
+// values of its own iteration.
+//
+// A panic, raised by `panic(value)` or by a runtime error, makes the function
+// that raised it run its deferred calls. Then it's passed to its caller, which
+// runs its own deferred calls, and so on until a deferred call calls
+// `recover()`, in which case the function that deferred it returns normally,
+// or until the bottom of the stack, in which case the panic is reported and
+// the program exits. The call a panic is passing through holds it in its
+// `Panic` field.
+//
+// Errors that leave the program in a broken state, such as a stack overflow
+// or a deadlock, can't be recovered.
+
+// DeferredCall is a call deferred by the expression at `Line`
+type DeferredCall struct {
+	Line int
+	// heap object holding the values of its arguments
+	Args int
+}
+
+// CXPanic is a panic being unwound
+type CXPanic struct {
+	// what the panic was raised with, e.g. a UserPanic
+	Value interface{}
+
+	// the state of the stack when it was raised, which is reported if
+	// the panic is not recovered
+	callStack    []CXCall
+	callCounter  int
+	stackPointer int
+	stackStart   int
+	stack        []byte
+}
+
+// UserPanic is raised by `panic(value)`, and holds the printed value
+type UserPanic string
+
+func (p UserPanic) String() string {
+	return "panic: " + string(p)
+}
+
+// AssertionError is raised by `panic(a, b, message)` when `a` and `b` are
+// not equal
+type AssertionError string
+
+func (err AssertionError) Error() string {
+	return string(err)
+}
+
+// returns the message `recover` returns for a panic raised with `r`
+func panicMessage(r interface{}) string {
+	switch v := r.(type) {
+	case UserPanic:
+		return string(v)
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// errors that can't be recovered
+func isFatalError(r interface{}) bool {
+	if _, ok := r.(MemoryError); ok {
+		return true
+	}
+	return r == DEADLOCK_ERROR
+}
+
+// the receiver of a deferred method call is read when the call is run
+func deferredArguments(expr *CXExpression) []*CXArgument {
+	if expr.IsMethodCall {
+		return expr.Inputs[1:]
+	}
+	return expr.Inputs
+}
+
+// defers the call of the expression `call` is running
+func (prgrm *CXProgram) deferCall(call *CXCall) {
+	expr := call.Operator.Expressions[call.Line]
+	args := deferredArguments(expr)
+
+	size := 0
+	for _, arg := range args {
+		size += arg.TotalSize
+	}
+
+	heapOffset := NULL_HEAP_ADDRESS
+	if size > 0 {
+		heapOffset = AllocateSeq(OBJECT_HEADER_SIZE + size)
+
+		obj := make([]byte, OBJECT_HEADER_SIZE, OBJECT_HEADER_SIZE+size)
+		copy(obj[OBJECT_GC_HEADER_SIZE:], encoder.SerializeAtomic(int32(size)))
+		for _, arg := range args {
+			obj = append(obj, ReadMemory(GetFinalOffset(call.FramePointer, arg), arg)...)
+		}
+		WriteMemory(heapOffset, obj)
+	}
+
+	call.Deferred = append(call.Deferred, DeferredCall{Line: call.Line, Args: heapOffset})
+}
+
+// runs the last call deferred by `call`, whose function reached its end
+func (prgrm *CXProgram) runDeferred(call *CXCall) {
+	last := len(call.Deferred) - 1
+	deferred := call.Deferred[last]
+	call.Deferred = call.Deferred[:last]
+
+	expr := call.Operator.Expressions[deferred.Line]
+	off := deferred.Args + OBJECT_HEADER_SIZE
+	for _, arg := range deferredArguments(expr) {
+		WriteMemory(GetFinalOffset(call.FramePointer, arg), PROGRAM.Memory[off:off+arg.TotalSize])
+		off += arg.TotalSize
+	}
+
+	if !expr.Operator.IsNative || expr.Operator.OpCode == OP_CALL {
+		prgrm.pushCall(expr, call.FramePointer)
+		return
+	}
+
+	// natives run the expression at `Line`
+	CollectGarbage()
+	call.Line = deferred.Line
+	execNative(prgrm)
+	call.Line = call.Operator.Length
+
+	if len(prgrm.Threads) > 0 && prgrm.Threads[prgrm.CurrentThread].Blocked {
+		// the call is run again once the thread is woken up
+		call.Deferred = append(call.Deferred, deferred)
+		prgrm.Yield()
+	}
+}
+
+// checks if any call in the stack can recover a panic
+func (prgrm *CXProgram) canRecover() bool {
+	for c := 0; c <= prgrm.CallCounter; c++ {
+		call := &prgrm.CallStack[c]
+		if len(call.Deferred) > 0 || call.Panic != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// startPanic makes the running call unwind the panic raised with `r`. It
+// returns false if the panic can't be recovered, and it has to be reported
+// right away
+func (prgrm *CXProgram) startPanic(r interface{}) bool {
+	p, ok := r.(*CXPanic)
+	if !ok {
+		// it's not a panic raised again after leaving a callback
+		if isFatalError(r) || !prgrm.canRecover() {
+			return false
+		}
+
+		start, _ := prgrm.stackBounds()
+		p = &CXPanic{
+			Value:        r,
+			callStack:    append([]CXCall(nil), prgrm.CallStack[:prgrm.CallCounter+1]...),
+			callCounter:  prgrm.CallCounter,
+			stackPointer: prgrm.StackPointer,
+			stackStart:   start,
+			stack:        append([]byte(nil), prgrm.Memory[start:prgrm.StackPointer]...),
+		}
+	}
+
+	call := &prgrm.CallStack[prgrm.CallCounter]
+	call.Panic = p
+	// the rest of the function is skipped, and its deferred calls are run
+	call.Line = call.Operator.Length
+	return true
+}
+
+// passes the panic of `call`, which ran all of its deferred calls, to its
+// caller. If there's no caller, the panic is reported
+func (prgrm *CXProgram) unwindPanic(call *CXCall) {
+	p := call.Panic
+	call.Panic = nil
+
+	if prgrm.CallCounter == 0 {
+		p.report()
+	}
+
+	prgrm.CallCounter--
+	prgrm.StackPointer = call.FramePointer
+
+	caller := &prgrm.CallStack[prgrm.CallCounter]
+	caller.Panic = p
+	caller.Line = caller.Operator.Length
+}
+
+// reports a panic that wasn't recovered, with the stack as it was when the
+// panic was raised, and exits
+func (p *CXPanic) report() {
+	copy(PROGRAM.CallStack, p.callStack)
+	PROGRAM.CallCounter = p.callCounter
+	PROGRAM.StackPointer = p.stackPointer
+	copy(PROGRAM.Memory[p.stackStart:], p.stack)
+
+	reportRuntimeError(p.Value)
+}
+
+func op_panic_value(expr *CXExpression, fp int) {
+	panic(UserPanic(GetPrintableValue(fp, expr.Inputs[0])))
+}
+
+// recover stops the panic of the function that deferred the running call,
+// returning its message. It returns an empty string if there's no panic, or
+// if the running call was not deferred
+func op_recover(expr *CXExpression, fp int) {
+	var message string
+
+	if PROGRAM.CallCounter > 0 {
+		// only deferred calls run on top of a panicking call
+		caller := &PROGRAM.CallStack[PROGRAM.CallCounter-1]
+		if caller.Panic != nil {
+			message = panicMessage(caller.Panic.Value)
+			caller.Panic = nil
+		}
+	}
+
+	// its message can be discarded
+	if len(expr.Outputs) > 0 {
+		writeString(expr, fp, message, expr.Outputs[0])
+	}
+}
@@ -32,6 +32,31 @@ func op_str_str(expr *CXExpression, fp int) {
 			panic("")
 		}
 		WriteMemory(out1Offset, encoder.Serialize(l))
+	case TYPE_I8, TYPE_I16:
+		i, err := strconv.ParseInt(ReadStr(fp, inp1), 10, out1.Size * 8)
+		if err != nil {
+			panic("")
+		}
+		if out1.Type == TYPE_I8 {
+			WriteMemory(out1Offset, FromI8(int8(i)))
+		} else {
+			WriteMemory(out1Offset, FromI16(int16(i)))
+		}
+	case TYPE_UI8, TYPE_UI16, TYPE_UI32, TYPE_UI64:
+		u, err := strconv.ParseUint(ReadStr(fp, inp1), 10, out1.Size * 8)
+		if err != nil {
+			panic("")
+		}
+		switch out1.Type {
+		case TYPE_UI8:
+			WriteMemory(out1Offset, FromUI8(uint8(u)))
+		case TYPE_UI16:
+			WriteMemory(out1Offset, FromUI16(uint16(u)))
+		case TYPE_UI32:
+			WriteMemory(out1Offset, FromUI32(uint32(u)))
+		case TYPE_UI64:
+			WriteMemory(out1Offset, FromUI64(u))
+		}
 	case TYPE_F32:
 		f, err := strconv.ParseFloat(ReadStr(fp, inp1), 32)
 		if err != nil {
@@ -58,6 +83,27 @@ func op_str_eq(expr *CXExpression, fp int) {
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
 }
 
+// op_str_bytes copies the bytes of a string to a new byte slice, which is
+// how []byte(str) converts a string
+func op_str_bytes(expr *CXExpression, fp int) {
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+	byts := []byte(ReadStr(fp, inp1))
+
+	var slcOff int
+	if len(byts) > 0 {
+		slcOff = AllocateSeq(OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + len(byts))
+
+		var header []byte = make([]byte, OBJECT_HEADER_SIZE)
+		copy(header[5:], encoder.SerializeAtomic(int32(SLICE_HEADER_SIZE + len(byts))))
+
+		obj := append(header, sliceHeader(len(byts), len(byts))...)
+
+		WriteMemory(slcOff, append(obj, byts...))
+	}
+
+	WriteMemory(GetFinalOffset(fp, out1), FromI32(int32(slcOff)))
+}
+
 func writeString(expr *CXExpression, fp int, str string, out *CXArgument) {
 
 	byts := encoder.Serialize(str)
@@ -2,7 +2,6 @@ package base
 
 import (
 	"fmt"
-	"os"
 	// "github.com/skycoin/skycoin/src/cipher/encoder"
 )
 
@@ -12,7 +11,7 @@ func AssertFailed() bool {
 }
 
 func assert(expr *CXExpression, fp int) (same bool) {
-	inp1, inp2, inp3 := expr.Inputs[0], expr.Inputs[1], expr.Inputs[2]
+	inp1, inp2 := expr.Inputs[0], expr.Inputs[1]
 	var byts1, byts2 []byte
 
 	if inp1.Type == TYPE_STR {
@@ -42,14 +41,8 @@ func assert(expr *CXExpression, fp int) (same bool) {
 		}
 	}
 
-	message := ReadStr(fp, inp3)
-
 	if !same {
-		if message != "" {
-			fmt.Printf("%s: %d: result was not equal to the expected value; %s\n", expr.FileName, expr.FileLine, message)
-		} else {
-			fmt.Printf("%s: %d: result was not equal to the expected value\n", expr.FileName, expr.FileLine)
-		}
+		fmt.Printf("%s: %d: %s\n", expr.FileName, expr.FileLine, assertionMessage(expr, fp))
 	}
 
 	assertSuccess = assertSuccess && same
@@ -66,9 +59,17 @@ func op_test(expr *CXExpression, fp int) {
 	assert(expr, fp)
 }
 
+func assertionMessage(expr *CXExpression, fp int) string {
+	if message := ReadStr(fp, expr.Inputs[2]); message != "" {
+		return "result was not equal to the expected value; " + message
+	}
+	return "result was not equal to the expected value"
+}
+
+// a failed assertion raises a panic, see op_panic.go
 func op_panic(expr *CXExpression, fp int) {
 	if (assert(expr, fp) == false) {
-		os.Exit(CX_ASSERT)
+		panic(AssertionError(assertionMessage(expr, fp)))
 	}
 }
 
@@ -24,5 +24,6 @@ func op_time_UnixNano(expr *CXExpression, fp int) {
 
 func op_time_Sleep(expr *CXExpression, fp int) {
 	inp1 := expr.Inputs[0]
-	time.Sleep(time.Duration(ReadI32(fp, inp1)) * time.Millisecond)
+	// other threads keep running while this one sleeps
+	PROGRAM.SleepThread(time.Duration(ReadI32(fp, inp1)) * time.Millisecond)
 }
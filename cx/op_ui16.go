@@ -0,0 +1,164 @@
+package base
+
+import (
+	"fmt"
+	"strconv"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+func op_ui16_ui16 (expr *CXExpression, fp int) {
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+	out1Offset := GetFinalOffset(fp, out1)
+
+	switch out1.Type {
+	case TYPE_STR:
+		WriteObject(out1Offset, encoder.Serialize(strconv.FormatUint(uint64(ReadUI16(fp, inp1)), 10)))
+	case TYPE_BYTE:
+		WriteMemory(out1Offset, FromByte(byte(ReadUI16(fp, inp1))))
+	case TYPE_I8:
+		WriteMemory(out1Offset, FromI8(int8(ReadUI16(fp, inp1))))
+	case TYPE_I16:
+		WriteMemory(out1Offset, FromI16(int16(ReadUI16(fp, inp1))))
+	case TYPE_I32:
+		WriteMemory(out1Offset, FromI32(int32(ReadUI16(fp, inp1))))
+	case TYPE_I64:
+		WriteMemory(out1Offset, FromI64(int64(ReadUI16(fp, inp1))))
+	case TYPE_UI8:
+		WriteMemory(out1Offset, FromUI8(uint8(ReadUI16(fp, inp1))))
+	case TYPE_UI16:
+		WriteMemory(out1Offset, FromUI16(ReadUI16(fp, inp1)))
+	case TYPE_UI32:
+		WriteMemory(out1Offset, FromUI32(uint32(ReadUI16(fp, inp1))))
+	case TYPE_UI64:
+		WriteMemory(out1Offset, FromUI64(uint64(ReadUI16(fp, inp1))))
+	case TYPE_F32:
+		WriteMemory(out1Offset, FromF32(float32(ReadUI16(fp, inp1))))
+	case TYPE_F64:
+		WriteMemory(out1Offset, FromF64(float64(ReadUI16(fp, inp1))))
+	}
+}
+
+func op_ui16_print (expr *CXExpression, fp int) {
+	inp1 := expr.Inputs[0]
+	fmt.Println(ReadUI16(fp, inp1))
+}
+
+func op_ui16_add (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) + ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_sub (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) - ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_mul (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) * ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_div (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) / ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_mod (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) % ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_gt (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI16(fp, inp1) > ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_gteq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI16(fp, inp1) >= ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_lt (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI16(fp, inp1) < ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_lteq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI16(fp, inp1) <= ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_eq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI16(fp, inp1) == ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_uneq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI16(fp, inp1) != ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_bitand (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) & ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_bitor (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) | ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_bitxor (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) ^ ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_bitclear (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) &^ ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_bitshl (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) << ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_bitshr (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI16(ReadUI16(fp, inp1) >> ReadUI16(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui16_max (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	x, y := ReadUI16(fp, inp1), ReadUI16(fp, inp2)
+	if y > x {
+		x = y
+	}
+	WriteMemory(GetFinalOffset(fp, out1), FromUI16(x))
+}
+
+func op_ui16_min (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	x, y := ReadUI16(fp, inp1), ReadUI16(fp, inp2)
+	if y < x {
+		x = y
+	}
+	WriteMemory(GetFinalOffset(fp, out1), FromUI16(x))
+}
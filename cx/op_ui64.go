@@ -0,0 +1,164 @@
+package base
+
+import (
+	"fmt"
+	"strconv"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+func op_ui64_ui64 (expr *CXExpression, fp int) {
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+	out1Offset := GetFinalOffset(fp, out1)
+
+	switch out1.Type {
+	case TYPE_STR:
+		WriteObject(out1Offset, encoder.Serialize(strconv.FormatUint(uint64(ReadUI64(fp, inp1)), 10)))
+	case TYPE_BYTE:
+		WriteMemory(out1Offset, FromByte(byte(ReadUI64(fp, inp1))))
+	case TYPE_I8:
+		WriteMemory(out1Offset, FromI8(int8(ReadUI64(fp, inp1))))
+	case TYPE_I16:
+		WriteMemory(out1Offset, FromI16(int16(ReadUI64(fp, inp1))))
+	case TYPE_I32:
+		WriteMemory(out1Offset, FromI32(int32(ReadUI64(fp, inp1))))
+	case TYPE_I64:
+		WriteMemory(out1Offset, FromI64(int64(ReadUI64(fp, inp1))))
+	case TYPE_UI8:
+		WriteMemory(out1Offset, FromUI8(uint8(ReadUI64(fp, inp1))))
+	case TYPE_UI16:
+		WriteMemory(out1Offset, FromUI16(uint16(ReadUI64(fp, inp1))))
+	case TYPE_UI32:
+		WriteMemory(out1Offset, FromUI32(uint32(ReadUI64(fp, inp1))))
+	case TYPE_UI64:
+		WriteMemory(out1Offset, FromUI64(ReadUI64(fp, inp1)))
+	case TYPE_F32:
+		WriteMemory(out1Offset, FromF32(float32(ReadUI64(fp, inp1))))
+	case TYPE_F64:
+		WriteMemory(out1Offset, FromF64(float64(ReadUI64(fp, inp1))))
+	}
+}
+
+func op_ui64_print (expr *CXExpression, fp int) {
+	inp1 := expr.Inputs[0]
+	fmt.Println(ReadUI64(fp, inp1))
+}
+
+func op_ui64_add (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) + ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_sub (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) - ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_mul (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) * ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_div (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) / ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_mod (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) % ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_gt (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI64(fp, inp1) > ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_gteq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI64(fp, inp1) >= ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_lt (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI64(fp, inp1) < ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_lteq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI64(fp, inp1) <= ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_eq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI64(fp, inp1) == ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_uneq (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromBool(ReadUI64(fp, inp1) != ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_bitand (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) & ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_bitor (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) | ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_bitxor (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) ^ ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_bitclear (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) &^ ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_bitshl (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) << ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_bitshr (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	outB1 := FromUI64(ReadUI64(fp, inp1) >> ReadUI64(fp, inp2))
+	WriteMemory(GetFinalOffset(fp, out1), outB1)
+}
+
+func op_ui64_max (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	x, y := ReadUI64(fp, inp1), ReadUI64(fp, inp2)
+	if y > x {
+		x = y
+	}
+	WriteMemory(GetFinalOffset(fp, out1), FromUI64(x))
+}
+
+func op_ui64_min (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	x, y := ReadUI64(fp, inp1), ReadUI64(fp, inp2)
+	if y < x {
+		x = y
+	}
+	WriteMemory(GetFinalOffset(fp, out1), FromUI64(x))
+}
@@ -6,6 +6,7 @@ import (
 	"bufio"
 	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/skycoin/skycoin/src/cipher/encoder"
 )
@@ -22,6 +23,18 @@ func op_lt (expr *CXExpression, fp int) {
 		outB1 = FromBool(ReadI32(fp, inp1) < ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromBool(ReadI64(fp, inp1) < ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromBool(ReadI8(fp, inp1) < ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromBool(ReadI16(fp, inp1) < ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromBool(ReadUI8(fp, inp1) < ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromBool(ReadUI16(fp, inp1) < ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromBool(ReadUI32(fp, inp1) < ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromBool(ReadUI64(fp, inp1) < ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromBool(ReadF32(fp, inp1) < ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -43,6 +56,18 @@ func op_gt(expr *CXExpression, fp int) {
 		outB1 = FromBool(ReadI32(fp, inp1) > ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromBool(ReadI64(fp, inp1) > ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromBool(ReadI8(fp, inp1) > ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromBool(ReadI16(fp, inp1) > ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromBool(ReadUI8(fp, inp1) > ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromBool(ReadUI16(fp, inp1) > ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromBool(ReadUI32(fp, inp1) > ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromBool(ReadUI64(fp, inp1) > ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromBool(ReadF32(fp, inp1) > ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -63,6 +88,18 @@ func op_lteq(expr *CXExpression, fp int) {
 		outB1 = FromBool(ReadI32(fp, inp1) <= ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromBool(ReadI64(fp, inp1) <= ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromBool(ReadI8(fp, inp1) <= ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromBool(ReadI16(fp, inp1) <= ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromBool(ReadUI8(fp, inp1) <= ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromBool(ReadUI16(fp, inp1) <= ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromBool(ReadUI32(fp, inp1) <= ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromBool(ReadUI64(fp, inp1) <= ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromBool(ReadF32(fp, inp1) <= ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -84,6 +121,18 @@ func op_gteq(expr *CXExpression, fp int) {
 		outB1 = FromBool(ReadI32(fp, inp1) >= ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromBool(ReadI64(fp, inp1) >= ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromBool(ReadI8(fp, inp1) >= ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromBool(ReadI16(fp, inp1) >= ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromBool(ReadUI8(fp, inp1) >= ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromBool(ReadUI16(fp, inp1) >= ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromBool(ReadUI32(fp, inp1) >= ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromBool(ReadUI64(fp, inp1) >= ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromBool(ReadF32(fp, inp1) >= ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -107,6 +156,18 @@ func op_equal(expr *CXExpression, fp int) {
 		outB1 = FromBool(ReadI32(fp, inp1) == ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromBool(ReadI64(fp, inp1) == ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromBool(ReadI8(fp, inp1) == ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromBool(ReadI16(fp, inp1) == ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromBool(ReadUI8(fp, inp1) == ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromBool(ReadUI16(fp, inp1) == ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromBool(ReadUI32(fp, inp1) == ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromBool(ReadUI64(fp, inp1) == ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromBool(ReadF32(fp, inp1) == ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -130,6 +191,18 @@ func op_unequal(expr *CXExpression, fp int) {
 		outB1 = FromBool(ReadI32(fp, inp1) != ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromBool(ReadI64(fp, inp1) != ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromBool(ReadI8(fp, inp1) != ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromBool(ReadI16(fp, inp1) != ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromBool(ReadUI8(fp, inp1) != ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromBool(ReadUI16(fp, inp1) != ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromBool(ReadUI32(fp, inp1) != ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromBool(ReadUI64(fp, inp1) != ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromBool(ReadF32(fp, inp1) != ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -147,6 +220,18 @@ func op_bitand(expr *CXExpression, fp int) {
 		outB1 = FromI32(ReadI32(fp, inp1) & ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromI64(ReadI64(fp, inp1) & ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromI8(ReadI8(fp, inp1) & ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromI16(ReadI16(fp, inp1) & ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) & ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) & ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) & ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) & ReadUI64(fp, inp2))
 	}
 
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
@@ -160,6 +245,18 @@ func op_bitor(expr *CXExpression, fp int) {
 		outB1 = FromI32(ReadI32(fp, inp1) | ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromI64(ReadI64(fp, inp1) | ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromI8(ReadI8(fp, inp1) | ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromI16(ReadI16(fp, inp1) | ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) | ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) | ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) | ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) | ReadUI64(fp, inp2))
 	}
 
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
@@ -173,6 +270,18 @@ func op_bitxor(expr *CXExpression, fp int) {
 		outB1 = FromI32(ReadI32(fp, inp1) ^ ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromI64(ReadI64(fp, inp1) ^ ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromI8(ReadI8(fp, inp1) ^ ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromI16(ReadI16(fp, inp1) ^ ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) ^ ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) ^ ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) ^ ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) ^ ReadUI64(fp, inp2))
 	}
 
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
@@ -188,6 +297,18 @@ func op_mul(expr *CXExpression, fp int) {
 		outB1 = FromI32(ReadI32(fp, inp1) * ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromI64(ReadI64(fp, inp1) * ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromI8(ReadI8(fp, inp1) * ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromI16(ReadI16(fp, inp1) * ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) * ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) * ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) * ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) * ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromF32(ReadF32(fp, inp1) * ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -207,6 +328,18 @@ func op_div(expr *CXExpression, fp int) {
 		outB1 = FromI32(ReadI32(fp, inp1) / ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromI64(ReadI64(fp, inp1) / ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromI8(ReadI8(fp, inp1) / ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromI16(ReadI16(fp, inp1) / ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) / ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) / ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) / ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) / ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromF32(ReadF32(fp, inp1) / ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -226,6 +359,18 @@ func op_mod(expr *CXExpression, fp int) {
 		outB1 = FromI32(ReadI32(fp, inp1) % ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromI64(ReadI64(fp, inp1) % ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromI8(ReadI8(fp, inp1) % ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromI16(ReadI16(fp, inp1) % ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) % ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) % ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) % ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) % ReadUI64(fp, inp2))
 	}
 
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
@@ -241,6 +386,18 @@ func op_add(expr *CXExpression, fp int) {
 		outB1 = FromI32(ReadI32(fp, inp1) + ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromI64(ReadI64(fp, inp1) + ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromI8(ReadI8(fp, inp1) + ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromI16(ReadI16(fp, inp1) + ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) + ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) + ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) + ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) + ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromF32(ReadF32(fp, inp1) + ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -260,6 +417,18 @@ func op_sub(expr *CXExpression, fp int) {
 		outB1 = FromI32(ReadI32(fp, inp1) - ReadI32(fp, inp2))
 	case TYPE_I64:
 		outB1 = FromI64(ReadI64(fp, inp1) - ReadI64(fp, inp2))
+	case TYPE_I8:
+		outB1 = FromI8(ReadI8(fp, inp1) - ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromI16(ReadI16(fp, inp1) - ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) - ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) - ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) - ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) - ReadUI64(fp, inp2))
 	case TYPE_F32:
 		outB1 = FromF32(ReadF32(fp, inp1) - ReadF32(fp, inp2))
 	case TYPE_F64:
@@ -269,14 +438,51 @@ func op_sub(expr *CXExpression, fp int) {
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
 }
 
+// shiftCount reads the count of a shift, which can be of any integer type.
+// A negative count shifts out every bit
+func shiftCount(fp int, inp *CXArgument) uint64 {
+	switch inp.Type {
+	case TYPE_I8:
+		return uint64(ReadI8(fp, inp))
+	case TYPE_I16:
+		return uint64(ReadI16(fp, inp))
+	case TYPE_I32:
+		return uint64(ReadI32(fp, inp))
+	case TYPE_I64:
+		return uint64(ReadI64(fp, inp))
+	case TYPE_UI8:
+		return uint64(ReadUI8(fp, inp))
+	case TYPE_UI16:
+		return uint64(ReadUI16(fp, inp))
+	case TYPE_UI32:
+		return uint64(ReadUI32(fp, inp))
+	case TYPE_UI64:
+		return ReadUI64(fp, inp)
+	}
+	panic("invalid shift count type")
+}
+
 func op_bitshl(expr *CXExpression, fp int) {
 	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	count := shiftCount(fp, inp2)
 	var outB1 []byte
 	switch inp1.Type {
 	case TYPE_I32:
-		outB1 = FromI32(int32(uint32(ReadI32(fp, inp1)) << uint32(ReadI32(fp, inp2))))
+		outB1 = FromI32(int32(uint32(ReadI32(fp, inp1)) << count))
 	case TYPE_I64:
-		outB1 = FromI64(int64(uint64(ReadI64(fp, inp1)) << uint64(ReadI64(fp, inp2))))
+		outB1 = FromI64(int64(uint64(ReadI64(fp, inp1)) << count))
+	case TYPE_I8:
+		outB1 = FromI8(int8(uint8(ReadI8(fp, inp1)) << count))
+	case TYPE_I16:
+		outB1 = FromI16(int16(uint16(ReadI16(fp, inp1)) << count))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) << count)
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) << count)
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) << count)
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) << count)
 	}
 
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
@@ -284,12 +490,25 @@ func op_bitshl(expr *CXExpression, fp int) {
 
 func op_bitshr(expr *CXExpression, fp int) {
 	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
+	count := shiftCount(fp, inp2)
 	var outB1 []byte
 	switch inp1.Type {
 	case TYPE_I32:
-		outB1 = FromI32(int32(uint32(ReadI32(fp, inp1)) >> uint32(ReadI32(fp, inp2))))
+		outB1 = FromI32(int32(uint32(ReadI32(fp, inp1)) >> count))
 	case TYPE_I64:
-		outB1 = FromI64(int64(uint32(ReadI64(fp, inp1)) >> uint32(ReadI64(fp, inp2))))
+		outB1 = FromI64(int64(uint64(ReadI64(fp, inp1)) >> count))
+	case TYPE_I8:
+		outB1 = FromI8(int8(uint8(ReadI8(fp, inp1)) >> count))
+	case TYPE_I16:
+		outB1 = FromI16(int16(uint16(ReadI16(fp, inp1)) >> count))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) >> count)
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) >> count)
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) >> count)
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) >> count)
 	}
 
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
@@ -303,6 +522,18 @@ func op_bitclear(expr *CXExpression, fp int) {
 		outB1 = FromI32(int32(uint32(ReadI32(fp, inp1)) &^ uint32(ReadI32(fp, inp2))))
 	case TYPE_I64:
 		outB1 = FromI64(int64(uint32(ReadI64(fp, inp1)) &^ uint32(ReadI64(fp, inp2))))
+	case TYPE_I8:
+		outB1 = FromI8(ReadI8(fp, inp1) &^ ReadI8(fp, inp2))
+	case TYPE_I16:
+		outB1 = FromI16(ReadI16(fp, inp1) &^ ReadI16(fp, inp2))
+	case TYPE_UI8:
+		outB1 = FromUI8(ReadUI8(fp, inp1) &^ ReadUI8(fp, inp2))
+	case TYPE_UI16:
+		outB1 = FromUI16(ReadUI16(fp, inp1) &^ ReadUI16(fp, inp2))
+	case TYPE_UI32:
+		outB1 = FromUI32(ReadUI32(fp, inp1) &^ ReadUI32(fp, inp2))
+	case TYPE_UI64:
+		outB1 = FromUI64(ReadUI64(fp, inp1) &^ ReadUI64(fp, inp2))
 	}
 
 	WriteMemory(GetFinalOffset(fp, out1), outB1)
@@ -312,7 +543,7 @@ func op_len(expr *CXExpression, fp int) {
 	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
 	elt := GetAssignmentElement(inp1)
 	
-	if elt.IsSlice || elt.Type == TYPE_AFF {
+	if elt.IsSlice || elt.IsMap || elt.IsChan || elt.Type == TYPE_AFF {
 		preInp1Offset := GetFinalOffset(fp, inp1)
 		
 		var inp1Offset int32
@@ -324,9 +555,10 @@ func op_len(expr *CXExpression, fp int) {
 			return
 		}
 		
-		sliceHeader := PROGRAM.Memory[inp1Offset + OBJECT_HEADER_SIZE : inp1Offset + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE]
-		WriteMemory(GetFinalOffset(fp, out1), sliceHeader[:4])
-	} else if elt.Type == TYPE_STR {
+		// the length is the first field of the headers of slices,
+		// maps, channels and affordances
+		WriteMemory(GetFinalOffset(fp, out1), PROGRAM.Memory[inp1Offset + OBJECT_HEADER_SIZE : inp1Offset + OBJECT_HEADER_SIZE + I32_SIZE])
+	} else if elt.Type == TYPE_STR && len(elt.Lengths) == len(elt.Indexes) {
 		inp1Offset := GetFinalOffset(fp, inp1)
 		var offset int32
 		if elt.Name == "" {
@@ -343,159 +575,304 @@ func op_len(expr *CXExpression, fp int) {
 	}
 }
 
-func op_append (expr *CXExpression, fp int) {
-	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
-	
-	preInp1Offset := GetFinalOffset(fp, inp1)
-	inp2Offset := GetFinalOffset(fp, inp2)
-	out1Offset := GetFinalOffset(fp, out1)
+func op_cap(expr *CXExpression, fp int) {
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+	elt := GetAssignmentElement(inp1)
 
-	var inp1Offset int32
-	encoder.DeserializeAtomic(PROGRAM.Memory[preInp1Offset : preInp1Offset + TYPE_POINTER_SIZE], &inp1Offset)
-	
-	var off int32
+	var capacity int
+	if elt.IsSlice {
+		if obj := readPointer(GetFinalOffset(fp, inp1)); obj != NULL_HEAP_ADDRESS {
+			_, capacity = readSliceHeader(obj)
+		}
+	} else {
+		capacity = elt.Lengths[len(elt.Indexes)]
+	}
 
-	byts := PROGRAM.Memory[out1Offset : out1Offset + TYPE_POINTER_SIZE]
-	encoder.DeserializeAtomic(byts, &off)
+	WriteMemory(GetFinalOffset(fp, out1), FromI32(int32(capacity)))
+}
 
-	var heapOffset int
+// Slices are heap objects. After the object header, a slice stores its
+// length, its capacity and the offset of its elements (4 bytes each). The
+// offset is NULL_HEAP_ADDRESS if the elements are stored after the header.
+// Otherwise the elements are shared with the slice they were sliced from,
+// and the offset points into the object of that slice.
 
-	if off == 0 {
-		// then out1 == nil
-		var sliceHeader []byte
-		var len1 int32
+const (
+	sliceLen = iota
+	sliceCap
+	sliceElts
+)
 
-		if inp1Offset != 0 {
-			// then we need to reserve for obj1 too
-			// sliceHeader = PROGRAM.Memory[inp1Offset-SLICE_HEADER_SIZE : inp1Offset]
-			sliceHeader = PROGRAM.Memory[inp1Offset + OBJECT_HEADER_SIZE : inp1Offset + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE]
+// returns the length and the capacity of the slice object at `obj`
+func readSliceHeader(obj int) (int, int) {
+	return readSliceField(obj, sliceLen), readSliceField(obj, sliceCap)
+}
 
-			encoder.DeserializeAtomic(sliceHeader[:4], &len1)
-			heapOffset = AllocateSeq((int(len1)*inp2.TotalSize) + inp2.TotalSize + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE)
-		} else {
-			// then obj1 is nil and zero-sized
-			heapOffset = AllocateSeq(inp2.TotalSize + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE)
-		}
+func readSliceField(obj int, field int) int {
+	var val int32
+	off := obj + OBJECT_HEADER_SIZE + field*I32_SIZE
+	encoder.DeserializeAtomic(PROGRAM.Memory[off:off+I32_SIZE], &val)
+	return int(val)
+}
 
-		WriteMemory(out1Offset, encoder.SerializeAtomic(int32(heapOffset)))
+func writeSliceField(obj int, field int, val int) {
+	WriteMemory(obj + OBJECT_HEADER_SIZE + field*I32_SIZE, encoder.SerializeAtomic(int32(val)))
+}
 
-		var obj1 []byte
-		var obj2 []byte
+// returns the header of a new slice object of length `l` and capacity `c`,
+// which stores its elements after the header
+func sliceHeader(l int, c int) []byte {
+	header := make([]byte, 0, SLICE_HEADER_SIZE)
+	header = append(header, encoder.SerializeAtomic(int32(l))...)
+	header = append(header, encoder.SerializeAtomic(int32(c))...)
+	return append(header, encoder.SerializeAtomic(int32(NULL_HEAP_ADDRESS))...)
+}
 
-		obj1 = PROGRAM.Memory[inp1Offset + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE : OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + int32(inp1Offset) + len1*int32(inp2.TotalSize)]
+// returns the offset of the elements of the slice object at `obj`
+func sliceElements(obj int) int {
+	if obj == NULL_HEAP_ADDRESS {
+		return NULL_HEAP_ADDRESS
+	}
+	if elts := readSliceField(obj, sliceElts); elts != NULL_HEAP_ADDRESS {
+		return elts
+	}
+	return obj + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE
+}
 
-		if inp2.Type == TYPE_STR || inp2.Type == TYPE_AFF {
-			obj2 = encoder.SerializeAtomic(int32(inp2Offset))
-		} else {
-			obj2 = ReadMemory(inp2Offset, inp2)
+// writes the slice expression `inp1[inp2:inp3:inp4]`, where bounds that were
+// left out are SLICE_BOUND_OMITTED. Slicing a string makes a new string, and
+// slicing a slice makes a new slice sharing its elements from the low bound
+// up to the max bound. Arrays aren't heap objects, so slicing an array makes
+// a new slice holding a copy of these elements
+func op_slice(expr *CXExpression, fp int) {
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+	elt := GetAssignmentElement(inp1)
+	lo, hi, max := int(ReadI32(fp, expr.Inputs[1])), int(ReadI32(fp, expr.Inputs[2])), int(ReadI32(fp, expr.Inputs[3]))
+
+	if elt.Type == TYPE_STR && !elt.IsSlice && len(elt.Lengths) == len(elt.Indexes) {
+		str := ReadStr(fp, inp1)
+		if hi == SLICE_BOUND_OMITTED {
+			hi = len(str)
+		}
+		if lo < 0 || lo > hi || hi > len(str) {
+			panic(fmt.Sprintf("%s [%d:%d] with length %d", SLICE_BOUNDS_ERROR, lo, hi, len(str)))
 		}
+		writeString(expr, fp, str[lo:hi], out1)
+		return
+	}
 
-		var size []byte
-		if inp1Offset != 0 {
-			size = encoder.SerializeAtomic(int32(len(obj1)) + int32(len(obj2) + SLICE_HEADER_SIZE))
-		} else {
-			size = encoder.SerializeAtomic(int32(len(obj2) + SLICE_HEADER_SIZE))
+	var length, capacity int
+	var obj int
+	eltSize := elt.TotalSize
+	if elt.IsSlice {
+		if obj = readPointer(GetFinalOffset(fp, inp1)); obj != NULL_HEAP_ADDRESS {
+			length, capacity = readSliceHeader(obj)
 		}
+	} else {
+		length = elt.Lengths[len(elt.Indexes)]
+		capacity = length
+		eltSize = out1.TotalSize
+	}
 
-		var header []byte = make([]byte, OBJECT_HEADER_SIZE)
-		for c := 5; c < OBJECT_HEADER_SIZE; c++ {
-			header[c] = size[c-5]
+	hasMax := max != SLICE_BOUND_OMITTED
+	if hi == SLICE_BOUND_OMITTED {
+		hi = length
+	}
+	if !hasMax {
+		max = capacity
+	}
+	if lo < 0 || lo > hi || hi > max || max > capacity {
+		if hasMax {
+			panic(fmt.Sprintf("%s [%d:%d:%d] with capacity %d", SLICE_BOUNDS_ERROR, lo, hi, max, capacity))
 		}
+		panic(fmt.Sprintf("%s [%d:%d] with capacity %d", SLICE_BOUNDS_ERROR, lo, hi, capacity))
+	}
 
-		// length
-		lenTotal := encoder.SerializeAtomic(len1 + 1)
-		capTotal := lenTotal
+	if max == lo {
+		// then it has no room for any element
+		WriteMemory(GetFinalOffset(fp, out1), encoder.SerializeAtomic(int32(NULL_HEAP_ADDRESS)))
+		return
+	}
 
-		finalObj := append(header, lenTotal...)
-		finalObj = append(finalObj, capTotal...)
-		
-		if inp1Offset != 0 {
-			// then obj1 is not nil, and we need to append
-			finalObj = append(finalObj, obj1...)
-		}
-		finalObj = append(finalObj, obj2...)
+	var heapOffset int
+	if elt.IsSlice {
+		heapOffset = AllocateSeq(OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE)
 
-		WriteMemory(heapOffset, finalObj)
+		header := make([]byte, OBJECT_HEADER_SIZE)
+		copy(header[OBJECT_GC_HEADER_SIZE:], encoder.SerializeAtomic(int32(SLICE_HEADER_SIZE)))
+		WriteMemory(heapOffset, append(header, sliceHeader(hi - lo, max - lo)...))
+		writeSliceField(heapOffset, sliceElts, sliceElements(obj) + lo * eltSize)
 	} else {
-		// then we have access to a size and capacity
-		sliceHeader := PROGRAM.Memory[inp1Offset + OBJECT_HEADER_SIZE : inp1Offset + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE]
+		size := OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + (max - lo) * eltSize
+		elts := GetFinalOffset(fp, inp1)
+
+		obj := make([]byte, OBJECT_HEADER_SIZE, size)
+		copy(obj[OBJECT_GC_HEADER_SIZE:], encoder.SerializeAtomic(int32(size - OBJECT_HEADER_SIZE)))
+		obj = append(obj, sliceHeader(hi - lo, max - lo)...)
+		obj = append(obj, PROGRAM.Memory[elts + lo * eltSize : elts + max * eltSize]...)
 
-		var l int32
-		var c int32
+		heapOffset = AllocateSeq(size)
+		WriteMemory(heapOffset, obj)
+	}
+	WriteMemory(GetFinalOffset(fp, out1), encoder.SerializeAtomic(int32(heapOffset)))
+}
 
-		encoder.DeserializeAtomic(sliceHeader[:4], &l)
-		encoder.DeserializeAtomic(sliceHeader[4:], &c)
+// writes the number of entries a range loop iterates over in the collection
+// or string of the input: its length, or the number of used entries of a map,
+// which includes the deleted ones op_range_next skips
+func op_range_len (expr *CXExpression, fp int) {
+	inp1, out1 := expr.Inputs[0], expr.Outputs[0]
+	if !GetAssignmentElement(inp1).IsMap {
+		op_len(expr, fp)
+		return
+	}
 
-		if l >= c {
-			// then we need to increase cap and relocate slice
-			var obj1 []byte
-			var obj2 []byte
+	var used int
+	if mapOffset := readPointer(GetFinalOffset(fp, inp1)); mapOffset != NULL_HEAP_ADDRESS {
+		used = mapUsed(mapOffset)
+	}
+	WriteMemory(GetFinalOffset(fp, out1), FromI32(int32(used)))
+}
 
-			obj1 = PROGRAM.Memory[inp1Offset + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE : int32(inp1Offset) + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + l*int32(inp2.TotalSize)]
+// writes the position of the first entry that isn't deleted, starting at the
+// second input, in the collection or string of the first input, which range
+// loops iterate over, and the position of the entry following it. The runes
+// of a string are iterated, so the third output is the rune starting at the
+// position
+func op_range_next (expr *CXExpression, fp int) {
+	inp1, inp2 := expr.Inputs[0], expr.Inputs[1]
+	out1, out2, out3 := expr.Outputs[0], expr.Outputs[1], expr.Outputs[2]
+	elt := GetAssignmentElement(inp1)
+	idx := ReadI32(fp, inp2)
 
-			if inp2.Type == TYPE_STR || inp2.Type == TYPE_AFF {
-				obj2 = encoder.SerializeAtomic(int32(inp2Offset))
-			} else {
-				obj2 = ReadMemory(inp2Offset, inp2)
+	if elt.IsMap {
+		if mapOffset := readPointer(GetFinalOffset(fp, inp1)); mapOffset != NULL_HEAP_ADDRESS {
+			for int(idx) < mapUsed(mapOffset) && mapDeleted(mapOffset, int(idx)) {
+				idx++
 			}
+		}
+	}
 
-			l++
-			c = c * 2
+	next, r := idx + 1, int32(0)
+	if elt.Type == TYPE_STR && !elt.IsMap && len(elt.Lengths) == len(elt.Indexes) {
+		str := ReadStr(fp, inp1)
+		rn, size := utf8.DecodeRuneInString(str[idx:])
+		next, r = idx + int32(size), int32(rn)
+	}
 
-			heapOffset = AllocateSeq(int(c)*inp2.TotalSize + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE)
+	WriteMemory(GetFinalOffset(fp, out1), FromI32(idx))
+	WriteMemory(GetFinalOffset(fp, out2), FromI32(next))
+	WriteMemory(GetFinalOffset(fp, out3), FromI32(r))
+}
 
-			WriteMemory(out1Offset, encoder.SerializeAtomic(int32(heapOffset)))
+func op_append (expr *CXExpression, fp int) {
+	inp1, inp2, out1 := expr.Inputs[0], expr.Inputs[1], expr.Outputs[0]
 
-			size := encoder.SerializeAtomic(int32(int(c) * inp2.TotalSize + SLICE_HEADER_SIZE))
+	// the size and bytes of the appended element
+	size2 := inp2.TotalSize
+	readInp2 := func(inp2Offset int) []byte {
+		if inp2.Type == TYPE_STR || inp2.Type == TYPE_AFF {
+			return encoder.SerializeAtomic(int32(inp2Offset))
+		}
+		return ReadMemory(inp2Offset, inp2)
+	}
 
-			var header []byte = make([]byte, OBJECT_HEADER_SIZE)
-			for c := 5; c < OBJECT_HEADER_SIZE; c++ {
-				header[c] = size[c-5]
+	if elt := GetAssignmentElement(inp2); elt.IsSlice && len(elt.Indexes) < len(elt.Lengths) {
+		// a slice is appended to a slice of slices as the address of its
+		// heap object, whatever the size of its elements
+		size2 = TYPE_POINTER_SIZE
+		readInp2 = func(inp2Offset int) []byte {
+			return PROGRAM.Memory[inp2Offset : inp2Offset + TYPE_POINTER_SIZE]
+		}
+	} else if isPointerElement(GetAssignmentElement(inp1), 1) {
+		// the elements are pointers, e.g. `append(ps, &c)`
+		size2 = TYPE_POINTER_SIZE
+		readInp2 = func(inp2Offset int) []byte {
+			if inp2.PassBy == PASSBY_REFERENCE {
+				return encoder.SerializeAtomic(int32(inp2Offset))
 			}
+			return PROGRAM.Memory[inp2Offset : inp2Offset + TYPE_POINTER_SIZE]
+		}
+	}
 
-			lB := encoder.SerializeAtomic(l)
-			cB := encoder.SerializeAtomic(c)
+	if elt := GetAssignmentElement(out1); elt.CustomType != nil && elt.CustomType.IsInterface && !IsInterfaceValue(inp2) {
+		// the element is converted to an interface value
+		iface := interfaceValue(fp, inp2)
+		size2 = INTERFACE_SIZE
+		readInp2 = func(int) []byte {
+			return iface
+		}
+	}
+	
+	inp1Offset := readPointer(GetFinalOffset(fp, inp1))
+	inp2Offset := GetFinalOffset(fp, inp2)
+	out1Offset := GetFinalOffset(fp, out1)
 
-			finalObj := append(header, lB...)
-			finalObj = append(finalObj, cB...)
-			finalObj = append(finalObj, obj1...)
-			finalObj = append(finalObj, obj2...)
+	var l, c int
+	if inp1Offset != NULL_HEAP_ADDRESS {
+		l, c = readSliceHeader(inp1Offset)
+	}
+	obj2 := readInp2(inp2Offset)
 
-			WriteMemory(heapOffset, finalObj)
-		} else {
-			// then we can simply write the element
+	if l < c {
+		// then the element is written in the room after the elements,
+		// which can be shared with other slices
+		WriteMemory(sliceElements(inp1Offset) + l * size2, obj2)
 
-			// updating the length
-			newL := encoder.SerializeAtomic(l+int32(1))
-			
-			for i, byt := range newL {
-				PROGRAM.Memory[int(off) + OBJECT_HEADER_SIZE + i] = byt
-			}
+		if readPointer(out1Offset) == inp1Offset {
+			// then the slice grows in place
+			writeSliceField(inp1Offset, sliceLen, l + 1)
+			return
+		}
 
-			var obj2 []byte
-			if inp2.Type == TYPE_STR || inp2.Type == TYPE_AFF {
-				obj2 = encoder.SerializeAtomic(int32(inp2Offset))
-			} else {
-				obj2 = ReadMemory(inp2Offset, inp2)
-			}
+		// then the output is a new slice sharing the elements
+		heapOffset := AllocateSeq(OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE)
 
-			// write the obj
-			for i, byt := range obj2 {
-				PROGRAM.Memory[off + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + int32(int(l) * inp2.TotalSize + i)] = byt
-			}
-		}
+		header := make([]byte, OBJECT_HEADER_SIZE)
+		copy(header[OBJECT_GC_HEADER_SIZE:], encoder.SerializeAtomic(int32(SLICE_HEADER_SIZE)))
+		WriteMemory(heapOffset, append(header, sliceHeader(l + 1, c)...))
+		writeSliceField(heapOffset, sliceElts, sliceElements(inp1Offset))
+
+		WriteMemory(out1Offset, encoder.SerializeAtomic(int32(heapOffset)))
+		return
 	}
-}
 
-func buildString(expr *CXExpression, fp int) []byte {
-	inp1 := expr.Inputs[0]
+	// then we need to increase cap and relocate slice
+	c = c * 2
+	if c < l + 1 {
+		c = l + 1
+	}
 
-	fmtStr := ReadStr(fp, inp1)
+	var obj1 []byte
+	if inp1Offset != NULL_HEAP_ADDRESS {
+		elts := sliceElements(inp1Offset)
+		obj1 = PROGRAM.Memory[elts : elts + l * size2]
+	}
 
-	var res []byte
-	var specifiersCounter int
+	heapOffset := AllocateSeq(c * size2 + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE)
+
+	WriteMemory(out1Offset, encoder.SerializeAtomic(int32(heapOffset)))
+
+	size := encoder.SerializeAtomic(int32(c * size2 + SLICE_HEADER_SIZE))
+
+	var header []byte = make([]byte, OBJECT_HEADER_SIZE)
+	for c := 5; c < OBJECT_HEADER_SIZE; c++ {
+		header[c] = size[c-5]
+	}
+
+	finalObj := append(header, sliceHeader(l + 1, c)...)
+	finalObj = append(finalObj, obj1...)
+	finalObj = append(finalObj, obj2...)
+
+	WriteMemory(heapOffset, finalObj)
+}
+
+// parseFormat reads the format string of printf and sprintf. It calls
+// `text` with each character that is written as it is, and `directive`
+// with the verb of each directive, e.g. 'd' for %d
+func parseFormat(fmtStr string, text func(ch byte), directive func(verb byte)) {
 	var lenStr int = len(fmtStr)
-	
+
 	for c := 0; c < len(fmtStr); c++ {
 		var nextCh byte
 		ch := fmtStr[c]
@@ -506,52 +883,79 @@ func buildString(expr *CXExpression, fp int) []byte {
 			switch nextCh {
 			case '%':
 				c++
-				res = append(res, nextCh)
-				continue
+				text(nextCh)
 			case 'n':
 				c++
-				res = append(res, '\n')
-				continue
+				text('\n')
 			default:
-				res = append(res, ch)
-				continue
+				text(ch)
 			}
+			continue
 		}
 		if ch == '%' {
-			if specifiersCounter + 1 == len(expr.Inputs) {
-				res = append(res, []byte(fmt.Sprintf("%%!%c(MISSING)", nextCh))...)
-				c++
-				continue
-			}
-			
-			inp := expr.Inputs[specifiersCounter + 1]
-			switch nextCh {
-			case 's':
-				res = append(res, []byte(checkForEscapedChars(ReadStr(fp, inp)))...)
-			case 'd':
-				switch inp.Type {
-				case TYPE_I32:
-					res = append(res, []byte(strconv.FormatInt(int64(ReadI32(fp, inp)), 10))...)
-				case TYPE_I64:
-					res = append(res, []byte(strconv.FormatInt(ReadI64(fp, inp), 10))...)
-				}
-			case 'f':
-				switch inp.Type {
-				case TYPE_F32:
-					res = append(res, []byte(strconv.FormatFloat(float64(ReadF32(fp, inp)), 'f', 7, 32))...)
-				case TYPE_F64:
-					res = append(res, []byte(strconv.FormatFloat(ReadF64(fp, inp), 'f', 16, 64))...)
-				}
-			case 'v':
-				res = append(res, []byte(GetPrintableValue(fp, inp))...)
-			}
+			directive(nextCh)
 			c++
-			specifiersCounter++
 		} else {
-			res = append(res, ch)
+			text(ch)
+		}
+	}
+}
+
+func buildString(expr *CXExpression, fp int) []byte {
+	inp1 := expr.Inputs[0]
+
+	fmtStr := ReadStr(fp, inp1)
+
+	var res []byte
+	var specifiersCounter int
+
+	text := func(ch byte) {
+		res = append(res, ch)
+	}
+	directive := func(verb byte) {
+		if specifiersCounter + 1 == len(expr.Inputs) {
+			res = append(res, []byte(fmt.Sprintf("%%!%c(MISSING)", verb))...)
+			return
+		}
+
+		inp := expr.Inputs[specifiersCounter + 1]
+		switch verb {
+		case 's':
+			res = append(res, []byte(ReadStr(fp, inp))...)
+		case 'd':
+			switch inp.Type {
+			case TYPE_I32:
+				res = append(res, []byte(strconv.FormatInt(int64(ReadI32(fp, inp)), 10))...)
+			case TYPE_I64:
+				res = append(res, []byte(strconv.FormatInt(ReadI64(fp, inp), 10))...)
+			case TYPE_I8:
+				res = append(res, []byte(strconv.FormatInt(int64(ReadI8(fp, inp)), 10))...)
+			case TYPE_I16:
+				res = append(res, []byte(strconv.FormatInt(int64(ReadI16(fp, inp)), 10))...)
+			case TYPE_UI8:
+				res = append(res, []byte(strconv.FormatUint(uint64(ReadUI8(fp, inp)), 10))...)
+			case TYPE_UI16:
+				res = append(res, []byte(strconv.FormatUint(uint64(ReadUI16(fp, inp)), 10))...)
+			case TYPE_UI32:
+				res = append(res, []byte(strconv.FormatUint(uint64(ReadUI32(fp, inp)), 10))...)
+			case TYPE_UI64:
+				res = append(res, []byte(strconv.FormatUint(ReadUI64(fp, inp), 10))...)
+			}
+		case 'f':
+			switch inp.Type {
+			case TYPE_F32:
+				res = append(res, []byte(strconv.FormatFloat(float64(ReadF32(fp, inp)), 'f', 7, 32))...)
+			case TYPE_F64:
+				res = append(res, []byte(strconv.FormatFloat(ReadF64(fp, inp), 'f', 16, 64))...)
+			}
+		case 'v':
+			res = append(res, []byte(GetPrintableValue(fp, inp))...)
 		}
+		specifiersCounter++
 	}
 
+	parseFormat(fmtStr, text, directive)
+
 	if specifiersCounter != len(expr.Inputs) - 1 {
 		extra := "%!(EXTRA "
 		// for _, inp := range expr.Inputs[:specifiersCounter] {
@@ -561,9 +965,9 @@ func buildString(expr *CXExpression, fp int) []byte {
 			elt := GetAssignmentElement(inp)
 			typ := ""
 			_ = typ
-			if elt.CustomType != nil {
+			if strct := ReceiverType(inp); strct != nil {
 				// then it's custom type
-				typ = elt.CustomType.Name
+				typ = strct.Name
 			} else {
 				// then it's native type
 				typ = TypeNames[elt.Type]
@@ -6,7 +6,7 @@ import (
 
 var CorePackages = []string{
 	// temporary solution until we can implement these packages in pure CX I guess
-	"gl", "glfw", "time", "http", "os", "explorer", "aff", "gltext", "cx",
+	"gl", "glfw", "time", "http", "os", "explorer", "aff", "gltext", "cx", "errors",
 }
 
 // op codes
@@ -36,6 +36,7 @@ const (
 	OP_UND_LTEQ
 	OP_UND_GTEQ
 	OP_UND_LEN
+	OP_UND_CAP
 	OP_UND_PRINTF
 	OP_UND_SPRINTF
 	OP_UND_READ
@@ -54,15 +55,95 @@ const (
 	OP_BYTE_I64
 	OP_BYTE_F32
 	OP_BYTE_F64
+	OP_BYTE_I8
+	OP_BYTE_I16
+	OP_BYTE_UI8
+	OP_BYTE_UI16
+	OP_BYTE_UI32
+	OP_BYTE_UI64
 
 	OP_BYTE_PRINT
 
+	OP_I8_BYTE
+	OP_I8_STR
+	OP_I8_I8
+	OP_I8_I16
+	OP_I8_I32
+	OP_I8_I64
+	OP_I8_UI8
+	OP_I8_UI16
+	OP_I8_UI32
+	OP_I8_UI64
+	OP_I8_F32
+	OP_I8_F64
+
+	OP_I8_PRINT
+	OP_I8_ADD
+	OP_I8_SUB
+	OP_I8_MUL
+	OP_I8_DIV
+	OP_I8_MOD
+	OP_I8_GT
+	OP_I8_GTEQ
+	OP_I8_LT
+	OP_I8_LTEQ
+	OP_I8_EQ
+	OP_I8_UNEQ
+	OP_I8_BITAND
+	OP_I8_BITOR
+	OP_I8_BITXOR
+	OP_I8_BITCLEAR
+	OP_I8_BITSHL
+	OP_I8_BITSHR
+	OP_I8_MAX
+	OP_I8_MIN
+
+	OP_I16_BYTE
+	OP_I16_STR
+	OP_I16_I8
+	OP_I16_I16
+	OP_I16_I32
+	OP_I16_I64
+	OP_I16_UI8
+	OP_I16_UI16
+	OP_I16_UI32
+	OP_I16_UI64
+	OP_I16_F32
+	OP_I16_F64
+
+	OP_I16_PRINT
+	OP_I16_ADD
+	OP_I16_SUB
+	OP_I16_MUL
+	OP_I16_DIV
+	OP_I16_MOD
+	OP_I16_GT
+	OP_I16_GTEQ
+	OP_I16_LT
+	OP_I16_LTEQ
+	OP_I16_EQ
+	OP_I16_UNEQ
+	OP_I16_BITAND
+	OP_I16_BITOR
+	OP_I16_BITXOR
+	OP_I16_BITCLEAR
+	OP_I16_BITSHL
+	OP_I16_BITSHR
+	OP_I16_MAX
+	OP_I16_MIN
+
 	OP_I32_BYTE
 	OP_I32_STR
 	OP_I32_I32
 	OP_I32_I64
 	OP_I32_F32
 	OP_I32_F64
+	OP_I32_I8
+	OP_I32_I16
+	OP_I32_UI8
+	OP_I32_UI16
+	OP_I32_UI32
+	OP_I32_UI64
 
 	OP_I32_PRINT
 	OP_I32_ADD
@@ -98,6 +179,12 @@ const (
 	OP_I64_I64
 	OP_I64_F32
 	OP_I64_F64
+	OP_I64_I8
+	OP_I64_I16
+	OP_I64_UI8
+	OP_I64_UI16
+	OP_I64_UI32
+	OP_I64_UI64
 
 	OP_I64_PRINT
 	OP_I64_ADD
@@ -127,6 +214,142 @@ const (
 	OP_I64_MAX
 	OP_I64_MIN
 
+	OP_UI8_BYTE
+	OP_UI8_STR
+	OP_UI8_I8
+	OP_UI8_I16
+	OP_UI8_I32
+	OP_UI8_I64
+	OP_UI8_UI8
+	OP_UI8_UI16
+	OP_UI8_UI32
+	OP_UI8_UI64
+	OP_UI8_F32
+	OP_UI8_F64
+
+	OP_UI8_PRINT
+	OP_UI8_ADD
+	OP_UI8_SUB
+	OP_UI8_MUL
+	OP_UI8_DIV
+	OP_UI8_MOD
+	OP_UI8_GT
+	OP_UI8_GTEQ
+	OP_UI8_LT
+	OP_UI8_LTEQ
+	OP_UI8_EQ
+	OP_UI8_UNEQ
+	OP_UI8_BITAND
+	OP_UI8_BITOR
+	OP_UI8_BITXOR
+	OP_UI8_BITCLEAR
+	OP_UI8_BITSHL
+	OP_UI8_BITSHR
+	OP_UI8_MAX
+	OP_UI8_MIN
+
+	OP_UI16_BYTE
+	OP_UI16_STR
+	OP_UI16_I8
+	OP_UI16_I16
+	OP_UI16_I32
+	OP_UI16_I64
+	OP_UI16_UI8
+	OP_UI16_UI16
+	OP_UI16_UI32
+	OP_UI16_UI64
+	OP_UI16_F32
+	OP_UI16_F64
+
+	OP_UI16_PRINT
+	OP_UI16_ADD
+	OP_UI16_SUB
+	OP_UI16_MUL
+	OP_UI16_DIV
+	OP_UI16_MOD
+	OP_UI16_GT
+	OP_UI16_GTEQ
+	OP_UI16_LT
+	OP_UI16_LTEQ
+	OP_UI16_EQ
+	OP_UI16_UNEQ
+	OP_UI16_BITAND
+	OP_UI16_BITOR
+	OP_UI16_BITXOR
+	OP_UI16_BITCLEAR
+	OP_UI16_BITSHL
+	OP_UI16_BITSHR
+	OP_UI16_MAX
+	OP_UI16_MIN
+
+	OP_UI32_BYTE
+	OP_UI32_STR
+	OP_UI32_I8
+	OP_UI32_I16
+	OP_UI32_I32
+	OP_UI32_I64
+	OP_UI32_UI8
+	OP_UI32_UI16
+	OP_UI32_UI32
+	OP_UI32_UI64
+	OP_UI32_F32
+	OP_UI32_F64
+
+	OP_UI32_PRINT
+	OP_UI32_ADD
+	OP_UI32_SUB
+	OP_UI32_MUL
+	OP_UI32_DIV
+	OP_UI32_MOD
+	OP_UI32_GT
+	OP_UI32_GTEQ
+	OP_UI32_LT
+	OP_UI32_LTEQ
+	OP_UI32_EQ
+	OP_UI32_UNEQ
+	OP_UI32_BITAND
+	OP_UI32_BITOR
+	OP_UI32_BITXOR
+	OP_UI32_BITCLEAR
+	OP_UI32_BITSHL
+	OP_UI32_BITSHR
+	OP_UI32_MAX
+	OP_UI32_MIN
+
+	OP_UI64_BYTE
+	OP_UI64_STR
+	OP_UI64_I8
+	OP_UI64_I16
+	OP_UI64_I32
+	OP_UI64_I64
+	OP_UI64_UI8
+	OP_UI64_UI16
+	OP_UI64_UI32
+	OP_UI64_UI64
+	OP_UI64_F32
+	OP_UI64_F64
+
+	OP_UI64_PRINT
+	OP_UI64_ADD
+	OP_UI64_SUB
+	OP_UI64_MUL
+	OP_UI64_DIV
+	OP_UI64_MOD
+	OP_UI64_GT
+	OP_UI64_GTEQ
+	OP_UI64_LT
+	OP_UI64_LTEQ
+	OP_UI64_EQ
+	OP_UI64_UNEQ
+	OP_UI64_BITAND
+	OP_UI64_BITOR
+	OP_UI64_BITXOR
+	OP_UI64_BITCLEAR
+	OP_UI64_BITSHL
+	OP_UI64_BITSHR
+	OP_UI64_MAX
+	OP_UI64_MIN
+
 	OP_F32_IS_NAN
 	OP_F32_BYTE
 	OP_F32_STR
@@ -134,6 +357,12 @@ const (
 	OP_F32_I64
 	OP_F32_F32
 	OP_F32_F64
+	OP_F32_I8
+	OP_F32_I16
+	OP_F32_UI8
+	OP_F32_UI16
+	OP_F32_UI32
+	OP_F32_UI64
 
 	OP_F32_PRINT
 	OP_F32_ADD
@@ -163,6 +392,12 @@ const (
 	OP_F64_I64
 	OP_F64_F32
 	OP_F64_F64
+	OP_F64_I8
+	OP_F64_I16
+	OP_F64_UI8
+	OP_F64_UI16
+	OP_F64_UI32
+	OP_F64_UI64
 
 	OP_F64_PRINT
 	OP_F64_ADD
@@ -193,6 +428,7 @@ const (
 	OP_STR_INDEX
 	OP_STR_TRIM_SPACE
 	OP_STR_EQ
+	OP_STR_BYTES
 
 	OP_STR_BYTE
 	OP_STR_STR
@@ -200,6 +436,12 @@ const (
 	OP_STR_I64
 	OP_STR_F32
 	OP_STR_F64
+	OP_STR_I8
+	OP_STR_I16
+	OP_STR_UI8
+	OP_STR_UI16
+	OP_STR_UI32
+	OP_STR_UI64
 
 	OP_MAKE
 	OP_READ
@@ -207,6 +449,17 @@ const (
 	OP_LEN
 	OP_CONCAT
 	OP_APPEND
+	OP_SLICE
+	OP_DELETE
+	OP_SEND
+	OP_RECV
+	OP_CLOSE
+	OP_SELECT
+	OP_CLOSURE
+	OP_CALL
+	OP_TYPE_ASSERT
+	OP_RANGE_LEN
+	OP_RANGE_NEXT
 	OP_COPY
 	OP_CAST
 	OP_EQ
@@ -229,6 +482,13 @@ const (
 	OP_ASSERT
 	OP_TEST
 	OP_PANIC
+	OP_PANIC_VALUE
+	OP_RECOVER
+
+	// errors
+	OP_ERRORS_NEW
+	OP_ERRORS_WRAP
+	OP_ERRORS_MESSAGE
 
 	// affordances
 	OP_AFF_PRINT
@@ -254,6 +514,13 @@ func AddOpCode (code int, name string, inputs []int, outputs []int) {
 	Natives[code] = MakeNative(code, inputs, outputs)
 }
 
+// AddVariadicOpCode adds a native that receives any number of arguments
+// after the ones of its inputs, e.g. the values formatted by printf
+func AddVariadicOpCode (code int, name string, inputs []int, outputs []int) {
+	AddOpCode(code, name, inputs, outputs)
+	Natives[code].IsVariadic = true
+}
+
 /*
 // debug helper
 func DumpOpCodes(opCode int) () {
@@ -295,8 +562,9 @@ func init () {
 	AddOpCode(OP_UND_LTEQ, "lteq", []int{TYPE_UNDEFINED, TYPE_UNDEFINED}, []int{TYPE_BOOL})
 	AddOpCode(OP_UND_GTEQ, "gteq", []int{TYPE_UNDEFINED, TYPE_UNDEFINED}, []int{TYPE_BOOL})
 	AddOpCode(OP_UND_LEN, "len", []int{TYPE_UNDEFINED}, []int{TYPE_I32})
-	AddOpCode(OP_UND_PRINTF, "printf", []int{TYPE_UNDEFINED}, []int{})
-	AddOpCode(OP_UND_SPRINTF, "sprintf", []int{TYPE_UNDEFINED}, []int{TYPE_STR})
+	AddOpCode(OP_UND_CAP, "cap", []int{TYPE_UNDEFINED}, []int{TYPE_I32})
+	AddVariadicOpCode(OP_UND_PRINTF, "printf", []int{TYPE_UNDEFINED}, []int{})
+	AddVariadicOpCode(OP_UND_SPRINTF, "sprintf", []int{TYPE_UNDEFINED}, []int{TYPE_STR})
 	AddOpCode(OP_UND_READ, "read", []int{}, []int{TYPE_STR})
 
 	AddOpCode(OP_BYTE_BYTE, "byte.byte", []int{TYPE_BYTE}, []int{TYPE_BYTE})
@@ -305,6 +573,12 @@ func init () {
 	AddOpCode(OP_BYTE_I64, "byte.i64", []int{TYPE_BYTE}, []int{TYPE_I64})
 	AddOpCode(OP_BYTE_F32, "byte.f32", []int{TYPE_BYTE}, []int{TYPE_F32})
 	AddOpCode(OP_BYTE_F64, "byte.f64", []int{TYPE_BYTE}, []int{TYPE_F64})
+	AddOpCode(OP_BYTE_I8, "byte.i8", []int{TYPE_BYTE}, []int{TYPE_I8})
+	AddOpCode(OP_BYTE_I16, "byte.i16", []int{TYPE_BYTE}, []int{TYPE_I16})
+	AddOpCode(OP_BYTE_UI8, "byte.ui8", []int{TYPE_BYTE}, []int{TYPE_UI8})
+	AddOpCode(OP_BYTE_UI16, "byte.ui16", []int{TYPE_BYTE}, []int{TYPE_UI16})
+	AddOpCode(OP_BYTE_UI32, "byte.ui32", []int{TYPE_BYTE}, []int{TYPE_UI32})
+	AddOpCode(OP_BYTE_UI64, "byte.ui64", []int{TYPE_BYTE}, []int{TYPE_UI64})
 
 	AddOpCode(OP_BYTE_PRINT, "byte.print", []int{TYPE_BYTE}, []int{})
 
@@ -315,12 +589,86 @@ func init () {
 	AddOpCode(OP_BOOL_OR, "bool.or", []int{TYPE_BOOL, TYPE_BOOL}, []int{TYPE_BOOL})
 	AddOpCode(OP_BOOL_AND, "bool.and", []int{TYPE_BOOL, TYPE_BOOL}, []int{TYPE_BOOL})
 
+	AddOpCode(OP_I8_BYTE, "i8.byte", []int{TYPE_I8}, []int{TYPE_BYTE})
+	AddOpCode(OP_I8_STR, "i8.str", []int{TYPE_I8}, []int{TYPE_STR})
+	AddOpCode(OP_I8_I8, "i8.i8", []int{TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_I16, "i8.i16", []int{TYPE_I8}, []int{TYPE_I16})
+	AddOpCode(OP_I8_I32, "i8.i32", []int{TYPE_I8}, []int{TYPE_I32})
+	AddOpCode(OP_I8_I64, "i8.i64", []int{TYPE_I8}, []int{TYPE_I64})
+	AddOpCode(OP_I8_UI8, "i8.ui8", []int{TYPE_I8}, []int{TYPE_UI8})
+	AddOpCode(OP_I8_UI16, "i8.ui16", []int{TYPE_I8}, []int{TYPE_UI16})
+	AddOpCode(OP_I8_UI32, "i8.ui32", []int{TYPE_I8}, []int{TYPE_UI32})
+	AddOpCode(OP_I8_UI64, "i8.ui64", []int{TYPE_I8}, []int{TYPE_UI64})
+	AddOpCode(OP_I8_F32, "i8.f32", []int{TYPE_I8}, []int{TYPE_F32})
+	AddOpCode(OP_I8_F64, "i8.f64", []int{TYPE_I8}, []int{TYPE_F64})
+
+	AddOpCode(OP_I8_PRINT, "i8.print", []int{TYPE_I8}, []int{})
+	AddOpCode(OP_I8_ADD, "i8.add", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_SUB, "i8.sub", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_MUL, "i8.mul", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_DIV, "i8.div", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_MOD, "i8.mod", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_GT, "i8.gt", []int{TYPE_I8, TYPE_I8}, []int{TYPE_BOOL})
+	AddOpCode(OP_I8_GTEQ, "i8.gteq", []int{TYPE_I8, TYPE_I8}, []int{TYPE_BOOL})
+	AddOpCode(OP_I8_LT, "i8.lt", []int{TYPE_I8, TYPE_I8}, []int{TYPE_BOOL})
+	AddOpCode(OP_I8_LTEQ, "i8.lteq", []int{TYPE_I8, TYPE_I8}, []int{TYPE_BOOL})
+	AddOpCode(OP_I8_EQ, "i8.eq", []int{TYPE_I8, TYPE_I8}, []int{TYPE_BOOL})
+	AddOpCode(OP_I8_UNEQ, "i8.uneq", []int{TYPE_I8, TYPE_I8}, []int{TYPE_BOOL})
+	AddOpCode(OP_I8_BITAND, "i8.bitand", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_BITOR, "i8.bitor", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_BITXOR, "i8.bitxor", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_BITCLEAR, "i8.bitclear", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_BITSHL, "i8.bitshl", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_BITSHR, "i8.bitshr", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_MAX, "i8.max", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+	AddOpCode(OP_I8_MIN, "i8.min", []int{TYPE_I8, TYPE_I8}, []int{TYPE_I8})
+
+	AddOpCode(OP_I16_BYTE, "i16.byte", []int{TYPE_I16}, []int{TYPE_BYTE})
+	AddOpCode(OP_I16_STR, "i16.str", []int{TYPE_I16}, []int{TYPE_STR})
+	AddOpCode(OP_I16_I8, "i16.i8", []int{TYPE_I16}, []int{TYPE_I8})
+	AddOpCode(OP_I16_I16, "i16.i16", []int{TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_I32, "i16.i32", []int{TYPE_I16}, []int{TYPE_I32})
+	AddOpCode(OP_I16_I64, "i16.i64", []int{TYPE_I16}, []int{TYPE_I64})
+	AddOpCode(OP_I16_UI8, "i16.ui8", []int{TYPE_I16}, []int{TYPE_UI8})
+	AddOpCode(OP_I16_UI16, "i16.ui16", []int{TYPE_I16}, []int{TYPE_UI16})
+	AddOpCode(OP_I16_UI32, "i16.ui32", []int{TYPE_I16}, []int{TYPE_UI32})
+	AddOpCode(OP_I16_UI64, "i16.ui64", []int{TYPE_I16}, []int{TYPE_UI64})
+	AddOpCode(OP_I16_F32, "i16.f32", []int{TYPE_I16}, []int{TYPE_F32})
+	AddOpCode(OP_I16_F64, "i16.f64", []int{TYPE_I16}, []int{TYPE_F64})
+
+	AddOpCode(OP_I16_PRINT, "i16.print", []int{TYPE_I16}, []int{})
+	AddOpCode(OP_I16_ADD, "i16.add", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_SUB, "i16.sub", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_MUL, "i16.mul", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_DIV, "i16.div", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_MOD, "i16.mod", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_GT, "i16.gt", []int{TYPE_I16, TYPE_I16}, []int{TYPE_BOOL})
+	AddOpCode(OP_I16_GTEQ, "i16.gteq", []int{TYPE_I16, TYPE_I16}, []int{TYPE_BOOL})
+	AddOpCode(OP_I16_LT, "i16.lt", []int{TYPE_I16, TYPE_I16}, []int{TYPE_BOOL})
+	AddOpCode(OP_I16_LTEQ, "i16.lteq", []int{TYPE_I16, TYPE_I16}, []int{TYPE_BOOL})
+	AddOpCode(OP_I16_EQ, "i16.eq", []int{TYPE_I16, TYPE_I16}, []int{TYPE_BOOL})
+	AddOpCode(OP_I16_UNEQ, "i16.uneq", []int{TYPE_I16, TYPE_I16}, []int{TYPE_BOOL})
+	AddOpCode(OP_I16_BITAND, "i16.bitand", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_BITOR, "i16.bitor", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_BITXOR, "i16.bitxor", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_BITCLEAR, "i16.bitclear", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_BITSHL, "i16.bitshl", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_BITSHR, "i16.bitshr", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_MAX, "i16.max", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+	AddOpCode(OP_I16_MIN, "i16.min", []int{TYPE_I16, TYPE_I16}, []int{TYPE_I16})
+
 	AddOpCode(OP_I32_BYTE, "i32.byte", []int{TYPE_I32}, []int{TYPE_BYTE})
 	AddOpCode(OP_I32_STR, "i32.str", []int{TYPE_I32}, []int{TYPE_STR})
 	AddOpCode(OP_I32_I32, "i32.i32", []int{TYPE_I32}, []int{TYPE_I32})
 	AddOpCode(OP_I32_I64, "i32.i64", []int{TYPE_I32}, []int{TYPE_I64})
 	AddOpCode(OP_I32_F32, "i32.f32", []int{TYPE_I32}, []int{TYPE_F32})
 	AddOpCode(OP_I32_F64, "i32.f64", []int{TYPE_I32}, []int{TYPE_F64})
+	AddOpCode(OP_I32_I8, "i32.i8", []int{TYPE_I32}, []int{TYPE_I8})
+	AddOpCode(OP_I32_I16, "i32.i16", []int{TYPE_I32}, []int{TYPE_I16})
+	AddOpCode(OP_I32_UI8, "i32.ui8", []int{TYPE_I32}, []int{TYPE_UI8})
+	AddOpCode(OP_I32_UI16, "i32.ui16", []int{TYPE_I32}, []int{TYPE_UI16})
+	AddOpCode(OP_I32_UI32, "i32.ui32", []int{TYPE_I32}, []int{TYPE_UI32})
+	AddOpCode(OP_I32_UI64, "i32.ui64", []int{TYPE_I32}, []int{TYPE_UI64})
 
 	AddOpCode(OP_I32_PRINT, "i32.print", []int{TYPE_I32}, []int{})
 	AddOpCode(OP_I32_ADD, "i32.add", []int{TYPE_I32, TYPE_I32}, []int{TYPE_I32})
@@ -356,6 +704,12 @@ func init () {
 	AddOpCode(OP_I64_I64, "i64.i64", []int{TYPE_I64}, []int{TYPE_I64})
 	AddOpCode(OP_I64_F32, "i64.f32", []int{TYPE_I64}, []int{TYPE_F32})
 	AddOpCode(OP_I64_F64, "i64.f64", []int{TYPE_I64}, []int{TYPE_F64})
+	AddOpCode(OP_I64_I8, "i64.i8", []int{TYPE_I64}, []int{TYPE_I8})
+	AddOpCode(OP_I64_I16, "i64.i16", []int{TYPE_I64}, []int{TYPE_I16})
+	AddOpCode(OP_I64_UI8, "i64.ui8", []int{TYPE_I64}, []int{TYPE_UI8})
+	AddOpCode(OP_I64_UI16, "i64.ui16", []int{TYPE_I64}, []int{TYPE_UI16})
+	AddOpCode(OP_I64_UI32, "i64.ui32", []int{TYPE_I64}, []int{TYPE_UI32})
+	AddOpCode(OP_I64_UI64, "i64.ui64", []int{TYPE_I64}, []int{TYPE_UI64})
 
 	AddOpCode(OP_I64_PRINT, "i64.print", []int{TYPE_I64}, []int{})
 	AddOpCode(OP_I64_ADD, "i64.add", []int{TYPE_I64, TYPE_I64}, []int{TYPE_I64})
@@ -385,6 +739,142 @@ func init () {
 	AddOpCode(OP_I64_MAX, "i64.max", []int{TYPE_I64, TYPE_I64}, []int{TYPE_I64})
 	AddOpCode(OP_I64_MIN, "i64.min", []int{TYPE_I64, TYPE_I64}, []int{TYPE_I64})
 
+	AddOpCode(OP_UI8_BYTE, "ui8.byte", []int{TYPE_UI8}, []int{TYPE_BYTE})
+	AddOpCode(OP_UI8_STR, "ui8.str", []int{TYPE_UI8}, []int{TYPE_STR})
+	AddOpCode(OP_UI8_I8, "ui8.i8", []int{TYPE_UI8}, []int{TYPE_I8})
+	AddOpCode(OP_UI8_I16, "ui8.i16", []int{TYPE_UI8}, []int{TYPE_I16})
+	AddOpCode(OP_UI8_I32, "ui8.i32", []int{TYPE_UI8}, []int{TYPE_I32})
+	AddOpCode(OP_UI8_I64, "ui8.i64", []int{TYPE_UI8}, []int{TYPE_I64})
+	AddOpCode(OP_UI8_UI8, "ui8.ui8", []int{TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_UI16, "ui8.ui16", []int{TYPE_UI8}, []int{TYPE_UI16})
+	AddOpCode(OP_UI8_UI32, "ui8.ui32", []int{TYPE_UI8}, []int{TYPE_UI32})
+	AddOpCode(OP_UI8_UI64, "ui8.ui64", []int{TYPE_UI8}, []int{TYPE_UI64})
+	AddOpCode(OP_UI8_F32, "ui8.f32", []int{TYPE_UI8}, []int{TYPE_F32})
+	AddOpCode(OP_UI8_F64, "ui8.f64", []int{TYPE_UI8}, []int{TYPE_F64})
+
+	AddOpCode(OP_UI8_PRINT, "ui8.print", []int{TYPE_UI8}, []int{})
+	AddOpCode(OP_UI8_ADD, "ui8.add", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_SUB, "ui8.sub", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_MUL, "ui8.mul", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_DIV, "ui8.div", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_MOD, "ui8.mod", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_GT, "ui8.gt", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI8_GTEQ, "ui8.gteq", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI8_LT, "ui8.lt", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI8_LTEQ, "ui8.lteq", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI8_EQ, "ui8.eq", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI8_UNEQ, "ui8.uneq", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI8_BITAND, "ui8.bitand", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_BITOR, "ui8.bitor", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_BITXOR, "ui8.bitxor", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_BITCLEAR, "ui8.bitclear", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_BITSHL, "ui8.bitshl", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_BITSHR, "ui8.bitshr", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_MAX, "ui8.max", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+	AddOpCode(OP_UI8_MIN, "ui8.min", []int{TYPE_UI8, TYPE_UI8}, []int{TYPE_UI8})
+
+	AddOpCode(OP_UI16_BYTE, "ui16.byte", []int{TYPE_UI16}, []int{TYPE_BYTE})
+	AddOpCode(OP_UI16_STR, "ui16.str", []int{TYPE_UI16}, []int{TYPE_STR})
+	AddOpCode(OP_UI16_I8, "ui16.i8", []int{TYPE_UI16}, []int{TYPE_I8})
+	AddOpCode(OP_UI16_I16, "ui16.i16", []int{TYPE_UI16}, []int{TYPE_I16})
+	AddOpCode(OP_UI16_I32, "ui16.i32", []int{TYPE_UI16}, []int{TYPE_I32})
+	AddOpCode(OP_UI16_I64, "ui16.i64", []int{TYPE_UI16}, []int{TYPE_I64})
+	AddOpCode(OP_UI16_UI8, "ui16.ui8", []int{TYPE_UI16}, []int{TYPE_UI8})
+	AddOpCode(OP_UI16_UI16, "ui16.ui16", []int{TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_UI32, "ui16.ui32", []int{TYPE_UI16}, []int{TYPE_UI32})
+	AddOpCode(OP_UI16_UI64, "ui16.ui64", []int{TYPE_UI16}, []int{TYPE_UI64})
+	AddOpCode(OP_UI16_F32, "ui16.f32", []int{TYPE_UI16}, []int{TYPE_F32})
+	AddOpCode(OP_UI16_F64, "ui16.f64", []int{TYPE_UI16}, []int{TYPE_F64})
+
+	AddOpCode(OP_UI16_PRINT, "ui16.print", []int{TYPE_UI16}, []int{})
+	AddOpCode(OP_UI16_ADD, "ui16.add", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_SUB, "ui16.sub", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_MUL, "ui16.mul", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_DIV, "ui16.div", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_MOD, "ui16.mod", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_GT, "ui16.gt", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI16_GTEQ, "ui16.gteq", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI16_LT, "ui16.lt", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI16_LTEQ, "ui16.lteq", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI16_EQ, "ui16.eq", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI16_UNEQ, "ui16.uneq", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI16_BITAND, "ui16.bitand", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_BITOR, "ui16.bitor", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_BITXOR, "ui16.bitxor", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_BITCLEAR, "ui16.bitclear", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_BITSHL, "ui16.bitshl", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_BITSHR, "ui16.bitshr", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_MAX, "ui16.max", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+	AddOpCode(OP_UI16_MIN, "ui16.min", []int{TYPE_UI16, TYPE_UI16}, []int{TYPE_UI16})
+
+	AddOpCode(OP_UI32_BYTE, "ui32.byte", []int{TYPE_UI32}, []int{TYPE_BYTE})
+	AddOpCode(OP_UI32_STR, "ui32.str", []int{TYPE_UI32}, []int{TYPE_STR})
+	AddOpCode(OP_UI32_I8, "ui32.i8", []int{TYPE_UI32}, []int{TYPE_I8})
+	AddOpCode(OP_UI32_I16, "ui32.i16", []int{TYPE_UI32}, []int{TYPE_I16})
+	AddOpCode(OP_UI32_I32, "ui32.i32", []int{TYPE_UI32}, []int{TYPE_I32})
+	AddOpCode(OP_UI32_I64, "ui32.i64", []int{TYPE_UI32}, []int{TYPE_I64})
+	AddOpCode(OP_UI32_UI8, "ui32.ui8", []int{TYPE_UI32}, []int{TYPE_UI8})
+	AddOpCode(OP_UI32_UI16, "ui32.ui16", []int{TYPE_UI32}, []int{TYPE_UI16})
+	AddOpCode(OP_UI32_UI32, "ui32.ui32", []int{TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_UI64, "ui32.ui64", []int{TYPE_UI32}, []int{TYPE_UI64})
+	AddOpCode(OP_UI32_F32, "ui32.f32", []int{TYPE_UI32}, []int{TYPE_F32})
+	AddOpCode(OP_UI32_F64, "ui32.f64", []int{TYPE_UI32}, []int{TYPE_F64})
+
+	AddOpCode(OP_UI32_PRINT, "ui32.print", []int{TYPE_UI32}, []int{})
+	AddOpCode(OP_UI32_ADD, "ui32.add", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_SUB, "ui32.sub", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_MUL, "ui32.mul", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_DIV, "ui32.div", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_MOD, "ui32.mod", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_GT, "ui32.gt", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI32_GTEQ, "ui32.gteq", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI32_LT, "ui32.lt", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI32_LTEQ, "ui32.lteq", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI32_EQ, "ui32.eq", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI32_UNEQ, "ui32.uneq", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI32_BITAND, "ui32.bitand", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_BITOR, "ui32.bitor", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_BITXOR, "ui32.bitxor", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_BITCLEAR, "ui32.bitclear", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_BITSHL, "ui32.bitshl", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_BITSHR, "ui32.bitshr", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_MAX, "ui32.max", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+	AddOpCode(OP_UI32_MIN, "ui32.min", []int{TYPE_UI32, TYPE_UI32}, []int{TYPE_UI32})
+
+	AddOpCode(OP_UI64_BYTE, "ui64.byte", []int{TYPE_UI64}, []int{TYPE_BYTE})
+	AddOpCode(OP_UI64_STR, "ui64.str", []int{TYPE_UI64}, []int{TYPE_STR})
+	AddOpCode(OP_UI64_I8, "ui64.i8", []int{TYPE_UI64}, []int{TYPE_I8})
+	AddOpCode(OP_UI64_I16, "ui64.i16", []int{TYPE_UI64}, []int{TYPE_I16})
+	AddOpCode(OP_UI64_I32, "ui64.i32", []int{TYPE_UI64}, []int{TYPE_I32})
+	AddOpCode(OP_UI64_I64, "ui64.i64", []int{TYPE_UI64}, []int{TYPE_I64})
+	AddOpCode(OP_UI64_UI8, "ui64.ui8", []int{TYPE_UI64}, []int{TYPE_UI8})
+	AddOpCode(OP_UI64_UI16, "ui64.ui16", []int{TYPE_UI64}, []int{TYPE_UI16})
+	AddOpCode(OP_UI64_UI32, "ui64.ui32", []int{TYPE_UI64}, []int{TYPE_UI32})
+	AddOpCode(OP_UI64_UI64, "ui64.ui64", []int{TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_F32, "ui64.f32", []int{TYPE_UI64}, []int{TYPE_F32})
+	AddOpCode(OP_UI64_F64, "ui64.f64", []int{TYPE_UI64}, []int{TYPE_F64})
+
+	AddOpCode(OP_UI64_PRINT, "ui64.print", []int{TYPE_UI64}, []int{})
+	AddOpCode(OP_UI64_ADD, "ui64.add", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_SUB, "ui64.sub", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_MUL, "ui64.mul", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_DIV, "ui64.div", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_MOD, "ui64.mod", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_GT, "ui64.gt", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI64_GTEQ, "ui64.gteq", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI64_LT, "ui64.lt", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI64_LTEQ, "ui64.lteq", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI64_EQ, "ui64.eq", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI64_UNEQ, "ui64.uneq", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_BOOL})
+	AddOpCode(OP_UI64_BITAND, "ui64.bitand", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_BITOR, "ui64.bitor", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_BITXOR, "ui64.bitxor", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_BITCLEAR, "ui64.bitclear", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_BITSHL, "ui64.bitshl", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_BITSHR, "ui64.bitshr", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_MAX, "ui64.max", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+	AddOpCode(OP_UI64_MIN, "ui64.min", []int{TYPE_UI64, TYPE_UI64}, []int{TYPE_UI64})
+
 	AddOpCode(OP_F32_IS_NAN, "f32.isnan", []int{TYPE_F32}, []int{TYPE_BOOL})
 	AddOpCode(OP_F32_BYTE, "f32.byte", []int{TYPE_F32}, []int{TYPE_BYTE})
 	AddOpCode(OP_F32_STR, "f32.str", []int{TYPE_F32}, []int{TYPE_STR})
@@ -392,6 +882,12 @@ func init () {
 	AddOpCode(OP_F32_I64, "f32.i64", []int{TYPE_F32}, []int{TYPE_I64})
 	AddOpCode(OP_F32_F32, "f32.f32", []int{TYPE_F32}, []int{TYPE_F32})
 	AddOpCode(OP_F32_F64, "f32.f64", []int{TYPE_F32}, []int{TYPE_F64})
+	AddOpCode(OP_F32_I8, "f32.i8", []int{TYPE_F32}, []int{TYPE_I8})
+	AddOpCode(OP_F32_I16, "f32.i16", []int{TYPE_F32}, []int{TYPE_I16})
+	AddOpCode(OP_F32_UI8, "f32.ui8", []int{TYPE_F32}, []int{TYPE_UI8})
+	AddOpCode(OP_F32_UI16, "f32.ui16", []int{TYPE_F32}, []int{TYPE_UI16})
+	AddOpCode(OP_F32_UI32, "f32.ui32", []int{TYPE_F32}, []int{TYPE_UI32})
+	AddOpCode(OP_F32_UI64, "f32.ui64", []int{TYPE_F32}, []int{TYPE_UI64})
 	AddOpCode(OP_F32_PRINT, "f32.print", []int{TYPE_F32}, []int{})
 	AddOpCode(OP_F32_ADD, "f32.add", []int{TYPE_F32, TYPE_F32}, []int{TYPE_F32})
 	AddOpCode(OP_F32_SUB, "f32.sub", []int{TYPE_F32, TYPE_F32}, []int{TYPE_F32})
@@ -420,6 +916,12 @@ func init () {
 	AddOpCode(OP_F64_I64, "f64.i64", []int{TYPE_F64}, []int{TYPE_I64})
 	AddOpCode(OP_F64_F32, "f64.f32", []int{TYPE_F64}, []int{TYPE_F32})
 	AddOpCode(OP_F64_F64, "f64.f64", []int{TYPE_F64}, []int{TYPE_F64})
+	AddOpCode(OP_F64_I8, "f64.i8", []int{TYPE_F64}, []int{TYPE_I8})
+	AddOpCode(OP_F64_I16, "f64.i16", []int{TYPE_F64}, []int{TYPE_I16})
+	AddOpCode(OP_F64_UI8, "f64.ui8", []int{TYPE_F64}, []int{TYPE_UI8})
+	AddOpCode(OP_F64_UI16, "f64.ui16", []int{TYPE_F64}, []int{TYPE_UI16})
+	AddOpCode(OP_F64_UI32, "f64.ui32", []int{TYPE_F64}, []int{TYPE_UI32})
+	AddOpCode(OP_F64_UI64, "f64.ui64", []int{TYPE_F64}, []int{TYPE_UI64})
 
 	AddOpCode(OP_F64_PRINT, "f64.print", []int{TYPE_F64}, []int{})
 	AddOpCode(OP_F64_ADD, "f64.add", []int{TYPE_F64, TYPE_F64}, []int{TYPE_F64})
@@ -449,6 +951,7 @@ func init () {
 	AddOpCode(OP_STR_INDEX, "str.index", []int{TYPE_STR, TYPE_STR}, []int{TYPE_I32})
 	AddOpCode(OP_STR_TRIM_SPACE, "str.trimspace", []int{TYPE_STR}, []int{TYPE_STR})
 	AddOpCode(OP_STR_EQ, "str.eq", []int{TYPE_STR, TYPE_STR}, []int{TYPE_BOOL})
+	AddOpCode(OP_STR_BYTES, "str.bytes", []int{TYPE_STR}, []int{TYPE_BYTE})
 
 	AddOpCode(OP_STR_BYTE, "str.byte", []int{TYPE_STR}, []int{TYPE_BYTE})
 	AddOpCode(OP_STR_STR, "str.str", []int{TYPE_STR}, []int{TYPE_STR})
@@ -456,11 +959,36 @@ func init () {
 	AddOpCode(OP_STR_I64, "str.i64", []int{TYPE_STR}, []int{TYPE_I64})
 	AddOpCode(OP_STR_F32, "str.f32", []int{TYPE_STR}, []int{TYPE_F32})
 	AddOpCode(OP_STR_F64, "str.f64", []int{TYPE_STR}, []int{TYPE_F64})
-
-	AddOpCode(OP_APPEND, "append", []int{TYPE_UNDEFINED, TYPE_UNDEFINED}, []int{TYPE_UNDEFINED})
+	AddOpCode(OP_STR_I8, "str.i8", []int{TYPE_STR}, []int{TYPE_I8})
+	AddOpCode(OP_STR_I16, "str.i16", []int{TYPE_STR}, []int{TYPE_I16})
+	AddOpCode(OP_STR_UI8, "str.ui8", []int{TYPE_STR}, []int{TYPE_UI8})
+	AddOpCode(OP_STR_UI16, "str.ui16", []int{TYPE_STR}, []int{TYPE_UI16})
+	AddOpCode(OP_STR_UI32, "str.ui32", []int{TYPE_STR}, []int{TYPE_UI32})
+	AddOpCode(OP_STR_UI64, "str.ui64", []int{TYPE_STR}, []int{TYPE_UI64})
+
+	AddVariadicOpCode(OP_APPEND, "append", []int{TYPE_UNDEFINED, TYPE_UNDEFINED}, []int{TYPE_UNDEFINED})
+	AddOpCode(OP_SLICE, "slice.expr", []int{TYPE_UNDEFINED, TYPE_I32, TYPE_I32, TYPE_I32}, []int{TYPE_UNDEFINED})
+	AddOpCode(OP_DELETE, "delete", []int{TYPE_UNDEFINED, TYPE_UNDEFINED}, []int{})
+	AddOpCode(OP_MAKE, "make", []int{TYPE_I32}, []int{TYPE_UNDEFINED})
+	AddOpCode(OP_SEND, "send", []int{TYPE_UNDEFINED, TYPE_UNDEFINED}, []int{})
+	AddOpCode(OP_RECV, "recv", []int{TYPE_UNDEFINED}, []int{TYPE_UNDEFINED})
+	AddOpCode(OP_CLOSE, "close", []int{TYPE_UNDEFINED}, []int{})
+	AddVariadicOpCode(OP_SELECT, "select", []int{TYPE_UNDEFINED}, []int{TYPE_I32})
+	AddVariadicOpCode(OP_CLOSURE, "closure", []int{TYPE_UNDEFINED}, []int{TYPE_FUNC})
+	AddOpCode(OP_CALL, "call", []int{TYPE_UNDEFINED}, []int{TYPE_UNDEFINED})
+	AddOpCode(OP_TYPE_ASSERT, "type.assert", []int{TYPE_UNDEFINED, TYPE_UNDEFINED}, []int{TYPE_UNDEFINED})
+	AddOpCode(OP_RANGE_LEN, "range.len", []int{TYPE_UNDEFINED}, []int{TYPE_I32})
+	AddOpCode(OP_RANGE_NEXT, "range.next", []int{TYPE_UNDEFINED, TYPE_I32}, []int{TYPE_I32, TYPE_I32, TYPE_I32})
 	AddOpCode(OP_ASSERT, "assert", []int{TYPE_UNDEFINED, TYPE_UNDEFINED, TYPE_STR}, []int{TYPE_BOOL})
 	AddOpCode(OP_TEST, "test", []int{TYPE_UNDEFINED, TYPE_UNDEFINED, TYPE_STR}, []int{})
 	AddOpCode(OP_PANIC, "panic", []int{TYPE_UNDEFINED, TYPE_UNDEFINED, TYPE_STR}, []int{})
+	AddOpCode(OP_PANIC_VALUE, "panic.value", []int{TYPE_UNDEFINED}, []int{})
+	AddOpCode(OP_RECOVER, "recover", []int{}, []int{TYPE_STR})
+
+	// errors
+	AddOpCode(OP_ERRORS_NEW, "errors.New", []int{TYPE_STR}, []int{TYPE_STR})
+	AddOpCode(OP_ERRORS_WRAP, "errors.Wrap", []int{TYPE_STR, TYPE_STR}, []int{TYPE_STR})
+	AddOpCode(OP_ERRORS_MESSAGE, "errors.Message", []int{TYPE_STR}, []int{TYPE_STR})
 
 	// affordances
 	AddOpCode(OP_AFF_PRINT, "aff.print", []int{TYPE_AFF}, []int{})
@@ -527,6 +1055,8 @@ func init () {
 			op_gteq(expr, fp)
 		case OP_UND_LEN:
 			op_len(expr, fp)
+		case OP_UND_CAP:
+			op_cap(expr, fp)
 		case OP_UND_PRINTF:
 			op_printf(expr, fp)
 		case OP_UND_SPRINTF:
@@ -546,6 +1076,18 @@ func init () {
 			op_byte_byte(expr, fp)
 		case OP_BYTE_F64:
 			op_byte_byte(expr, fp)
+		case OP_BYTE_I8:
+			op_byte_byte(expr, fp)
+		case OP_BYTE_I16:
+			op_byte_byte(expr, fp)
+		case OP_BYTE_UI8:
+			op_byte_byte(expr, fp)
+		case OP_BYTE_UI16:
+			op_byte_byte(expr, fp)
+		case OP_BYTE_UI32:
+			op_byte_byte(expr, fp)
+		case OP_BYTE_UI64:
+			op_byte_byte(expr, fp)
 
 		case OP_BYTE_PRINT:
 			op_byte_print(expr, fp)
@@ -563,6 +1105,138 @@ func init () {
 		case OP_BOOL_AND:
 			op_bool_and(expr, fp)
 
+		case OP_I8_BYTE:
+			op_i8_i8(expr, fp)
+		case OP_I8_STR:
+			op_i8_i8(expr, fp)
+		case OP_I8_I8:
+			op_i8_i8(expr, fp)
+		case OP_I8_I16:
+			op_i8_i8(expr, fp)
+		case OP_I8_I32:
+			op_i8_i8(expr, fp)
+		case OP_I8_I64:
+			op_i8_i8(expr, fp)
+		case OP_I8_UI8:
+			op_i8_i8(expr, fp)
+		case OP_I8_UI16:
+			op_i8_i8(expr, fp)
+		case OP_I8_UI32:
+			op_i8_i8(expr, fp)
+		case OP_I8_UI64:
+			op_i8_i8(expr, fp)
+		case OP_I8_F32:
+			op_i8_i8(expr, fp)
+		case OP_I8_F64:
+			op_i8_i8(expr, fp)
+
+		case OP_I8_PRINT:
+			op_i8_print(expr, fp)
+		case OP_I8_ADD:
+			op_i8_add(expr, fp)
+		case OP_I8_SUB:
+			op_i8_sub(expr, fp)
+		case OP_I8_MUL:
+			op_i8_mul(expr, fp)
+		case OP_I8_DIV:
+			op_i8_div(expr, fp)
+		case OP_I8_MOD:
+			op_i8_mod(expr, fp)
+		case OP_I8_GT:
+			op_i8_gt(expr, fp)
+		case OP_I8_GTEQ:
+			op_i8_gteq(expr, fp)
+		case OP_I8_LT:
+			op_i8_lt(expr, fp)
+		case OP_I8_LTEQ:
+			op_i8_lteq(expr, fp)
+		case OP_I8_EQ:
+			op_i8_eq(expr, fp)
+		case OP_I8_UNEQ:
+			op_i8_uneq(expr, fp)
+		case OP_I8_BITAND:
+			op_i8_bitand(expr, fp)
+		case OP_I8_BITOR:
+			op_i8_bitor(expr, fp)
+		case OP_I8_BITXOR:
+			op_i8_bitxor(expr, fp)
+		case OP_I8_BITCLEAR:
+			op_i8_bitclear(expr, fp)
+		case OP_I8_BITSHL:
+			op_i8_bitshl(expr, fp)
+		case OP_I8_BITSHR:
+			op_i8_bitshr(expr, fp)
+		case OP_I8_MAX:
+			op_i8_max(expr, fp)
+		case OP_I8_MIN:
+			op_i8_min(expr, fp)
+
+		case OP_I16_BYTE:
+			op_i16_i16(expr, fp)
+		case OP_I16_STR:
+			op_i16_i16(expr, fp)
+		case OP_I16_I8:
+			op_i16_i16(expr, fp)
+		case OP_I16_I16:
+			op_i16_i16(expr, fp)
+		case OP_I16_I32:
+			op_i16_i16(expr, fp)
+		case OP_I16_I64:
+			op_i16_i16(expr, fp)
+		case OP_I16_UI8:
+			op_i16_i16(expr, fp)
+		case OP_I16_UI16:
+			op_i16_i16(expr, fp)
+		case OP_I16_UI32:
+			op_i16_i16(expr, fp)
+		case OP_I16_UI64:
+			op_i16_i16(expr, fp)
+		case OP_I16_F32:
+			op_i16_i16(expr, fp)
+		case OP_I16_F64:
+			op_i16_i16(expr, fp)
+
+		case OP_I16_PRINT:
+			op_i16_print(expr, fp)
+		case OP_I16_ADD:
+			op_i16_add(expr, fp)
+		case OP_I16_SUB:
+			op_i16_sub(expr, fp)
+		case OP_I16_MUL:
+			op_i16_mul(expr, fp)
+		case OP_I16_DIV:
+			op_i16_div(expr, fp)
+		case OP_I16_MOD:
+			op_i16_mod(expr, fp)
+		case OP_I16_GT:
+			op_i16_gt(expr, fp)
+		case OP_I16_GTEQ:
+			op_i16_gteq(expr, fp)
+		case OP_I16_LT:
+			op_i16_lt(expr, fp)
+		case OP_I16_LTEQ:
+			op_i16_lteq(expr, fp)
+		case OP_I16_EQ:
+			op_i16_eq(expr, fp)
+		case OP_I16_UNEQ:
+			op_i16_uneq(expr, fp)
+		case OP_I16_BITAND:
+			op_i16_bitand(expr, fp)
+		case OP_I16_BITOR:
+			op_i16_bitor(expr, fp)
+		case OP_I16_BITXOR:
+			op_i16_bitxor(expr, fp)
+		case OP_I16_BITCLEAR:
+			op_i16_bitclear(expr, fp)
+		case OP_I16_BITSHL:
+			op_i16_bitshl(expr, fp)
+		case OP_I16_BITSHR:
+			op_i16_bitshr(expr, fp)
+		case OP_I16_MAX:
+			op_i16_max(expr, fp)
+		case OP_I16_MIN:
+			op_i16_min(expr, fp)
+
 		case OP_I32_BYTE:
 			op_i32_i32(expr, fp)
 		case OP_I32_STR:
@@ -575,6 +1249,18 @@ func init () {
 			op_i32_i32(expr, fp)
 		case OP_I32_F64:
 			op_i32_i32(expr, fp)
+		case OP_I32_I8:
+			op_i32_i32(expr, fp)
+		case OP_I32_I16:
+			op_i32_i32(expr, fp)
+		case OP_I32_UI8:
+			op_i32_i32(expr, fp)
+		case OP_I32_UI16:
+			op_i32_i32(expr, fp)
+		case OP_I32_UI32:
+			op_i32_i32(expr, fp)
+		case OP_I32_UI64:
+			op_i32_i32(expr, fp)
 
 		case OP_I32_PRINT:
 			op_i32_print(expr, fp)
@@ -644,6 +1330,18 @@ func init () {
 			op_i64_i64(expr, fp)
 		case OP_I64_F64:
 			op_i64_i64(expr, fp)
+		case OP_I64_I8:
+			op_i64_i64(expr, fp)
+		case OP_I64_I16:
+			op_i64_i64(expr, fp)
+		case OP_I64_UI8:
+			op_i64_i64(expr, fp)
+		case OP_I64_UI16:
+			op_i64_i64(expr, fp)
+		case OP_I64_UI32:
+			op_i64_i64(expr, fp)
+		case OP_I64_UI64:
+			op_i64_i64(expr, fp)
 
 		case OP_I64_PRINT:
 			op_i64_print(expr, fp)
@@ -700,6 +1398,270 @@ func init () {
 		case OP_I64_MIN:
 			op_i64_min(expr, fp)
 
+		case OP_UI8_BYTE:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_STR:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_I8:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_I16:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_I32:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_I64:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_UI8:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_UI16:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_UI32:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_UI64:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_F32:
+			op_ui8_ui8(expr, fp)
+		case OP_UI8_F64:
+			op_ui8_ui8(expr, fp)
+
+		case OP_UI8_PRINT:
+			op_ui8_print(expr, fp)
+		case OP_UI8_ADD:
+			op_ui8_add(expr, fp)
+		case OP_UI8_SUB:
+			op_ui8_sub(expr, fp)
+		case OP_UI8_MUL:
+			op_ui8_mul(expr, fp)
+		case OP_UI8_DIV:
+			op_ui8_div(expr, fp)
+		case OP_UI8_MOD:
+			op_ui8_mod(expr, fp)
+		case OP_UI8_GT:
+			op_ui8_gt(expr, fp)
+		case OP_UI8_GTEQ:
+			op_ui8_gteq(expr, fp)
+		case OP_UI8_LT:
+			op_ui8_lt(expr, fp)
+		case OP_UI8_LTEQ:
+			op_ui8_lteq(expr, fp)
+		case OP_UI8_EQ:
+			op_ui8_eq(expr, fp)
+		case OP_UI8_UNEQ:
+			op_ui8_uneq(expr, fp)
+		case OP_UI8_BITAND:
+			op_ui8_bitand(expr, fp)
+		case OP_UI8_BITOR:
+			op_ui8_bitor(expr, fp)
+		case OP_UI8_BITXOR:
+			op_ui8_bitxor(expr, fp)
+		case OP_UI8_BITCLEAR:
+			op_ui8_bitclear(expr, fp)
+		case OP_UI8_BITSHL:
+			op_ui8_bitshl(expr, fp)
+		case OP_UI8_BITSHR:
+			op_ui8_bitshr(expr, fp)
+		case OP_UI8_MAX:
+			op_ui8_max(expr, fp)
+		case OP_UI8_MIN:
+			op_ui8_min(expr, fp)
+
+		case OP_UI16_BYTE:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_STR:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_I8:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_I16:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_I32:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_I64:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_UI8:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_UI16:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_UI32:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_UI64:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_F32:
+			op_ui16_ui16(expr, fp)
+		case OP_UI16_F64:
+			op_ui16_ui16(expr, fp)
+
+		case OP_UI16_PRINT:
+			op_ui16_print(expr, fp)
+		case OP_UI16_ADD:
+			op_ui16_add(expr, fp)
+		case OP_UI16_SUB:
+			op_ui16_sub(expr, fp)
+		case OP_UI16_MUL:
+			op_ui16_mul(expr, fp)
+		case OP_UI16_DIV:
+			op_ui16_div(expr, fp)
+		case OP_UI16_MOD:
+			op_ui16_mod(expr, fp)
+		case OP_UI16_GT:
+			op_ui16_gt(expr, fp)
+		case OP_UI16_GTEQ:
+			op_ui16_gteq(expr, fp)
+		case OP_UI16_LT:
+			op_ui16_lt(expr, fp)
+		case OP_UI16_LTEQ:
+			op_ui16_lteq(expr, fp)
+		case OP_UI16_EQ:
+			op_ui16_eq(expr, fp)
+		case OP_UI16_UNEQ:
+			op_ui16_uneq(expr, fp)
+		case OP_UI16_BITAND:
+			op_ui16_bitand(expr, fp)
+		case OP_UI16_BITOR:
+			op_ui16_bitor(expr, fp)
+		case OP_UI16_BITXOR:
+			op_ui16_bitxor(expr, fp)
+		case OP_UI16_BITCLEAR:
+			op_ui16_bitclear(expr, fp)
+		case OP_UI16_BITSHL:
+			op_ui16_bitshl(expr, fp)
+		case OP_UI16_BITSHR:
+			op_ui16_bitshr(expr, fp)
+		case OP_UI16_MAX:
+			op_ui16_max(expr, fp)
+		case OP_UI16_MIN:
+			op_ui16_min(expr, fp)
+
+		case OP_UI32_BYTE:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_STR:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_I8:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_I16:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_I32:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_I64:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_UI8:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_UI16:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_UI32:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_UI64:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_F32:
+			op_ui32_ui32(expr, fp)
+		case OP_UI32_F64:
+			op_ui32_ui32(expr, fp)
+
+		case OP_UI32_PRINT:
+			op_ui32_print(expr, fp)
+		case OP_UI32_ADD:
+			op_ui32_add(expr, fp)
+		case OP_UI32_SUB:
+			op_ui32_sub(expr, fp)
+		case OP_UI32_MUL:
+			op_ui32_mul(expr, fp)
+		case OP_UI32_DIV:
+			op_ui32_div(expr, fp)
+		case OP_UI32_MOD:
+			op_ui32_mod(expr, fp)
+		case OP_UI32_GT:
+			op_ui32_gt(expr, fp)
+		case OP_UI32_GTEQ:
+			op_ui32_gteq(expr, fp)
+		case OP_UI32_LT:
+			op_ui32_lt(expr, fp)
+		case OP_UI32_LTEQ:
+			op_ui32_lteq(expr, fp)
+		case OP_UI32_EQ:
+			op_ui32_eq(expr, fp)
+		case OP_UI32_UNEQ:
+			op_ui32_uneq(expr, fp)
+		case OP_UI32_BITAND:
+			op_ui32_bitand(expr, fp)
+		case OP_UI32_BITOR:
+			op_ui32_bitor(expr, fp)
+		case OP_UI32_BITXOR:
+			op_ui32_bitxor(expr, fp)
+		case OP_UI32_BITCLEAR:
+			op_ui32_bitclear(expr, fp)
+		case OP_UI32_BITSHL:
+			op_ui32_bitshl(expr, fp)
+		case OP_UI32_BITSHR:
+			op_ui32_bitshr(expr, fp)
+		case OP_UI32_MAX:
+			op_ui32_max(expr, fp)
+		case OP_UI32_MIN:
+			op_ui32_min(expr, fp)
+
+		case OP_UI64_BYTE:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_STR:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_I8:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_I16:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_I32:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_I64:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_UI8:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_UI16:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_UI32:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_UI64:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_F32:
+			op_ui64_ui64(expr, fp)
+		case OP_UI64_F64:
+			op_ui64_ui64(expr, fp)
+
+		case OP_UI64_PRINT:
+			op_ui64_print(expr, fp)
+		case OP_UI64_ADD:
+			op_ui64_add(expr, fp)
+		case OP_UI64_SUB:
+			op_ui64_sub(expr, fp)
+		case OP_UI64_MUL:
+			op_ui64_mul(expr, fp)
+		case OP_UI64_DIV:
+			op_ui64_div(expr, fp)
+		case OP_UI64_MOD:
+			op_ui64_mod(expr, fp)
+		case OP_UI64_GT:
+			op_ui64_gt(expr, fp)
+		case OP_UI64_GTEQ:
+			op_ui64_gteq(expr, fp)
+		case OP_UI64_LT:
+			op_ui64_lt(expr, fp)
+		case OP_UI64_LTEQ:
+			op_ui64_lteq(expr, fp)
+		case OP_UI64_EQ:
+			op_ui64_eq(expr, fp)
+		case OP_UI64_UNEQ:
+			op_ui64_uneq(expr, fp)
+		case OP_UI64_BITAND:
+			op_ui64_bitand(expr, fp)
+		case OP_UI64_BITOR:
+			op_ui64_bitor(expr, fp)
+		case OP_UI64_BITXOR:
+			op_ui64_bitxor(expr, fp)
+		case OP_UI64_BITCLEAR:
+			op_ui64_bitclear(expr, fp)
+		case OP_UI64_BITSHL:
+			op_ui64_bitshl(expr, fp)
+		case OP_UI64_BITSHR:
+			op_ui64_bitshr(expr, fp)
+		case OP_UI64_MAX:
+			op_ui64_max(expr, fp)
+		case OP_UI64_MIN:
+			op_ui64_min(expr, fp)
+
 		case OP_F32_IS_NAN:
 			op_f32_isnan(expr, fp)
 		case OP_F32_BYTE:
@@ -714,6 +1676,18 @@ func init () {
 			op_f32_f32(expr, fp)
 		case OP_F32_F64:
 			op_f32_f32(expr, fp)
+		case OP_F32_I8:
+			op_f32_f32(expr, fp)
+		case OP_F32_I16:
+			op_f32_f32(expr, fp)
+		case OP_F32_UI8:
+			op_f32_f32(expr, fp)
+		case OP_F32_UI16:
+			op_f32_f32(expr, fp)
+		case OP_F32_UI32:
+			op_f32_f32(expr, fp)
+		case OP_F32_UI64:
+			op_f32_f32(expr, fp)
 		case OP_F32_PRINT:
 			op_f32_print(expr, fp)
 		case OP_F32_ADD:
@@ -769,6 +1743,18 @@ func init () {
 			op_f64_f64(expr, fp)
 		case OP_F64_F64:
 			op_f64_f64(expr, fp)
+		case OP_F64_I8:
+			op_f64_f64(expr, fp)
+		case OP_F64_I16:
+			op_f64_f64(expr, fp)
+		case OP_F64_UI8:
+			op_f64_f64(expr, fp)
+		case OP_F64_UI16:
+			op_f64_f64(expr, fp)
+		case OP_F64_UI32:
+			op_f64_f64(expr, fp)
+		case OP_F64_UI64:
+			op_f64_f64(expr, fp)
 
 		case OP_F64_PRINT:
 			op_f64_print(expr, fp)
@@ -824,6 +1810,8 @@ func init () {
 			op_str_trim_space(expr, fp)
 		case OP_STR_EQ:
 			op_str_eq(expr, fp)
+		case OP_STR_BYTES:
+			op_str_bytes(expr, fp)
 
 		case OP_STR_BYTE:
 			op_str_str(expr, fp)
@@ -837,14 +1825,49 @@ func init () {
 			op_str_str(expr, fp)
 		case OP_STR_F64:
 			op_str_str(expr, fp)
+		case OP_STR_I8:
+			op_str_str(expr, fp)
+		case OP_STR_I16:
+			op_str_str(expr, fp)
+		case OP_STR_UI8:
+			op_str_str(expr, fp)
+		case OP_STR_UI16:
+			op_str_str(expr, fp)
+		case OP_STR_UI32:
+			op_str_str(expr, fp)
+		case OP_STR_UI64:
+			op_str_str(expr, fp)
 
 		case OP_MAKE:
+			op_make(expr, fp)
 		case OP_READ:
 		case OP_WRITE:
 		case OP_LEN:
 		case OP_CONCAT:
 		case OP_APPEND:
 			op_append(expr, fp)
+		case OP_SLICE:
+			op_slice(expr, fp)
+		case OP_DELETE:
+			op_delete(expr, fp)
+		case OP_SEND:
+			op_send(expr, fp)
+		case OP_RECV:
+			op_recv(expr, fp)
+		case OP_CLOSE:
+			op_close(expr, fp)
+		case OP_SELECT:
+			op_select(expr, fp)
+		case OP_CLOSURE:
+			op_closure(expr, fp)
+		case OP_CALL:
+			// calls through function values are handled by ccall
+		case OP_TYPE_ASSERT:
+			op_type_assert(expr, fp)
+		case OP_RANGE_LEN:
+			op_range_len(expr, fp)
+		case OP_RANGE_NEXT:
+			op_range_next(expr, fp)
 		case OP_COPY:
 		case OP_CAST:
 		case OP_EQ:
@@ -868,6 +1891,18 @@ func init () {
 			op_test(expr, fp)
 		case OP_PANIC:
 			op_panic(expr, fp)
+		case OP_PANIC_VALUE:
+			op_panic_value(expr, fp)
+		case OP_RECOVER:
+			op_recover(expr, fp)
+
+		// errors
+		case OP_ERRORS_NEW:
+			op_errors_New(expr, fp)
+		case OP_ERRORS_WRAP:
+			op_errors_Wrap(expr, fp)
+		case OP_ERRORS_MESSAGE:
+			op_errors_Message(expr, fp)
 
 		// affordances
 		case OP_AFF_PRINT:
@@ -19,6 +19,7 @@ const (
 
 	// os
 	OP_OS_GET_WORKING_DIRECTORY
+	OP_OS_READ_FILE
 	OP_OS_OPEN
 	OP_OS_CLOSE
 	OP_OS_RUN
@@ -42,12 +43,13 @@ func init () {
 	AddOpCode(OP_TIME_UNIX_NANO, "time.UnixNano", []int{}, []int{TYPE_I64})
 
 	// http
-	AddOpCode(OP_HTTP_GET, "http.Get", []int{TYPE_STR}, []int{TYPE_STR})
+	AddOpCode(OP_HTTP_GET, "http.Get", []int{TYPE_STR}, []int{TYPE_STR, TYPE_STR})
 
 	// os
 	AddOpCode(OP_OS_GET_WORKING_DIRECTORY, "os.GetWorkingDirectory", []int{}, []int{TYPE_STR})
-	AddOpCode(OP_OS_OPEN, "os.Open", []int{TYPE_STR}, []int{})
-	AddOpCode(OP_OS_CLOSE, "os.Close", []int{TYPE_STR}, []int{})
+	AddOpCode(OP_OS_READ_FILE, "os.ReadFile", []int{TYPE_STR}, []int{TYPE_STR, TYPE_STR})
+	AddOpCode(OP_OS_OPEN, "os.Open", []int{TYPE_STR}, []int{TYPE_STR})
+	AddOpCode(OP_OS_CLOSE, "os.Close", []int{TYPE_STR}, []int{TYPE_STR})
 	AddOpCode(OP_OS_RUN, "os.Run", []int{TYPE_STR, TYPE_I32, TYPE_I32, TYPE_STR}, []int{TYPE_I32, TYPE_I32, TYPE_STR})
 	AddOpCode(OP_OS_EXIT, "os.Exit", []int{TYPE_I32}, []int{})
 
@@ -79,6 +81,8 @@ func init () {
 				// os
 				case OP_OS_GET_WORKING_DIRECTORY:
 					op_os_GetWorkingDirectory(expr, fp)
+				case OP_OS_READ_FILE:
+					op_os_ReadFile(expr, fp)
 				case OP_OS_OPEN:
 					op_os_Open(expr, fp)
 				case OP_OS_CLOSE:
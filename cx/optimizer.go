@@ -0,0 +1,399 @@
+package base
+
+// Optimize rewrites the expressions of the functions of a complete program
+// so they're run with fewer calls to ccall, without changing what the
+// program does. It:
+//
+//	folds the arithmetic and comparisons of literals into a single literal,
+//	replaces the temporary variables that hold a literal with the literal,
+//	removes the identity copies to temporaries read by the next expression,
+//	removes the declarations and the expressions whose results are unused,
+//
+// and then recomputes the length and the frame size of the functions. Like
+// Check, it doesn't need the parser.
+func (prgrm *CXProgram) Optimize() {
+	// the folded natives read and write the memory of PROGRAM
+	PROGRAM = prgrm
+
+	opt := optimizer{prgrm: prgrm, literalUses: map[int]int{}}
+	for _, pkg := range prgrm.Packages {
+		for _, fn := range pkg.Functions {
+			for _, expr := range fn.Expressions {
+				forEachArg(expr, func(slot **CXArgument, isOutput bool) {
+					if opt.isLiteral(*slot) {
+						opt.literalUses[(*slot).Offset]++
+					}
+				})
+			}
+		}
+	}
+
+	for _, pkg := range prgrm.Packages {
+		for _, fn := range pkg.Functions {
+			if fn.IsNative {
+				continue
+			}
+			opt.optimizeFunction(fn)
+		}
+	}
+}
+
+type optimizer struct {
+	prgrm *CXProgram
+	// the number of arguments that read each literal, by its offset. A
+	// folded expression writes its result over its first literal, so that
+	// literal can't be read by other expressions
+	literalUses map[int]int
+}
+
+// foldable are the natives that only compute their output from their
+// inputs, with the types of the inputs they can fold
+var foldable = map[int]struct {
+	op    func(*CXExpression, int)
+	types []int
+}{
+	OP_UND_ADD:      {op_add, numericTypes},
+	OP_UND_SUB:      {op_sub, numericTypes},
+	OP_UND_MUL:      {op_mul, numericTypes},
+	OP_UND_DIV:      {op_div, numericTypes},
+	OP_UND_MOD:      {op_mod, append([]int{TYPE_BYTE}, integerTypes...)},
+	OP_UND_BITAND:   {op_bitand, integerTypes},
+	OP_UND_BITOR:    {op_bitor, integerTypes},
+	OP_UND_BITXOR:   {op_bitxor, integerTypes},
+	OP_UND_BITCLEAR: {op_bitclear, integerTypes},
+	OP_UND_BITSHL:   {op_bitshl, integerTypes},
+	OP_UND_BITSHR:   {op_bitshr, integerTypes},
+	OP_UND_LT:       {op_lt, numericTypes},
+	OP_UND_GT:       {op_gt, numericTypes},
+	OP_UND_LTEQ:     {op_lteq, numericTypes},
+	OP_UND_GTEQ:     {op_gteq, numericTypes},
+	OP_UND_EQUAL:    {op_equal, append([]int{TYPE_BOOL}, numericTypes...)},
+	OP_UND_UNEQUAL:  {op_unequal, append([]int{TYPE_BOOL}, numericTypes...)},
+}
+
+var integerTypes = []int{TYPE_I8, TYPE_I16, TYPE_I32, TYPE_I64, TYPE_UI8, TYPE_UI16, TYPE_UI32, TYPE_UI64}
+var numericTypes = append([]int{TYPE_BYTE, TYPE_F32, TYPE_F64}, integerTypes...)
+
+func (opt *optimizer) optimizeFunction(fn *CXFunction) {
+	// the offsets of the temporaries that are no longer used
+	var removedTemps []int
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, expr := range fn.Expressions {
+			if opt.fold(expr) {
+				changed = true
+			}
+		}
+
+		removed := make([]bool, len(fn.Expressions))
+		targets := jumpTargets(fn)
+
+		for i, expr := range fn.Expressions {
+			if !isIdentity(expr) || !opt.isPlainTemp(expr.Outputs[0]) {
+				continue
+			}
+			temp := expr.Outputs[0]
+			reads, writes := argUses(fn, temp.Name)
+			if writes != 1 || !opt.canPropagate(fn, i, expr.Inputs[0], reads, targets) {
+				continue
+			}
+			for _, read := range reads {
+				copied := *expr.Inputs[0]
+				copied.FileName, copied.FileLine, copied.Span = (*read.slot).FileName, (*read.slot).FileLine, (*read.slot).Span
+				*read.slot = &copied
+			}
+			if opt.isLiteral(expr.Inputs[0]) {
+				opt.literalUses[expr.Inputs[0].Offset] += len(reads) - 1
+			}
+			removed[i] = true
+			removedTemps = append(removedTemps, temp.Offset)
+			changed = true
+		}
+
+		for i, expr := range fn.Expressions {
+			if removed[i] || expr.Label != "" {
+				continue
+			}
+			if expr.Operator == nil {
+				// a declaration, which does nothing when it's run
+				removed[i] = true
+				changed = true
+				continue
+			}
+			if !opt.isPure(expr) {
+				continue
+			}
+			unused := len(expr.Outputs) > 0
+			for _, out := range expr.Outputs {
+				if reads, writes := argUses(fn, out.Name); !opt.isPlainTemp(out) || len(reads) > 0 || writes > 1 {
+					unused = false
+				}
+			}
+			if unused {
+				for _, out := range expr.Outputs {
+					removedTemps = append(removedTemps, out.Offset)
+				}
+				removed[i] = true
+				changed = true
+			}
+		}
+
+		removeExpressions(fn, removed)
+	}
+
+	fn.Length = len(fn.Expressions)
+	shrinkFrame(fn, removedTemps)
+}
+
+// fold replaces an operation of two literals with an identity of its result
+func (opt *optimizer) fold(expr *CXExpression) bool {
+	if expr.Operator == nil || !expr.Operator.IsNative || len(expr.Inputs) != 2 || len(expr.Outputs) != 1 {
+		return false
+	}
+	native, ok := foldable[expr.Operator.OpCode]
+	if !ok {
+		return false
+	}
+	inp1, inp2 := expr.Inputs[0], expr.Inputs[1]
+	if !opt.isLiteral(inp1) || !opt.isLiteral(inp2) || !hasType(inp1, native.types) || opt.literalUses[inp1.Offset] != 1 {
+		return false
+	}
+	// the count of a shift can be of any integer type
+	if isShift := expr.Operator.OpCode == OP_UND_BITSHL || expr.Operator.OpCode == OP_UND_BITSHR; inp1.Type != inp2.Type && !(isShift && hasType(inp2, integerTypes)) {
+		return false
+	}
+	out := GetAssignmentElement(expr.Outputs[0])
+	if out.DoesEscape || out.PassBy != PASSBY_VALUE || out.IsSlice {
+		return false
+	}
+
+	typ := expr.Operator.Outputs[0].Type
+	if typ == TYPE_UNDEFINED {
+		typ = inp1.Type
+	}
+	res := *inp1
+	res.Type = typ
+	res.Size = GetArgSize(typ)
+	res.TotalSize = res.Size
+	res.Span = JoinSpans(inp1.Span, inp2.Span)
+
+	if !evaluate(native.op, &CXExpression{Operator: expr.Operator, Inputs: expr.Inputs, Outputs: []*CXArgument{&res}}) {
+		// e.g. an integer division by zero, which is reported when it's run
+		return false
+	}
+
+	expr.Operator = Natives[OP_IDENTITY]
+	expr.Inputs = []*CXArgument{&res}
+	opt.literalUses[inp2.Offset]--
+	return true
+}
+
+// evaluate runs a folded native, which writes its result over its first
+// input. It tells if the native succeeded
+func evaluate(op func(*CXExpression, int), expr *CXExpression) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+	op(expr, 0)
+	return true
+}
+
+// canPropagate tells if the reads of the temporary assigned by the i-th
+// expression of `fn` can read its input `inp` instead. A literal can be read
+// anywhere after the expression, and a variable only by the next expression
+func (opt *optimizer) canPropagate(fn *CXFunction, i int, inp *CXArgument, reads []argUse, targets map[int]bool) bool {
+	temp := fn.Expressions[i].Outputs[0]
+	if len(reads) == 0 || !opt.isPlainValue(inp) || inp.Type != temp.Type || inp.Size != temp.Size {
+		return false
+	}
+	for _, read := range reads {
+		arg := *read.slot
+		if read.expr <= i || read.isOutput || !opt.isPlainValue(arg) || arg.Type != temp.Type {
+			return false
+		}
+		if !opt.isLiteral(inp) && (read.expr != i+1 || targets[i+1] || len(reads) > 1) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLiteral tells if `arg` is a literal of a basic type, stored in the data
+// segment
+func (opt *optimizer) isLiteral(arg *CXArgument) bool {
+	return arg.Name == "" && arg.Offset >= opt.prgrm.StackSize && opt.isPlainValue(arg)
+}
+
+// isPlainTemp tells if `arg` is a temporary of a basic type, stored in the
+// frame of its function
+func (opt *optimizer) isPlainTemp(arg *CXArgument) bool {
+	return IsTempVar(arg.Name) && arg.Offset < opt.prgrm.StackSize && opt.isPlainValue(arg)
+}
+
+// isPlainValue tells if `arg` is read or written directly, and holds a
+// boolean or a number
+func (opt *optimizer) isPlainValue(arg *CXArgument) bool {
+	return len(arg.Fields) == 0 && len(arg.Indexes) == 0 && len(arg.DereferenceOperations) == 0 && len(arg.Lengths) == 0 &&
+		!arg.IsPointer && !arg.IsSlice && !arg.IsMap && !arg.IsChan && !arg.IsCaptured && !arg.IsReference && !arg.DoesEscape &&
+		arg.PassBy == PASSBY_VALUE && arg.CustomType == nil && hasType(arg, append([]int{TYPE_BOOL}, numericTypes...))
+}
+
+func hasType(arg *CXArgument, types []int) bool {
+	for _, typ := range types {
+		if arg.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdentity(expr *CXExpression) bool {
+	return expr.Operator == Natives[OP_IDENTITY] && len(expr.Inputs) == 1 && len(expr.Outputs) == 1 && !expr.IsConversion
+}
+
+// isPure tells if running `expr` only writes its outputs. Divisions are
+// not, as they stop the program if they divide by zero, and neither are
+// the expressions that index or dereference their inputs
+func (opt *optimizer) isPure(expr *CXExpression) bool {
+	for _, inp := range expr.Inputs {
+		if !opt.isPlainValue(inp) {
+			return false
+		}
+	}
+	if isIdentity(expr) {
+		return true
+	}
+	if expr.Operator == nil || !expr.Operator.IsNative || expr.Operator.OpCode == OP_UND_DIV || expr.Operator.OpCode == OP_UND_MOD {
+		return false
+	}
+	_, ok := foldable[expr.Operator.OpCode]
+	return ok
+}
+
+// argUse is an argument of an expression that names a variable
+type argUse struct {
+	expr     int // the index of the expression
+	slot     **CXArgument
+	isOutput bool
+}
+
+// argUses returns the arguments of the expressions of `fn` that read the
+// variable `name`, and the number of them that write it
+func argUses(fn *CXFunction, name string) (reads []argUse, writes int) {
+	for i, expr := range fn.Expressions {
+		forEachArg(expr, func(slot **CXArgument, isOutput bool) {
+			if (*slot).Name != name {
+				return
+			}
+			if isOutput && len((*slot).Fields) == 0 && len((*slot).Indexes) == 0 && len((*slot).DereferenceOperations) == 0 {
+				writes++
+				return
+			}
+			reads = append(reads, argUse{expr: i, slot: slot, isOutput: isOutput})
+		})
+	}
+	return reads, writes
+}
+
+// forEachArg calls `f` with every argument of `expr`, including the
+// indexes of its arguments
+func forEachArg(expr *CXExpression, f func(slot **CXArgument, isOutput bool)) {
+	var walk func(args []*CXArgument, isOutput bool)
+	walk = func(args []*CXArgument, isOutput bool) {
+		for i := range args {
+			f(&args[i], isOutput)
+			walk(args[i].Indexes, false)
+			for _, fld := range args[i].Fields {
+				walk(fld.Indexes, false)
+			}
+		}
+	}
+	walk(expr.Inputs, false)
+	walk(expr.Outputs, true)
+}
+
+// jumpTargets returns the indexes of the expressions of `fn` that a jump
+// goes to
+func jumpTargets(fn *CXFunction) map[int]bool {
+	targets := map[int]bool{}
+	for i, expr := range fn.Expressions {
+		if expr.Operator == Natives[OP_JMP] {
+			targets[i+1+expr.ThenLines] = true
+			targets[i+1+expr.ElseLines] = true
+		}
+	}
+	return targets
+}
+
+// removeExpressions removes the expressions of `fn` marked in `removed`,
+// and moves the targets of the jumps to the expressions that are left. A
+// jump to a removed expression goes to the next one that's left
+func removeExpressions(fn *CXFunction, removed []bool) {
+	// newIndex[i] is the number of expressions before the i-th that are left
+	newIndex := make([]int, len(fn.Expressions)+1)
+	for i := range fn.Expressions {
+		newIndex[i+1] = newIndex[i]
+		if !removed[i] {
+			newIndex[i+1]++
+		}
+	}
+
+	remap := func(i, lines int) int {
+		target := i + 1 + lines
+		if target < 0 || target > len(fn.Expressions) {
+			// e.g. a return, which jumps past the end of the function
+			return lines
+		}
+		return newIndex[target] - newIndex[i] - 1
+	}
+
+	var exprs []*CXExpression
+	for i, expr := range fn.Expressions {
+		if removed[i] {
+			continue
+		}
+		if expr.Operator == Natives[OP_JMP] {
+			expr.ThenLines = remap(i, expr.ThenLines)
+			expr.ElseLines = remap(i, expr.ElseLines)
+		}
+		exprs = append(exprs, expr)
+	}
+	fn.Expressions = exprs
+}
+
+// shrinkFrame removes the end of the frame of `fn` if only removed
+// temporaries were stored there. The variables of a function are stored in
+// the order they're declared, so the frame can end where the first of those
+// temporaries starts
+func shrinkFrame(fn *CXFunction, removedTemps []int) {
+	lastUsed := -1
+	use := func(arg *CXArgument) {
+		if arg.Offset < fn.Size && arg.Offset > lastUsed {
+			lastUsed = arg.Offset
+		}
+	}
+	for _, arg := range fn.Inputs {
+		use(arg)
+	}
+	for _, arg := range fn.Outputs {
+		use(arg)
+	}
+	for _, arg := range fn.ListOfPointers {
+		use(arg)
+	}
+	for _, expr := range fn.Expressions {
+		forEachArg(expr, func(slot **CXArgument, isOutput bool) {
+			use(*slot)
+		})
+	}
+
+	for _, offset := range removedTemps {
+		if offset > lastUsed && offset < fn.Size {
+			fn.Size = offset
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// optimizerTest builds the functions of a small program the way the parser
+// does: every operation writes a temporary, and the literals are stored in
+// the data segment
+type optimizerTest struct {
+	prgrm      *CXProgram
+	pkg        *CXPackage
+	dataOffset int
+}
+
+func newOptimizerTest() *optimizerTest {
+	prgrm := MakeProgramWithSizes(CALLSTACK_SIZE, 1024, 1024, 1024)
+	pkg := MakePackage(MAIN_PKG)
+	prgrm.AddPackage(pkg)
+	return &optimizerTest{prgrm: prgrm, pkg: pkg, dataOffset: prgrm.StackSize + TYPE_POINTER_SIZE}
+}
+
+func (t *optimizerTest) function(name string) *CXFunction {
+	fn := MakeFunction(name)
+	t.pkg.AddFunction(fn)
+	return fn
+}
+
+func (t *optimizerTest) literal(val int32) *CXArgument {
+	arg := MakeArgument("", "test.cx", 1).AddType(TypeNames[TYPE_I32])
+	arg.Package = t.pkg
+	arg.Offset = t.dataOffset
+	copy(t.prgrm.Memory[arg.Offset:], encoder.SerializeAtomic(val))
+	t.dataOffset += arg.Size
+	return arg
+}
+
+func (t *optimizerTest) local(fn *CXFunction, name string) *CXArgument {
+	arg := MakeArgument(name, "test.cx", 1).AddType(TypeNames[TYPE_I32])
+	arg.Package = t.pkg
+	arg.Offset = fn.Size
+	fn.Size += arg.Size
+	return arg
+}
+
+func (t *optimizerTest) add(fn *CXFunction, opCode int, out *CXArgument, inps ...*CXArgument) {
+	var expr *CXExpression
+	if opCode < 0 {
+		// a declaration
+		expr = MakeExpression(nil, "test.cx", 1)
+	} else {
+		expr = MakeExpression(Natives[opCode], "test.cx", 1)
+	}
+	expr.Inputs = inps
+	expr.Outputs = []*CXArgument{out}
+	fn.AddExpression(expr)
+}
+
+func TestOptimize(t *testing.T) {
+	opt := newOptimizerTest()
+
+	// x := 2 * 3 + 4
+	folded := opt.function("folded")
+	x := opt.local(folded, "x")
+	lcl0 := opt.local(folded, LOCAL_PREFIX+"_0")
+	lcl1 := opt.local(folded, LOCAL_PREFIX+"_1")
+	opt.add(folded, -1, x)
+	opt.add(folded, OP_UND_MUL, lcl0, opt.literal(2), opt.literal(3))
+	opt.add(folded, OP_UND_ADD, lcl1, lcl0, opt.literal(4))
+	opt.add(folded, OP_IDENTITY, x, lcl1)
+	folded.AddOutput(x)
+
+	// y := n * (8 - 2 * 4)
+	mixed := opt.function("mixed")
+	n := opt.local(mixed, "n")
+	y := opt.local(mixed, "y")
+	lcl2 := opt.local(mixed, LOCAL_PREFIX+"_2")
+	lcl3 := opt.local(mixed, LOCAL_PREFIX+"_3")
+	lcl4 := opt.local(mixed, LOCAL_PREFIX+"_4")
+	mixed.AddInput(n)
+	opt.add(mixed, -1, y)
+	opt.add(mixed, OP_UND_MUL, lcl2, opt.literal(2), opt.literal(4))
+	opt.add(mixed, OP_UND_SUB, lcl3, opt.literal(8), lcl2)
+	opt.add(mixed, OP_UND_MUL, lcl4, n, lcl3)
+	opt.add(mixed, OP_IDENTITY, y, lcl4)
+	mixed.AddOutput(y)
+
+	tests := []struct {
+		fn     *CXFunction
+		before int
+		after  int
+	}{
+		{folded, 4, 1},
+		{mixed, 5, 2},
+	}
+	for _, tc := range tests {
+		if got := len(tc.fn.Expressions); got != tc.before {
+			t.Fatalf("%s has %d expressions before Optimize, expected %d", tc.fn.Name, got, tc.before)
+		}
+	}
+
+	opt.prgrm.Optimize()
+
+	for _, tc := range tests {
+		if got := len(tc.fn.Expressions); got != tc.after {
+			t.Errorf("%s has %d expressions after Optimize, expected %d", tc.fn.Name, got, tc.after)
+		}
+		if tc.fn.Length != len(tc.fn.Expressions) {
+			t.Errorf("%s has length %d, expected %d", tc.fn.Name, tc.fn.Length, len(tc.fn.Expressions))
+		}
+	}
+
+	// x = 10
+	expr := folded.Expressions[0]
+	if expr.Operator != Natives[OP_IDENTITY] || expr.Outputs[0] != x || ReadI32(0, expr.Inputs[0]) != 10 {
+		t.Errorf("folded computes %s, expected x = 10", OpNames[expr.Operator.OpCode])
+	}
+	if folded.Size != x.Size {
+		t.Errorf("folded has a frame of %d bytes, expected %d", folded.Size, x.Size)
+	}
+
+	// the multiplication by the variable is kept, of the folded literal
+	expr = mixed.Expressions[0]
+	if expr.Operator != Natives[OP_UND_MUL] || expr.Inputs[0] != n || ReadI32(0, expr.Inputs[1]) != 0 {
+		t.Errorf("mixed doesn't multiply n by the folded literal 0")
+	}
+}
@@ -39,6 +39,10 @@ type sProgram struct {
         HeapPointer                     int32
         StackPointer                    int32
         HeapStartsAt                    int32
+        StackSize                       int32
+        MaxHeapSize                     int32
+        NilMapValue                     int32
+        ThreadStackSize                 int32
         
         Terminated                      int32
 }
@@ -71,6 +75,10 @@ type sStruct struct {
         FieldsSize                      int32
 
         Size                            int32
+        IsInterface                     int32
+        UnderlyingOffset                int32
+        UnderlyingSize                  int32
+        IsAlias                         int32
 
         PackageOffset                   int32
 }
@@ -121,6 +129,10 @@ type sExpression struct {
 	IsUndType                       int32
 	IsBreak                         int32
 	IsContinue                      int32
+	IsGoCall                        int32
+	IsDeferCall                     int32
+	IsSpreadCall                    int32
+	IsConversion                    int32
 
         FunctionOffset                  int32
         PackageOffset                   int32
@@ -130,7 +142,9 @@ type sArgument struct {
         NameOffset                      int32
         NameSize                        int32
         Type                            int32
+        MapKeyType                      int32
         CustomTypeOffset                int32
+        NamedTypeOffset                 int32
         Size                            int32
         TotalSize                       int32
 
@@ -144,6 +158,8 @@ type sArgument struct {
         DeclarationSpecifiersSize       int32
 
         IsSlice                         int32
+        IsMap                           int32
+        IsChan                          int32
         IsArray                         int32
         IsArrayFirst                    int32
         IsPointer                       int32
@@ -158,6 +174,9 @@ type sArgument struct {
 
         PassBy                          int32
         DoesEscape                      int32
+        IsCaptured                      int32
+        IsVariadic                      int32
+        IsEmbedded                      int32
 
         LengthsOffset                   int32
         LengthsSize                     int32
@@ -165,6 +184,10 @@ type sArgument struct {
         IndexesSize                     int32
         FieldsOffset                    int32
         FieldsSize                      int32
+        InputsOffset                    int32
+        InputsSize                      int32
+        OutputsOffset                   int32
+        OutputsSize                     int32
 
         PackageOffset                   int32
 }
@@ -281,6 +304,7 @@ func serializeArgument (arg *CXArgument, s *sAll) int {
 	sArg.NameOffset, sArg.NameSize = serializeName(arg.Name, s)
 	
 	sArg.Type = int32(arg.Type)
+	sArg.MapKeyType = int32(arg.MapKeyType)
 	
 	if arg.CustomType == nil {
 		sArg.CustomTypeOffset = sNil
@@ -293,6 +317,17 @@ func serializeArgument (arg *CXArgument, s *sAll) int {
 		}
 	}
 
+	if arg.NamedType == nil {
+		sArg.NamedTypeOffset = sNil
+	} else {
+		strctName := arg.NamedType.Package.Name + "." + arg.NamedType.Name
+		if strctOff, found := s.StructsMap[strctName]; found {
+			sArg.NamedTypeOffset = int32(strctOff)
+		} else {
+			panic("struct reference not found")
+		}
+	}
+
 	sArg.Size = int32(arg.Size)
 	sArg.TotalSize = int32(arg.TotalSize)
 	sArg.Offset = int32(arg.Offset)
@@ -307,6 +342,8 @@ func serializeArgument (arg *CXArgument, s *sAll) int {
 	sArg.DeclarationSpecifiersSize = serializeIntegers(arg.DeclarationSpecifiers, s)
 
 	sArg.IsSlice = serializeBoolean(arg.IsSlice)
+	sArg.IsMap = serializeBoolean(arg.IsMap)
+	sArg.IsChan = serializeBoolean(arg.IsChan)
 	sArg.IsArray = serializeBoolean(arg.IsArray)
 	sArg.IsArrayFirst = serializeBoolean(arg.IsArrayFirst)
 	sArg.IsPointer = serializeBoolean(arg.IsPointer)
@@ -321,12 +358,17 @@ func serializeArgument (arg *CXArgument, s *sAll) int {
 
 	sArg.PassBy = int32(arg.PassBy)
 	sArg.DoesEscape = serializeBoolean(arg.DoesEscape)
+	sArg.IsCaptured = serializeBoolean(arg.IsCaptured)
+	sArg.IsVariadic = serializeBoolean(arg.IsVariadic)
+	sArg.IsEmbedded = serializeBoolean(arg.IsEmbedded)
 
 	sArg.LengthsOffset,
 	sArg.LengthsSize = serializeIntegers(arg.Lengths, s)
 
 	sArg.IndexesOffset, sArg.IndexesSize = serializeSliceOfArguments(arg.Indexes, s)
 	sArg.FieldsOffset, sArg.FieldsSize = serializeSliceOfArguments(arg.Fields, s)
+	sArg.InputsOffset, sArg.InputsSize = serializeSliceOfArguments(arg.Inputs, s)
+	sArg.OutputsOffset, sArg.OutputsSize = serializeSliceOfArguments(arg.Outputs, s)
 
 	if pkgOff, found := s.PackagesMap[arg.Package.Name]; found {
 		sArg.PackageOffset = int32(pkgOff)
@@ -400,6 +442,10 @@ func serializeExpression (expr *CXExpression, s *sAll) int {
 	sExpr.IsUndType = serializeBoolean(expr.IsUndType)
 	sExpr.IsBreak = serializeBoolean(expr.IsBreak)
 	sExpr.IsContinue = serializeBoolean(expr.IsContinue)
+	sExpr.IsGoCall = serializeBoolean(expr.IsGoCall)
+	sExpr.IsDeferCall = serializeBoolean(expr.IsDeferCall)
+	sExpr.IsSpreadCall = serializeBoolean(expr.IsSpreadCall)
+	sExpr.IsConversion = serializeBoolean(expr.IsConversion)
 
 	fnName := expr.Function.Package.Name + "." + expr.Function.Name
 	if fnOff, found := s.FunctionsMap[fnName]; found {
@@ -462,6 +508,10 @@ func serializeProgram (prgrm *CXProgram, s *sAll) {
 	sPrgrm.HeapPointer = int32(prgrm.HeapPointer)
 	sPrgrm.StackPointer = int32(prgrm.StackPointer)
 	sPrgrm.HeapStartsAt = int32(prgrm.HeapStartsAt)
+	sPrgrm.StackSize = int32(prgrm.StackSize)
+	sPrgrm.MaxHeapSize = int32(prgrm.MaxHeapSize)
+	sPrgrm.NilMapValue = int32(prgrm.NilMapValue)
+	sPrgrm.ThreadStackSize = int32(prgrm.ThreadStackSize)
 
 	sPrgrm.Terminated = serializeBoolean(prgrm.Terminated)
 }
@@ -471,6 +521,9 @@ func sStructArguments (strct *CXStruct, s *sAll) {
 	if strctOff, found := s.StructsMap[strctName]; found {
 		sStrct := &s.Structs[strctOff]
 		sStrct.FieldsOffset, sStrct.FieldsSize = serializeSliceOfArguments(strct.Fields, s)
+		if strct.Underlying != nil {
+			sStrct.UnderlyingOffset, sStrct.UnderlyingSize = serializeSliceOfArguments([]*CXArgument{strct.Underlying}, s)
+		}
 	} else {
 		panic("struct reference not found")
 	}
@@ -606,6 +659,8 @@ func sStructIntegers (strct *CXStruct, s *sAll) {
 	if off, found := s.StructsMap[strctName]; found {
 		sStrct := &s.Structs[off]
 		sStrct.Size = int32(strct.Size)
+		sStrct.IsInterface = serializeBoolean(strct.IsInterface)
+		sStrct.IsAlias = serializeBoolean(strct.IsAlias)
 	} else {
 		panic("struct reference not found")
 	}
@@ -832,11 +887,11 @@ func op_deserialize (expr *CXExpression, fp int) {
 	var off int32
 	encoder.DeserializeAtomic(PROGRAM.Memory[inpOffset : inpOffset + TYPE_POINTER_SIZE], &off)
 
-	var l int32
-	_l := PROGRAM.Memory[off + OBJECT_HEADER_SIZE : off + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE]
-	encoder.DeserializeAtomic(_l[:4], &l)
+	l, _ := readSliceHeader(int(off))
+	elts := sliceElements(int(off))
 
-	Deserialize(PROGRAM.Memory[off + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE : off + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + l])
+	prgrm := Deserialize(PROGRAM.Memory[elts : elts + l])
+	mustCheck(prgrm)
 }
 
 func dsName (off int32, size int32, s *sAll) string {
@@ -942,6 +997,11 @@ func dsStruct (sStrct *sStruct, strct *CXStruct, s *sAll, prgrm *CXProgram) {
 	strct.Name = dsName(sStrct.NameOffset, sStrct.NameSize, s)
 	strct.Fields = dsArguments(sStrct.FieldsOffset, sStrct.FieldsSize, s, prgrm)
 	strct.Size = int(sStrct.Size)
+	strct.IsInterface = dsBool(sStrct.IsInterface)
+	strct.IsAlias = dsBool(sStrct.IsAlias)
+	if sStrct.UnderlyingSize > 0 {
+		strct.Underlying = dsArguments(sStrct.UnderlyingOffset, sStrct.UnderlyingSize, s, prgrm)[0]
+	}
 	strct.Package = prgrm.Packages[sStrct.PackageOffset]
 }
 
@@ -962,12 +1022,17 @@ func dsArguments (off int32, size int32, s *sAll, prgrm *CXProgram) []*CXArgumen
 }
 
 func getCustomType (sArg *sArgument, s *sAll, prgrm *CXProgram) *CXStruct {
-	if sArg.CustomTypeOffset < 0 {
+	return getStruct(sArg.CustomTypeOffset, s, prgrm)
+}
+
+// returns the struct serialized at `strctOff`, or nil if it's negative
+func getStruct (strctOff int32, s *sAll, prgrm *CXProgram) *CXStruct {
+	if strctOff < 0 {
 		return nil
 	}
 
-	customTypePkg := prgrm.Packages[s.Structs[sArg.CustomTypeOffset].PackageOffset]
-	sStrct := s.Structs[sArg.CustomTypeOffset]
+	customTypePkg := prgrm.Packages[s.Structs[strctOff].PackageOffset]
+	sStrct := s.Structs[strctOff]
 	customTypeName := dsName(sStrct.NameOffset, sStrct.NameSize, s)
 
 	for _, strct := range customTypePkg.Structs {
@@ -983,8 +1048,10 @@ func dsArgument (sArg *sArgument, s *sAll, prgrm *CXProgram) *CXArgument {
 	var arg CXArgument
 	arg.Name = dsName(sArg.NameOffset, sArg.NameSize, s)
 	arg.Type = int(sArg.Type)
+	arg.MapKeyType = int(sArg.MapKeyType)
 	
 	arg.CustomType = getCustomType(sArg, s, prgrm)
+	arg.NamedType = getStruct(sArg.NamedTypeOffset, s, prgrm)
 	
 	arg.Size = int(sArg.Size)
 	arg.TotalSize = int(sArg.TotalSize)
@@ -997,6 +1064,8 @@ func dsArgument (sArg *sArgument, s *sAll, prgrm *CXProgram) *CXArgument {
 	arg.DeclarationSpecifiers = dsIntegers(sArg.DeclarationSpecifiersOffset, sArg.DeclarationSpecifiersSize, s)
 
 	arg.IsSlice = dsBool(sArg.IsSlice)
+	arg.IsMap = dsBool(sArg.IsMap)
+	arg.IsChan = dsBool(sArg.IsChan)
 	arg.IsArray = dsBool(sArg.IsArray)
 	arg.IsArrayFirst = dsBool(sArg.IsArrayFirst)
 	arg.IsPointer = dsBool(sArg.IsPointer)
@@ -1008,10 +1077,15 @@ func dsArgument (sArg *sArgument, s *sAll, prgrm *CXProgram) *CXArgument {
 	arg.IsShortDeclaration = dsBool(sArg.IsShortDeclaration)
 	arg.PreviouslyDeclared = dsBool(sArg.PreviouslyDeclared)
 	arg.DoesEscape = dsBool(sArg.DoesEscape)
+	arg.IsCaptured = dsBool(sArg.IsCaptured)
+	arg.IsVariadic = dsBool(sArg.IsVariadic)
+	arg.IsEmbedded = dsBool(sArg.IsEmbedded)
 
 	arg.Lengths = dsIntegers(sArg.LengthsOffset, sArg.LengthsSize, s)
 	arg.Indexes = dsArguments(sArg.IndexesOffset, sArg.IndexesSize, s, prgrm)
 	arg.Fields = dsArguments(sArg.FieldsOffset, sArg.FieldsSize, s, prgrm)
+	arg.Inputs = dsArguments(sArg.InputsOffset, sArg.InputsSize, s, prgrm)
+	arg.Outputs = dsArguments(sArg.OutputsOffset, sArg.OutputsSize, s, prgrm)
 
 	arg.Package = prgrm.Packages[sArg.PackageOffset]
 	
@@ -1105,6 +1179,10 @@ func dsExpression (sExpr *sExpression, s *sAll, prgrm *CXProgram) *CXExpression
 	expr.IsUndType = dsBool(sExpr.IsUndType)
 	expr.IsBreak = dsBool(sExpr.IsBreak)
 	expr.IsContinue = dsBool(sExpr.IsContinue)
+	expr.IsGoCall = dsBool(sExpr.IsGoCall)
+	expr.IsDeferCall = dsBool(sExpr.IsDeferCall)
+	expr.IsSpreadCall = dsBool(sExpr.IsSpreadCall)
+	expr.IsConversion = dsBool(sExpr.IsConversion)
 
 	expr.Function = getFunction(sExpr, s, prgrm)
 	expr.Package = prgrm.Packages[sExpr.PackageOffset]
@@ -1151,6 +1229,10 @@ func dsIntegers (off int32, size int32, s *sAll) []int {
 
 func initDeserialization (prgrm *CXProgram, s *sAll) {
 	prgrm.Memory = s.Memory
+	prgrm.StackSize = int(s.Program.StackSize)
+	prgrm.MaxHeapSize = int(s.Program.MaxHeapSize)
+	prgrm.NilMapValue = int(s.Program.NilMapValue)
+	prgrm.ThreadStackSize = int(s.Program.ThreadStackSize)
 	prgrm.Packages = make([]*CXPackage, len(s.Packages))
 
 	dsPackages(s, prgrm)
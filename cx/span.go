@@ -0,0 +1,104 @@
+package base
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// Span is the code of a node of a program, from its first character to the
+// character after its last one. Lines and columns start at 1, and offsets
+// and columns count bytes
+type Span struct {
+	Offset    int // from the start of the file
+	Line      int
+	Column    int
+	EndOffset int
+	EndLine   int
+	EndColumn int
+}
+
+// IsValid tells if the span is known
+func (span Span) IsValid() bool {
+	return span.Line > 0
+}
+
+// JoinSpans returns the span from the start of `first` to the end of `last`.
+// If one of them isn't known, the other one is returned
+func JoinSpans(first, last Span) Span {
+	if !first.IsValid() {
+		return last
+	}
+	if !last.IsValid() {
+		return first
+	}
+	return Span{
+		Offset:    first.Offset,
+		Line:      first.Line,
+		Column:    first.Column,
+		EndOffset: last.EndOffset,
+		EndLine:   last.EndLine,
+		EndColumn: last.EndColumn,
+	}
+}
+
+// the lines of the source files, so errors can show their code
+var sourceFiles = map[string][]string{}
+
+// AddSourceFile keeps the code of a file, so errors found in it can show
+// the code where they happened
+func AddSourceFile(fileName string, code string) {
+	sourceFiles[fileName] = strings.Split(code, "\n")
+}
+
+func sourceLine(fileName string, lineNo int) (string, bool) {
+	lines, ok := sourceFiles[fileName]
+	if !ok && fileName != "" {
+		// e.g. a program that was compiled before
+		if code, err := ioutil.ReadFile(fileName); err == nil {
+			AddSourceFile(fileName, string(code))
+			lines, ok = sourceFiles[fileName]
+		}
+	}
+	if !ok || lineNo < 1 || lineNo > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[lineNo-1], "\r"), true
+}
+
+// SourceExcerpt returns the line where a span starts followed by a line
+// that underlines the code of the span, e.g.
+//
+//	x = arr[i]
+//	    ^~~~~~
+//
+// It returns "" if the code isn't available
+func SourceExcerpt(fileName string, span Span) string {
+	if !span.IsValid() || span.Column < 1 {
+		return ""
+	}
+	line, ok := sourceLine(fileName, span.Line)
+	if !ok || span.Column > len(line)+1 {
+		return ""
+	}
+
+	end := span.EndColumn
+	if span.EndLine != span.Line || end > len(line)+1 {
+		end = len(line) + 1
+	}
+
+	var underline strings.Builder
+	for _, c := range []byte(line[:span.Column-1]) {
+		// so the underline is aligned with the code
+		if c == '\t' {
+			underline.WriteByte('\t')
+		} else {
+			underline.WriteByte(' ')
+		}
+	}
+	underline.WriteByte('^')
+	for i := span.Column + 1; i < end; i++ {
+		underline.WriteByte('~')
+	}
+
+	return line + "\n" + underline.String()
+}
@@ -8,6 +8,10 @@ type CXCall struct {
         Operator                        *CXFunction
         Line                            int
         FramePointer                    int
+        // calls deferred by the function, see defer.go
+        Deferred                        []DeferredCall
+        // the panic the function is unwinding, if any
+        Panic                           *CXPanic
 }
 
 /*
@@ -15,10 +19,18 @@ type CXCall struct {
 */
 
 type CXConstant struct {
-        // native constants. only used for pre-packaged constants (e.g. math package's PI)
+        // used for pre-packaged constants (e.g. math package's PI) and for
+        // constants declared with `const` or `enum` in a package
         // these fields are used to feed WritePrimary
+	Name                            string
 	Value                           []byte
         Type                            int
+        // untyped constants are folded from literals without a suffix, and
+        // they adopt the type of the typed constants they're used with
+        IsUntyped                       bool
+        // the reason why a constant expression can't be folded. It's only
+        // reported if the expression is used to declare a constant
+        Error                           error
 }
 
 type CXArgument struct {
@@ -27,10 +39,13 @@ type CXArgument struct {
         DeclarationSpecifiers           []int // used to determine finalSize
         Indexes                         []*CXArgument
         Fields                          []*CXArgument // strct.fld1.fld2().fld3
+        Inputs                          []*CXArgument // signature if Type is TYPE_FUNC
+        Outputs                         []*CXArgument
 	Name                            string
 	FileName                        string
         ElementID                       UUID
         Type                            int
+        MapKeyType                      int // type of the keys if IsMap
         Size                            int // size of underlaying basic type
         TotalSize                       int // total size of an array, performance reasons
         Offset                          int
@@ -38,9 +53,12 @@ type CXArgument struct {
         DereferenceLevels               int
 	PassBy                          int  // pass by value or reference
 	FileLine                        int
+	Span                            Span
 	CustomType                      *CXStruct
 	Package                         *CXPackage
         IsSlice                         bool
+        IsMap                           bool
+        IsChan                          bool // Type is the type of its elements
         IsArray                         bool
         IsArrayFirst                    bool // and then dereference
         IsPointer                       bool
@@ -52,4 +70,10 @@ type CXArgument struct {
 	IsShortDeclaration              bool
         PreviouslyDeclared              bool
         DoesEscape                      bool
+        IsCaptured                      bool // captured by a closure; Offset holds the address of its heap object
+        IsVariadic                      bool // last parameter, receiving the rest of the arguments as a slice
+        IsEmbedded                      bool // struct field named after its type, whose fields and methods are promoted
+        IsUntyped                       bool // inlined untyped constant, which adopts the numeric type it's used with
+        NamedType                       *CXStruct // declared with a named type, e.g. `type Meters f64`
+        RangeRune                       *CXArgument // variable holding the rune read by a range loop if a string is ranged over
 }
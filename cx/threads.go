@@ -0,0 +1,262 @@
+package base
+
+import (
+	"fmt"
+	"time"
+)
+
+// CX threads are started by `go` statements and are scheduled cooperatively
+// by `Run`: the running thread yields after THREAD_QUANTUM expressions, or
+// when it blocks (e.g. in `time.Sleep` or in a channel operation).
+//
+// A native blocks the running thread by calling `BlockThread`. The thread
+// then runs the same expression again once another thread completes a
+// channel operation, and if every thread is blocked the program deadlocked.
+//
+// The state of the running thread lives in the `CallStack`, `CallCounter`
+// and `StackPointer` fields of the program, so the rest of the runtime does
+// not need to know about threads. It is saved in its CXThread when another
+// thread is scheduled.
+//
+// The main thread (index 0) uses the bottom of the stack, and every other
+// thread gets a region of `ThreadStackSize` bytes taken from the top.
+
+type CXThread struct {
+	CallStack    []CXCall
+	CallCounter  int
+	StackPointer int
+	StackStart   int
+	StackLimit   int
+	WakeAt       time.Time
+	Blocked      bool
+	WaitReason   string
+
+	// channels it's waiting to receive from, see op_chan.go
+	receiving []int
+	// an unbuffered send waits for its value to be received
+	sendChan int
+	sendSeq  int
+}
+
+// returns the region of the stack the running thread can use
+func (prgrm *CXProgram) stackBounds() (int, int) {
+	if len(prgrm.Threads) == 0 {
+		return 0, prgrm.StackSize
+	}
+	thread := prgrm.Threads[prgrm.CurrentThread]
+	return thread.StackStart, thread.StackLimit
+}
+
+func (prgrm *CXProgram) saveThread() {
+	thread := prgrm.Threads[prgrm.CurrentThread]
+	thread.CallStack = prgrm.CallStack
+	thread.CallCounter = prgrm.CallCounter
+	thread.StackPointer = prgrm.StackPointer
+}
+
+func (prgrm *CXProgram) loadThread(i int) {
+	thread := prgrm.Threads[i]
+	prgrm.CurrentThread = i
+	prgrm.CallStack = thread.CallStack
+	prgrm.CallCounter = thread.CallCounter
+	prgrm.StackPointer = thread.StackPointer
+	prgrm.threadSteps = 0
+}
+
+// the main thread can use the stack up to the lowest region of the others
+func (prgrm *CXProgram) updateMainStackLimit() {
+	limit := prgrm.StackSize
+	for _, thread := range prgrm.Threads[1:] {
+		if thread.StackStart < limit {
+			limit = thread.StackStart
+		}
+	}
+	prgrm.Threads[0].StackLimit = limit
+}
+
+// returns the next thread after `from` that is neither sleeping nor
+// blocked. If all of them are sleeping or blocked, it waits for the first
+// sleeping one to wake up, and if none is sleeping, it returns -1
+func (prgrm *CXProgram) nextThread(from int) int {
+	now := time.Now()
+	next := -1
+	var wakeAt time.Time
+
+	for c := 1; c <= len(prgrm.Threads); c++ {
+		i := (from + c) % len(prgrm.Threads)
+		thread := prgrm.Threads[i]
+		if thread.Blocked {
+			continue
+		}
+		if !thread.WakeAt.After(now) {
+			return i
+		}
+		if next < 0 || thread.WakeAt.Before(wakeAt) {
+			next = i
+			wakeAt = thread.WakeAt
+		}
+	}
+
+	if next >= 0 {
+		time.Sleep(wakeAt.Sub(now))
+	}
+	return next
+}
+
+// the main thread is added to `Threads` the first time it's needed
+func (prgrm *CXProgram) initThreads() {
+	if len(prgrm.Threads) == 0 {
+		prgrm.Threads = []*CXThread{&CXThread{StackLimit: prgrm.StackSize}}
+		prgrm.CurrentThread = 0
+	}
+}
+
+func (prgrm *CXProgram) runningThread() *CXThread {
+	prgrm.initThreads()
+	return prgrm.Threads[prgrm.CurrentThread]
+}
+
+// SpawnThread starts a thread that calls the operator of `expr`, or the
+// function value it calls, taking its inputs from the stack frame at `fp`
+func (prgrm *CXProgram) SpawnThread(expr *CXExpression, fp int) {
+	prgrm.initThreads()
+	prgrm.saveThread()
+
+	fn, closure := calledFunction(expr, fp)
+	if fn.Size > prgrm.ThreadStackSize {
+		panic(MemoryError{
+			Kind:      STACK_OVERFLOW_ERROR,
+			Requested: fn.Size,
+			Used:      0,
+			Limit:     prgrm.ThreadStackSize,
+		})
+	}
+
+	// taking the highest free region above the main thread's frames
+	mainStackPointer := prgrm.Threads[0].StackPointer
+	start := -1
+	for slot := prgrm.StackSize - prgrm.ThreadStackSize; slot >= mainStackPointer; slot -= prgrm.ThreadStackSize {
+		free := true
+		for _, thread := range prgrm.Threads[1:] {
+			if thread.StackStart == slot {
+				free = false
+				break
+			}
+		}
+		if free {
+			start = slot
+			break
+		}
+	}
+	if start < 0 {
+		panic(MemoryError{
+			Kind:      THREAD_STACK_ERROR,
+			Requested: prgrm.ThreadStackSize,
+			Used:      mainStackPointer + (len(prgrm.Threads)-1)*prgrm.ThreadStackSize,
+			Limit:     prgrm.StackSize,
+		})
+	}
+
+	thread := &CXThread{
+		CallStack:    make([]CXCall, len(prgrm.CallStack)),
+		StackPointer: start + fn.Size,
+		StackStart:   start,
+		StackLimit:   start + prgrm.ThreadStackSize,
+	}
+	thread.CallStack[0] = CXCall{Operator: fn, Line: 0, FramePointer: start}
+
+	// wiping the new stack frame (removing garbage)
+	for c := start; c < start+fn.Size; c++ {
+		prgrm.Memory[c] = 0
+	}
+	writeInputs(prgrm, expr, fn, closure, fp, start)
+
+	prgrm.Threads = append(prgrm.Threads, thread)
+	prgrm.updateMainStackLimit()
+}
+
+// finishes the running thread, which is not the main thread, and switches
+// to the next one
+func (prgrm *CXProgram) finishThread() {
+	i := prgrm.CurrentThread
+	prgrm.Threads = append(prgrm.Threads[:i], prgrm.Threads[i+1:]...)
+	prgrm.updateMainStackLimit()
+
+	next := prgrm.nextThread(i - 1)
+	if next < 0 {
+		// the remaining threads are blocked
+		prgrm.loadThread(0)
+		panic(DEADLOCK_ERROR)
+	}
+	prgrm.loadThread(next)
+}
+
+// removes every thread but the running one, which is the main thread once
+// the program terminates
+func (prgrm *CXProgram) resetThreads() {
+	prgrm.Threads = nil
+	prgrm.CurrentThread = 0
+	prgrm.threadSteps = 0
+}
+
+// Yield switches to the next thread that is neither sleeping nor blocked
+func (prgrm *CXProgram) Yield() {
+	if len(prgrm.Threads) < 2 && !prgrm.runningThread().Blocked {
+		return
+	}
+
+	next := prgrm.nextThread(prgrm.CurrentThread)
+	if next < 0 {
+		panic(DEADLOCK_ERROR)
+	}
+	prgrm.saveThread()
+	prgrm.loadThread(next)
+}
+
+// BlockThread blocks the running thread once the current expression
+// finishes. The expression is run again when the thread is woken up
+func (prgrm *CXProgram) BlockThread(reason string) {
+	thread := prgrm.runningThread()
+	thread.Blocked = true
+	thread.WaitReason = reason
+}
+
+// wakes up the blocked threads, as they could be able to continue now
+func (prgrm *CXProgram) wakeThreads() {
+	for _, thread := range prgrm.Threads {
+		thread.Blocked = false
+	}
+}
+
+// PrintThreadStacks prints the call stack of every thread
+func (prgrm *CXProgram) PrintThreadStacks() {
+	if len(prgrm.Threads) == 0 {
+		prgrm.PrintStack()
+		return
+	}
+
+	current := prgrm.CurrentThread
+	prgrm.saveThread()
+	for i, thread := range prgrm.Threads {
+		prgrm.loadThread(i)
+		if thread.Blocked {
+			fmt.Printf("\nthread %d [%s]:", i, thread.WaitReason)
+		} else {
+			fmt.Printf("\nthread %d:", i)
+		}
+		prgrm.PrintStack()
+	}
+	prgrm.loadThread(current)
+}
+
+// SleepThread blocks the running thread for `d`, letting the other threads
+// run in the meantime
+func (prgrm *CXProgram) SleepThread(d time.Duration) {
+	if len(prgrm.Threads) < 2 {
+		time.Sleep(d)
+		return
+	}
+	prgrm.Threads[prgrm.CurrentThread].WakeAt = time.Now().Add(d)
+	// it can't yield in the middle of an expression, so it lets `Run` do it
+	prgrm.threadSteps = THREAD_QUANTUM
+}
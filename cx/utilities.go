@@ -51,6 +51,17 @@ func stackValueHeader (fileName string, fileLine int) string {
 }
 
 func (prgrm *CXProgram) PrintStack() {
+	// the stack is printed while reporting errors, and the error is
+	// still reported if a value in the stack can't be printed
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("(the rest of the call stack can't be printed: %v)\n", r)
+			if DBG_GOLANG_STACK_TRACE {
+				debug.PrintStack()
+			}
+		}
+	}()
+
         fmt.Println()
         fmt.Println("===Callstack===")
 
@@ -187,6 +198,16 @@ func (prgrm *CXProgram) PrintProgram() {
                         j++
                 }
 
+                if len(mod.Constants) > 0 {
+                        fmt.Println("\tConstants")
+                }
+
+                j = 0
+                for _, cnst := range mod.Constants {
+                        fmt.Printf("\t\t%d.- Constant: %s %s\n", j, cnst.Name, TypeNames[cnst.Type])
+                        j++
+                }
+
                 if len(mod.Structs) > 0 {
                         fmt.Println("\tStructs")
                 }
@@ -288,7 +309,7 @@ func (prgrm *CXProgram) PrintProgram() {
                                         var name string
                                         var dat []byte
 
-                                        if arg.Offset > STACK_SIZE {
+                                        if arg.Offset > prgrm.StackSize {
                                                 dat = prgrm.Memory[arg.Offset : arg.Offset+arg.Size]
                                         } else {
                                                 name = arg.Name
@@ -525,49 +546,19 @@ func IsTempVar (name string) bool {
 
 func GetArgSize(typ int) int {
         switch typ {
-        case TYPE_BOOL, TYPE_BYTE:
+        case TYPE_BOOL, TYPE_BYTE, TYPE_I8, TYPE_UI8:
                 return 1
-        case TYPE_STR, TYPE_I32, TYPE_F32, TYPE_AFF:
+        case TYPE_I16, TYPE_UI16:
+                return 2
+        case TYPE_STR, TYPE_I32, TYPE_UI32, TYPE_F32, TYPE_AFF:
                 return 4
-        case TYPE_I64, TYPE_F64:
+        case TYPE_I64, TYPE_UI64, TYPE_F64:
                 return 8
         default:
                 return 4
         }
 }
 
-func checkForEscapedChars (str string) []byte {
-        var res []byte
-        var lenStr int = len(str)
-        for c := 0; c < len(str); c++ {
-                var nextCh byte
-                ch := str[c]
-                if c < lenStr - 1{
-                        nextCh = str[c+1]
-                }
-                if ch == '\\' {
-                        switch nextCh {
-                        case '%':
-                                c++
-                                res = append(res, nextCh)
-                                continue
-                        case 'n':
-                                c++
-                                res = append(res, '\n')
-                                continue
-                        default:
-                                res = append(res, ch)
-                                continue
-                        }
-
-                } else {
-                        res = append(res, ch)
-                }
-        }
-
-        return res
-}
-
 func GetAssignmentElement (arg *CXArgument) *CXArgument {
         if len(arg.Fields) > 0 {
                 return arg.Fields[len(arg.Fields) - 1]
@@ -576,6 +567,29 @@ func GetAssignmentElement (arg *CXArgument) *CXArgument {
         }
 }
 
+// GetNamedType returns the named type of the value of `arg`, or nil if its
+// type is not named. The elements of a value of a named array or slice type
+// are not of the named type
+func GetNamedType (arg *CXArgument) *CXStruct {
+	elt := GetAssignmentElement(arg)
+	if elt.NamedType == nil || elt.NamedType.Underlying == nil {
+		return nil
+	}
+	if len(elt.Lengths) - len(elt.Indexes) != len(elt.NamedType.Underlying.Lengths) {
+		return nil
+	}
+	return elt.NamedType
+}
+
+// ReceiverType returns the type whose methods are called on the value of
+// `arg`, which is its named type if it has one
+func ReceiverType (arg *CXArgument) *CXStruct {
+	if named := GetNamedType(arg); named != nil {
+		return named
+	}
+	return GetAssignmentElement(arg).CustomType
+}
+
 func WriteToSlice (off int, inp []byte) int {
         var heapOffset int
         
@@ -591,47 +605,34 @@ func WriteToSlice (off int, inp []byte) int {
                         header[c] = size[c-5]
                 }
 
-                one := []byte{1, 0, 0, 0}
-
-                // len == 1
-                finalObj := append(header, one...)
-                // cap == 1
-                finalObj = append(finalObj, one...)
+                // len == 1, cap == 1
+                finalObj := append(header, sliceHeader(1, 1)...)
                 finalObj = append(finalObj, inp...)
 
                 WriteMemory(heapOffset, finalObj)
                 return heapOffset
         } else {
                 // then it already exists
-                sliceHeader := PROGRAM.Memory[off + OBJECT_HEADER_SIZE : off + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE]
-
-                var l int32
-                var c int32
-
-                encoder.DeserializeAtomic(sliceHeader[:4], &l)
-                encoder.DeserializeAtomic(sliceHeader[4:], &c)
+                l, c := readSliceHeader(off)
 
                 if l >= c {
                         // then we need to increase cap and relocate slice
-                        obj := PROGRAM.Memory[off + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE : int32(off) + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + l*int32(len(inp))]
+                        elts := sliceElements(off)
+                        obj := PROGRAM.Memory[elts : elts + l*len(inp)]
 
                         l++
                         c = c * 2
 
-                        heapOffset = AllocateSeq(int(c) * len(inp) + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE)
+                        heapOffset = AllocateSeq(c * len(inp) + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE)
 
-                        size := encoder.SerializeAtomic(int32(int(c) * len(inp) + SLICE_HEADER_SIZE))
+                        size := encoder.SerializeAtomic(int32(c * len(inp) + SLICE_HEADER_SIZE))
 
                         var header []byte = make([]byte, OBJECT_HEADER_SIZE)
                         for c := 5; c < OBJECT_HEADER_SIZE; c++ {
                                 header[c] = size[c-5]
                         }
 
-                        lB := encoder.SerializeAtomic(l)
-                        cB := encoder.SerializeAtomic(c)
-
-                        finalObj := append(header, lB...)
-                        finalObj = append(finalObj, cB...)
+                        finalObj := append(header, sliceHeader(l, c)...)
                         finalObj = append(finalObj, obj...)
                         finalObj = append(finalObj, inp...)
 
@@ -642,16 +643,10 @@ func WriteToSlice (off int, inp []byte) int {
                         // then we can simply write the element
 
                         // updating the length
-                        newL := encoder.SerializeAtomic(l+int32(1))
-
-                        for i, byt := range newL {
-                                PROGRAM.Memory[int(off) + OBJECT_HEADER_SIZE + i] = byt
-                        }
+                        writeSliceField(off, sliceLen, l + 1)
 
                         // write the obj
-                        for i, byt := range inp {
-                                PROGRAM.Memory[off + OBJECT_HEADER_SIZE + SLICE_HEADER_SIZE + int(l) * len(inp) + i] = byt
-                        }
+                        WriteMemory(sliceElements(off) + l * len(inp), inp)
 
                         return off
                 }
@@ -688,14 +683,22 @@ func WriteObjectRetOff (obj []byte) int {
         return writeObj(obj)
 }
 
-func ErrorHeader (currentFile string, lineNo int) string {
+// ErrorHeader returns the position of an error, which includes its column if
+// its span is known, e.g. "error: main.cx:7:12"
+func ErrorHeader (currentFile string, lineNo int, span Span) string {
+	if span.IsValid() {
+		return "error: " + currentFile + ":" + strconv.Itoa(span.Line) + ":" + strconv.Itoa(span.Column)
+	}
 	return "error: " + currentFile + ":" + strconv.FormatInt(int64(lineNo), 10)
 }
 
 func runtimeErrorInfo (r interface{}, printStack bool) {
 	call := PROGRAM.CallStack[PROGRAM.CallCounter]
 	expr := call.Operator.Expressions[call.Line]
-	fmt.Println(ErrorHeader(expr.FileName, expr.FileLine), r)
+	fmt.Println(ErrorHeader(expr.FileName, expr.FileLine, expr.Span), r)
+	if excerpt := SourceExcerpt(expr.FileName, expr.Span); excerpt != "" {
+		fmt.Println(excerpt)
+	}
 
 	if printStack {
 		PROGRAM.PrintStack()
@@ -704,28 +707,68 @@ func runtimeErrorInfo (r interface{}, printStack bool) {
 	if DBG_GOLANG_STACK_TRACE {
 		debug.PrintStack()
 	}
-	
-	os.Exit(3)
 }
 
+// MemoryError is raised when a program needs more memory than it is
+// allowed to use. `Kind` is one of STACK_OVERFLOW_ERROR,
+// CALLSTACK_OVERFLOW_ERROR or HEAP_EXHAUSTED_ERROR
+type MemoryError struct {
+	Kind      string
+	Requested int
+	Used      int
+	Limit     int
+}
+
+func (err MemoryError) Error() string {
+	if err.Kind == CALLSTACK_OVERFLOW_ERROR {
+		return fmt.Sprintf("%s: %d of %d calls in use", err.Kind, err.Used, err.Limit)
+	}
+	return fmt.Sprintf("%s: requested %d bytes, %d of %d bytes in use", err.Kind, err.Requested, err.Used, err.Limit)
+}
+
+// RuntimeError recovers from the error the program panicked with, which
+// is unwound by the program (see op_panic.go) or reported right away
 func RuntimeError () {
 	if r := recover(); r != nil {
-		switch r {
-		case STACK_OVERFLOW_ERROR:
-			call := PROGRAM.CallStack[PROGRAM.CallCounter]
-			if PROGRAM.CallCounter > 0 {
-				PROGRAM.CallCounter--
-				PROGRAM.StackPointer = call.FramePointer
-				runtimeErrorInfo(r, true)
-			} else {
-				// error at entry point
-				runtimeErrorInfo(r, false)
-			}
-		default:
+		if PROGRAM.startPanic(r) {
+			return
+		}
+		reportRuntimeError(r)
+	}
+}
+
+// reports the error the program panicked with, and exits
+func reportRuntimeError (r interface{}) {
+	var kind interface{} = r
+	if err, ok := r.(MemoryError); ok {
+		kind = err.Kind
+	}
+
+	switch kind {
+	case STACK_OVERFLOW_ERROR:
+		call := PROGRAM.CallStack[PROGRAM.CallCounter]
+		if PROGRAM.CallCounter > 0 {
+			PROGRAM.CallCounter--
+			PROGRAM.StackPointer = call.FramePointer
 			runtimeErrorInfo(r, true)
+		} else {
+			// error at entry point
+			runtimeErrorInfo(r, false)
+		}
+	case DEADLOCK_ERROR:
+		runtimeErrorInfo(r, false)
+		PROGRAM.PrintThreadStacks()
+	default:
+		if _, ok := r.(AssertionError); ok {
+			// the assertion already reported it
+			os.Exit(CX_ASSERT)
+		}
+		runtimeErrorInfo(r, true)
+		if _, ok := r.(UserPanic); ok {
+			os.Exit(CX_PANIC)
 		}
-        os.Exit(CX_RUNTIME_ERROR)
 	}
+	os.Exit(CX_RUNTIME_ERROR)
 }
 
 func getNonCollectionValue (fp int, arg, elt *CXArgument, typ string) string {
@@ -740,11 +783,30 @@ func getNonCollectionValue (fp int, arg, elt *CXArgument, typ string) string {
 		return fmt.Sprintf("%v", ReadI32(fp, elt))
 	case "i64":
 		return fmt.Sprintf("%v", ReadI64(fp, elt))
+	case "i8":
+		return fmt.Sprintf("%v", ReadI8(fp, elt))
+	case "i16":
+		return fmt.Sprintf("%v", ReadI16(fp, elt))
+	case "ui8":
+		return fmt.Sprintf("%v", ReadUI8(fp, elt))
+	case "ui16":
+		return fmt.Sprintf("%v", ReadUI16(fp, elt))
+	case "ui32":
+		return fmt.Sprintf("%v", ReadUI32(fp, elt))
+	case "ui64":
+		return fmt.Sprintf("%v", ReadUI64(fp, elt))
 	case "f32":
 		return fmt.Sprintf("%v", ReadF32(fp, elt))
 	case "f64":
 		return fmt.Sprintf("%v", ReadF64(fp, elt))
+	case "func":
+		return getFunctionValueName(readPointer(GetFinalOffset(fp, elt)))
 	default:
+		if elt.CustomType.IsInterface {
+			offset := GetFinalOffset(fp, elt)
+			return getInterfacePrintableValue(PROGRAM.Memory[offset : offset+INTERFACE_SIZE])
+		}
+
 		// then it's a struct
 		var val string
 		val = "{"
@@ -765,6 +827,14 @@ func getNonCollectionValue (fp int, arg, elt *CXArgument, typ string) string {
 	}
 }
 
+func getFunctionValueName (closure int) string {
+	if closure == NULL_HEAP_ADDRESS {
+		return "nil"
+	}
+	fn := closureFunc(closure)
+	return fmt.Sprintf("func(%s.%s)", fn.Package.Name, fn.Name)
+}
+
 func GetPrintableValue (fp int, arg *CXArgument) string {
 	var typ string
 	elt := GetAssignmentElement(arg)
@@ -776,6 +846,20 @@ func GetPrintableValue (fp int, arg *CXArgument) string {
 		typ = TypeNames[elt.Type]
 	}
 
+	if elt.IsMap {
+		return getMapPrintableValue(fp, arg, elt)
+	}
+	if elt.IsChan {
+		return fmt.Sprintf("chan(%d)", readPointer(GetFinalOffset(fp, arg)))
+	}
+	if elt.Type == TYPE_FUNC && len(elt.Lengths) == 0 {
+		return getFunctionValueName(readPointer(GetFinalOffset(fp, arg)))
+	}
+	if elt.IsPointer && elt.CustomType != nil && len(elt.Lengths) == 0 {
+		// printing the pointee could recurse forever, e.g. in a linked list
+		return fmt.Sprintf("&%s(%d)", ReceiverType(arg).Name, readPointer(GetFinalOffset(fp, arg)))
+	}
+
 	if len(elt.Lengths) > 0 {
 		var val string
 		if len(elt.Lengths) == 1 {
@@ -846,3 +930,100 @@ func GetPrintableValue (fp int, arg *CXArgument) string {
 
 	return getNonCollectionValue(fp, arg, elt, typ)
 }
+
+func getMapPrintableValue (fp int, arg, elt *CXArgument) string {
+	val := "map["
+
+	mapOffset := readPointer(GetFinalOffset(fp, arg))
+	if mapOffset != NULL_HEAP_ADDRESS {
+		_, _, keyType, valSize := readMapHeader(mapOffset)
+		keySize := GetArgSize(keyType)
+
+		for c := 0; c < mapUsed(mapOffset); c++ {
+			if mapDeleted(mapOffset, c) {
+				continue
+			}
+			entry := mapEntry(mapOffset, c)
+			if val != "map[" {
+				val += " "
+			}
+			val += getPrintableBytes(keyType, nil, PROGRAM.Memory[entry : entry + keySize]) + ":" +
+				getPrintableBytes(elt.Type, elt.CustomType, PROGRAM.Memory[entry + keySize : entry + keySize + valSize])
+		}
+	}
+
+	return val + "]"
+}
+
+// used to print values stored in heap objects, e.g. the keys and values of a map
+func getPrintableBytes (typ int, strct *CXStruct, byts []byte) string {
+	switch typ {
+	case TYPE_BOOL:
+		return fmt.Sprintf("%v", byts[0] != 0)
+	case TYPE_BYTE:
+		return fmt.Sprintf("%v", byts[0])
+	case TYPE_STR:
+		var off int32
+		encoder.DeserializeAtomic(byts, &off)
+		return readStrAt(off)
+	case TYPE_I32:
+		var val int32
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	case TYPE_I64:
+		var val int64
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	case TYPE_I8:
+		var val int8
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	case TYPE_I16:
+		var val int16
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	case TYPE_UI8:
+		var val uint8
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	case TYPE_UI16:
+		var val uint16
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	case TYPE_UI32:
+		var val uint32
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	case TYPE_UI64:
+		var val uint64
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	case TYPE_F32:
+		var val float32
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	case TYPE_F64:
+		var val float64
+		encoder.DeserializeAtomic(byts, &val)
+		return fmt.Sprintf("%v", val)
+	default:
+		if strct == nil {
+			return fmt.Sprintf("%v", byts)
+		}
+		if strct.IsInterface {
+			return getInterfacePrintableValue(byts)
+		}
+
+		// then it's a struct
+		val := "{"
+		off := 0
+		for c, fld := range strct.Fields {
+			if c > 0 {
+				val += ", "
+			}
+			val += fmt.Sprintf("%s: %s", fld.Name, getPrintableBytes(fld.Type, fld.CustomType, byts[off : off + fld.TotalSize]))
+			off += fld.TotalSize
+		}
+		return val + "}"
+	}
+}
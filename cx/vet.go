@@ -0,0 +1,339 @@
+package base
+
+import (
+	"sort"
+	"strings"
+)
+
+// Vet finds suspicious code in a complete program that compiles, and
+// reports it as warnings. Like Check, it doesn't need the parser. It
+// reports:
+//
+//	the imports that the code of their package doesn't use,
+//	the local variables that are declared but never used,
+//	the values assigned to variables that are never read,
+//	the declarations that shadow a global variable, or a variable of the
+//	function enclosing a function literal, and
+//	the calls to printf and sprintf whose arguments don't match their format.
+//
+// The calls that ignore the results of their functions don't compile.
+func (prgrm *CXProgram) Vet() []CheckError {
+	// the format strings and the functions of the closures are read from
+	// the memory of PROGRAM
+	PROGRAM = prgrm
+
+	var chk checker
+	literals := functionLiterals(prgrm)
+	for _, pkg := range prgrm.Packages {
+		chk.vetImports(pkg)
+		for _, fn := range pkg.Functions {
+			if fn.IsNative {
+				continue
+			}
+			chk.vetVariables(prgrm, fn)
+			chk.vetShadowing(fn, literals)
+			for _, expr := range fn.Expressions {
+				chk.vetPrintf(expr)
+			}
+		}
+	}
+	return chk.errs
+}
+
+// vetImports warns about the imports of `pkg` that its code doesn't use
+func (chk *checker) vetImports(pkg *CXPackage) {
+	var names []string
+	for name := range pkg.ImportDecls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if imp := pkg.ImportDecls[name]; !imp.IsUsed {
+			chk.warningf(imp.FileName, imp.FileLine, Span{}, DIAG_UNUSED, "package '%s' is imported but not used", name)
+		}
+	}
+}
+
+// vetVariables warns about the local variables of `fn` that are never used,
+// and about the assignments to its variables that are never read. The
+// variables whose address is taken or that are captured by a function
+// literal can be read anywhere, so they're not checked
+func (chk *checker) vetVariables(prgrm *CXProgram, fn *CXFunction) {
+	params := map[string]bool{}
+	for _, param := range append(fn.Inputs, fn.Outputs...) {
+		params[param.Name] = true
+	}
+
+	var vars []*CXArgument
+	declared := map[string]bool{}
+	for _, expr := range fn.Expressions {
+		// a declaration, or the initializer of a local variable, which
+		// declares it unless it's captured
+		for _, out := range expr.Outputs {
+			if expr.Operator != nil && (!out.IsLocalDeclaration || params[out.Name]) {
+				continue
+			}
+			if isUserVariable(out.Name) && !declared[out.Name] {
+				declared[out.Name] = true
+				vars = append(vars, out)
+			}
+		}
+	}
+	vars = append(vars, fn.Inputs...)
+
+	for _, v := range vars {
+		if isEscaped(fn, v.Name) {
+			continue
+		}
+
+		var isRead bool
+		for _, expr := range fn.Expressions {
+			if reads, _ := varAccess(expr, v.Name); reads {
+				isRead = true
+				break
+			}
+		}
+		if !isRead && declared[v.Name] {
+			chk.warningf(v.FileName, v.FileLine, v.Span, DIAG_UNUSED, "'%s' is declared but not used", v.Name)
+			continue
+		}
+
+		for i, expr := range fn.Expressions {
+			if _, writes := varAccess(expr, v.Name); !writes || expr.Operator == nil || isLiveAfter(prgrm, fn, i, v.Name) {
+				continue
+			}
+			for _, out := range expr.Outputs {
+				if out.Name == v.Name {
+					chk.warningf(out.FileName, out.FileLine, out.Span, DIAG_UNREAD, "value assigned to '%s' is never read", v.Name)
+					break
+				}
+			}
+		}
+	}
+}
+
+// isUserVariable tells if `name` was written in the code, and wasn't
+// generated by the compiler
+func isUserVariable(name string) bool {
+	return name != "" && name != "_" && !strings.HasPrefix(name, "*") && !strings.HasPrefix(name, NON_ASSIGN_PREFIX)
+}
+
+// isEscaped tells if the variable `name` of `fn` can be accessed without
+// naming it, because its address is taken or it's captured
+func isEscaped(fn *CXFunction, name string) bool {
+	var escaped bool
+	for _, expr := range fn.Expressions {
+		forEachArg(expr, func(slot **CXArgument, isOutput bool) {
+			arg := *slot
+			if arg.Name == name && (arg.IsCaptured || arg.DoesEscape || arg.PassBy == PASSBY_REFERENCE && arg.Type != TYPE_STR) {
+				escaped = true
+			}
+		})
+	}
+	return escaped
+}
+
+// varAccess tells if `expr` reads the variable `name`, and if it assigns a
+// new value to it. Assigning to a field or an element of a variable also
+// reads it
+func varAccess(expr *CXExpression, name string) (reads, writes bool) {
+	forEachArg(expr, func(slot **CXArgument, isOutput bool) {
+		arg := *slot
+		if arg.Name != name {
+			return
+		}
+		if isOutput && len(arg.Fields) == 0 && len(arg.Indexes) == 0 && len(arg.DereferenceOperations) == 0 {
+			writes = true
+		} else {
+			reads = true
+		}
+	})
+	return reads, writes
+}
+
+// isLiveAfter tells if the value that the i-th expression of `fn` assigns
+// to the variable `name` can be read, by following the expressions that
+// can be run after it until one reads the variable or assigns it again.
+// The outputs of `fn` are read when it returns
+func isLiveAfter(prgrm *CXProgram, fn *CXFunction, i int, name string) bool {
+	var isOutput bool
+	for _, out := range fn.Outputs {
+		if out.Name == name {
+			isOutput = true
+		}
+	}
+
+	visited := make([]bool, len(fn.Expressions))
+	pending := successors(prgrm, fn.Expressions[i], i)
+	for len(pending) > 0 {
+		j := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if j < 0 {
+			continue
+		}
+		if j >= len(fn.Expressions) {
+			if isOutput {
+				return true
+			}
+			continue
+		}
+		if visited[j] {
+			continue
+		}
+		visited[j] = true
+
+		reads, writes := varAccess(fn.Expressions[j], name)
+		if reads {
+			return true
+		}
+		if writes && fn.Expressions[j].Operator != nil {
+			continue
+		}
+		pending = append(pending, successors(prgrm, fn.Expressions[j], j)...)
+	}
+	return false
+}
+
+// functionLiteral is a function literal of the program being vetted
+type functionLiteral struct {
+	enclosing *CXFunction
+	// its last inputs receive the variables it captures
+	captures int
+}
+
+// functionLiterals returns the function literals of `prgrm` by their
+// function, found through the expressions making their closures
+func functionLiterals(prgrm *CXProgram) map[*CXFunction]functionLiteral {
+	literals := map[*CXFunction]functionLiteral{}
+	for _, pkg := range prgrm.Packages {
+		for _, fn := range pkg.Functions {
+			for _, expr := range fn.Expressions {
+				if expr.Operator == Natives[OP_CLOSURE] {
+					lit := closureFunc(readPointer(GetFinalOffset(0, expr.Inputs[0])))
+					literals[lit] = functionLiteral{enclosing: fn, captures: len(expr.Inputs) - 1}
+				}
+			}
+		}
+	}
+	return literals
+}
+
+// declaredVariables returns the parameters, outputs and local variables
+// of `fn` by name. The declarations of the variables captured by function
+// literals are replaced by the code moving them to the heap, so these are
+// found where they're used. The variables captured by `fn` itself are
+// declared by the functions enclosing it
+func declaredVariables(fn *CXFunction, literals map[*CXFunction]functionLiteral) map[string]*CXArgument {
+	params := fn.Inputs[:len(fn.Inputs)-literals[fn].captures]
+
+	vars := map[string]*CXArgument{}
+	captured := map[string]bool{}
+	for _, inp := range fn.Inputs[len(params):] {
+		captured[inp.Name] = true
+	}
+	for _, param := range params {
+		vars[param.Name] = param
+	}
+	for _, param := range fn.Outputs {
+		vars[param.Name] = param
+	}
+
+	for _, expr := range fn.Expressions {
+		forEachArg(expr, func(slot **CXArgument, isOutput bool) {
+			arg := *slot
+			if !isUserVariable(arg.Name) || captured[arg.Name] || vars[arg.Name] != nil {
+				return
+			}
+			if expr.Operator == nil && isOutput || arg.IsCaptured {
+				vars[arg.Name] = arg
+			}
+		})
+	}
+	return vars
+}
+
+// vetShadowing warns about the local variables of `fn` that have the name
+// of a global variable of its package, and about the variables of the
+// function literals that have the name of a variable of a function
+// enclosing them. The parameters of the other functions aren't checked
+func (chk *checker) vetShadowing(fn *CXFunction, literals map[*CXFunction]functionLiteral) {
+	isParam := map[*CXArgument]bool{}
+	for _, param := range fn.Inputs {
+		isParam[param] = true
+	}
+	for _, param := range fn.Outputs {
+		isParam[param] = true
+	}
+	_, isLiteral := literals[fn]
+
+	for name, v := range declaredVariables(fn, literals) {
+		if !isUserVariable(name) || isParam[v] && !isLiteral {
+			continue
+		}
+
+		var outer *CXArgument
+		for lit, found := literals[fn]; found && outer == nil; lit, found = literals[lit.enclosing] {
+			outer = declaredVariables(lit.enclosing, literals)[name]
+		}
+		if outer == nil {
+			outer, _ = fn.Package.GetGlobal(name)
+		}
+		if outer != nil {
+			chk.warningf(v.FileName, v.FileLine, v.Span, DIAG_SHADOW, "declaration of '%s' shadows the declaration at %s:%d", name, outer.FileName, outer.FileLine)
+		}
+	}
+}
+
+// vetPrintf warns about the calls to printf and sprintf whose format
+// string is a literal that doesn't match the arguments of the call. The
+// format is read by parseFormat, like when the call is run
+func (chk *checker) vetPrintf(expr *CXExpression) {
+	if expr.Operator != Natives[OP_UND_PRINTF] && expr.Operator != Natives[OP_UND_SPRINTF] || len(expr.Inputs) == 0 {
+		return
+	}
+	opName := OpNames[expr.Operator.OpCode]
+	format := expr.Inputs[0]
+	if format.Name != "" || format.Type != TYPE_STR || len(format.Fields) > 0 || len(format.Indexes) > 0 {
+		// the format is only known when the program runs
+		return
+	}
+	args := expr.Inputs[1:]
+
+	var directives int
+	parseFormat(ReadStr(0, format), func(ch byte) {}, func(verb byte) {
+		if directives >= len(args) {
+			chk.warningf(expr.FileName, expr.FileLine, expr.Span, DIAG_PRINTF, "%s format %%%c reads argument %d, but the call has %d arguments", opName, verb, directives+1, len(args))
+			return
+		}
+		arg := args[directives]
+		directives++
+
+		var types []int
+		switch verb {
+		case 's':
+			types = []int{TYPE_STR}
+		case 'd':
+			types = integerTypes
+		case 'f':
+			types = []int{TYPE_F32, TYPE_F64}
+		case 'v':
+			return
+		default:
+			chk.warningf(expr.FileName, expr.FileLine, expr.Span, DIAG_PRINTF, "%s format %%%c has an unknown verb", opName, verb)
+			return
+		}
+
+		elt := GetAssignmentElement(arg)
+		if elt.Type == TYPE_UNDEFINED || elt.IsPointer && elt.Type != TYPE_STR {
+			return
+		}
+		if elt.IsSlice || elt.IsMap || elt.IsChan || elt.CustomType != nil || !hasType(elt, types) {
+			chk.warningf(arg.FileName, arg.FileLine, arg.Span, DIAG_PRINTF, "%s format %%%c has argument %d of wrong type", opName, verb, directives)
+		}
+	})
+
+	if directives < len(args) {
+		chk.warningf(expr.FileName, expr.FileLine, expr.Span, DIAG_PRINTF, "%s call has %d arguments, but its format has %d directives", opName, len(args), directives)
+	}
+}
@@ -30,9 +30,10 @@ func ArrayLiteralAssignment(to []*CXExpression, from []*CXExpression) []*CXExpre
 }
 
 func ShortAssignment (expr *CXExpression, to []*CXExpression, from []*CXExpression, pkg *CXPackage, idx int) []*CXExpression {
+	// the package needs to be set first, as AddOutput assigns it to the output
+	expr.Package = pkg
 	expr.AddInput(to[0].Outputs[0])
 	expr.AddOutput(to[0].Outputs[0])
-	expr.Package = pkg
 
 	if from[idx].Operator == nil {
 		expr.AddInput(from[idx].Outputs[0])
@@ -47,15 +48,33 @@ func ShortAssignment (expr *CXExpression, to []*CXExpression, from []*CXExpressi
 	return append(from, expr)
 }
 
-func Assignment (to []*CXExpression, assignOp string, from []*CXExpression) []*CXExpression {
+func Assignment (to []*CXExpression, assignOp string, from []*CXExpression) (result []*CXExpression) {
+	defer RecoverExpressions(&result, from)
+
 	idx := len(from) - 1
 
+	if NoValue(from) {
+		return from
+	}
+
 	if pkg, err := PRGRM.GetCurrentPackage(); err == nil {
 
 		var expr *CXExpression
 		
 		switch assignOp {
 		case ":=":
+			if from[idx].Operator == Natives[OP_SLICE] {
+				// the symbol is declared from a temporary variable, which
+				// adopts its type once the sliced value's type is known
+				out := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo).AddType(TypeNames[TYPE_UNDEFINED])
+				out.Package = pkg
+				out.PreviouslyDeclared = true
+				from[idx].AddOutput(out)
+
+				from = append(from, PrimaryIdentifier(out.Name)...)
+				idx = len(from) - 1
+			}
+
 			expr = MakeExpression(nil, CurrentFile, LineNo)
 			expr.Package = pkg
 
@@ -64,7 +83,28 @@ func Assignment (to []*CXExpression, assignOp string, from []*CXExpression) []*C
 			if from[idx].Operator == nil {
 				// then it's a literal
 				sym = MakeArgument(to[0].Outputs[0].Name, CurrentFile, LineNo).AddType(TypeNames[from[idx].Outputs[0].Type])
-			} else {
+			} else if (!from[idx].Operator.IsNative && len(from[idx].Operator.Outputs) > 0) || from[idx].IsConversion {
+				// then it's a function call, and the symbol adopts its first output's type
+				out := from[idx].Operator.Outputs[0]
+				if from[idx].IsConversion {
+					// or a conversion, and the symbol adopts the converted type
+					out = from[idx].Outputs[0]
+				}
+				sym = MakeArgument(to[0].Outputs[0].Name, CurrentFile, LineNo).AddType(TypeNames[out.Type])
+				sym.CustomType = out.CustomType
+				sym.NamedType = out.NamedType
+				sym.Size = out.Size
+				sym.TotalSize = out.TotalSize
+				sym.Lengths = out.Lengths
+				sym.IsSlice = out.IsSlice
+				sym.IsMap = out.IsMap
+				sym.MapKeyType = out.MapKeyType
+				sym.IsChan = out.IsChan
+				sym.PassBy = out.PassBy
+				sym.DeclarationSpecifiers = out.DeclarationSpecifiers
+				sym.Inputs = out.Inputs
+				sym.Outputs = out.Outputs
+			} else if len(from[idx].Inputs) > 0 {
 				sym = MakeArgument(to[0].Outputs[0].Name, CurrentFile, LineNo).AddType(TypeNames[from[idx].Inputs[0].Type])
 				// sym = MakeArgument(to[0].Outputs[0].Name, CurrentFile, LineNo).AddType(TypeNames[from[idx].Operator.Outputs[0].Type])
 				
@@ -80,6 +120,23 @@ func Assignment (to []*CXExpression, assignOp string, from []*CXExpression) []*C
 				
 				sym.IsSlice = from[idx].Inputs[0].IsSlice
 				// sym.IsSlice = from[idx].Operator.Outputs[0].IsSlice
+
+				if from[idx].Inputs[0].IsMap {
+					sym.IsMap = true
+					sym.MapKeyType = from[idx].Inputs[0].MapKeyType
+					sym.CustomType = from[idx].Inputs[0].CustomType
+					sym.Size = TYPE_POINTER_SIZE
+					sym.TotalSize = TYPE_POINTER_SIZE
+				}
+				if from[idx].Inputs[0].IsChan {
+					sym.IsChan = true
+					sym.CustomType = from[idx].Inputs[0].CustomType
+					sym.DeclarationSpecifiers = from[idx].Inputs[0].DeclarationSpecifiers
+					sym.Size = TYPE_POINTER_SIZE
+					sym.TotalSize = TYPE_POINTER_SIZE
+				}
+			} else {
+				sym = MakeArgument(to[0].Outputs[0].Name, CurrentFile, LineNo).AddType(TypeNames[TYPE_UNDEFINED])
 			}
 			sym.Package = pkg
 			sym.PreviouslyDeclared = true
@@ -126,13 +183,26 @@ func Assignment (to []*CXExpression, assignOp string, from []*CXExpression) []*C
 		}
 	}
 
+	if from[idx].IsConversion && assignOp != ":=" {
+		// the converted value is assigned like any other value of its type
+		expr := MakeExpression(Natives[OP_IDENTITY], CurrentFile, LineNo)
+		expr.Package = from[idx].Package
+		expr.AddInput(from[idx].Outputs[0])
+		expr.Outputs = to[len(to)-1].Outputs
+
+		return append(to[:len(to)-1], append(from, expr)...)
+	}
+
 	if from[idx].Operator == nil {
+		// a short declaration added its own expression to the start of `to`
+		out := to[len(to)-1].Outputs[0]
+
 		from[idx].Operator = Natives[OP_IDENTITY]
-		to[0].Outputs[0].Size = from[idx].Outputs[0].Size
-		to[0].Outputs[0].Type = from[idx].Outputs[0].Type
-		to[0].Outputs[0].Lengths = from[idx].Outputs[0].Lengths
-		to[0].Outputs[0].PassBy = from[idx].Outputs[0].PassBy
-		to[0].Outputs[0].DoesEscape = from[idx].Outputs[0].DoesEscape
+		out.Size = from[idx].Outputs[0].Size
+		out.Type = from[idx].Outputs[0].Type
+		out.Lengths = from[idx].Outputs[0].Lengths
+		out.PassBy = from[idx].Outputs[0].PassBy
+		out.DoesEscape = from[idx].Outputs[0].DoesEscape
 		// to[0].Outputs[0].Program = PRGRM
 
 		if from[idx].IsMethodCall {
@@ -149,8 +219,9 @@ func Assignment (to []*CXExpression, assignOp string, from []*CXExpression) []*C
 		if from[idx].Operator.IsNative {
 			// only assigning as if the operator had only one output defined
 
-			if from[idx].Operator.OpCode != OP_IDENTITY {
-				// it's a short variable declaration
+			if from[idx].Operator.OpCode != OP_IDENTITY && !from[idx].IsConversion {
+				// it's a short variable declaration, and a conversion's
+				// output already has the converted type
 				to[0].Outputs[0].Size = Natives[from[idx].Operator.OpCode].Outputs[0].Size
 				to[0].Outputs[0].Type = from[idx].Operator.Outputs[0].Type
 				to[0].Outputs[0].Lengths = from[idx].Operator.Outputs[0].Lengths
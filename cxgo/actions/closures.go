@@ -0,0 +1,359 @@
+package actions
+
+import (
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	. "github.com/skycoin/cx/cx"
+)
+
+// Function literals are declared as functions of their package, and are
+// processed while the function enclosing them is. A variable captured by a
+// function literal escapes to the heap when it's declared, and the
+// enclosing function keeps the address of its heap object in its frame
+// (see `IsCaptured`). The closures made by `OP_CLOSURE` carry these
+// addresses, which the function literal receives in extra inputs.
+
+type functionLiteral struct {
+	fn   *CXFunction
+	body []*CXExpression
+}
+
+// the functions of the literals, indexed by the expressions making their closures
+var functionLiterals map[*CXExpression]*functionLiteral = make(map[*CXExpression]*functionLiteral)
+
+// the functions enclosing the function literals being parsed
+var enclosingFunctions []*CXFunction
+
+// the values of the functions used as values, kept in the data segment
+var functionValues map[*CXFunction]*CXArgument = make(map[*CXFunction]*CXArgument)
+
+// the function calls parsed, which can be called again if they return a function value
+var functionCalls map[*CXExpression]bool = make(map[*CXExpression]bool)
+
+// funcScope is a function being declared by `FunctionDeclaration`
+type funcScope struct {
+	fn      *CXFunction
+	symbols *map[string]*CXArgument
+	offset  *int
+	// the expression making the closures of fn if it's a function literal
+	closure *CXExpression
+	// the variables of fn captured by its function literals
+	captured map[string]bool
+}
+
+var scopes []*funcScope
+
+func FunctionLiteralHeader (inputs, outputs []*CXArgument) *CXFunction {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+	parent, err := pkg.GetCurrentFunction()
+	if err != nil {
+		panic(err)
+	}
+
+	fn := MakeFunction(MakeGenSym(LAMBDA_PREFIX))
+	pkg.AddFunction(fn)
+	for _, inp := range inputs {
+		fn.AddInput(inp)
+	}
+	for _, out := range outputs {
+		fn.AddOutput(out)
+	}
+
+	enclosingFunctions = append(enclosingFunctions, parent)
+
+	return fn
+}
+
+func FunctionLiteral (fn *CXFunction, body []*CXExpression) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	// the rest of the enclosing function is parsed now
+	pkg.CurrentFunction = enclosingFunctions[len(enclosingFunctions) - 1]
+	enclosingFunctions = enclosingFunctions[:len(enclosingFunctions) - 1]
+
+	expr := MakeExpression(Natives[OP_CLOSURE], CurrentFile, LineNo)
+	expr.Package = pkg
+	expr.AddInput(FunctionValue(fn))
+
+	functionLiterals[expr] = &functionLiteral{fn: fn, body: body}
+
+	return []*CXExpression{expr}
+}
+
+// FunctionValue returns the argument holding the value of `fn`, which
+// captures nothing
+func FunctionValue (fn *CXFunction) *CXArgument {
+	if val, found := functionValues[fn]; found {
+		return val
+	}
+
+	obj := WritePrimary(TYPE_FUNC, PRGRM.FunctionObject(fn), false)[0].Outputs[0]
+	val := WritePrimary(TYPE_FUNC, encoder.SerializeAtomic(int32(obj.Offset)), false)[0].Outputs[0]
+
+	val.DeclarationSpecifiers = []int{DECL_BASIC}
+	// the function literals can get more inputs for their captured variables
+	val.Inputs = append([]*CXArgument{}, fn.Inputs...)
+	val.Outputs = append([]*CXArgument{}, fn.Outputs...)
+
+	functionValues[fn] = val
+
+	return val
+}
+
+// declares the function literal whose closures are made by `expr`
+func declareFunctionLiteral (expr *CXExpression) {
+	if lit, found := functionLiterals[expr]; found {
+		FunctionDeclaration(lit.fn, append([]*CXArgument{}, lit.fn.Inputs...), append([]*CXArgument{}, lit.fn.Outputs...), lit.body)
+	}
+}
+
+func pushScope (fn *CXFunction, symbols *map[string]*CXArgument, offset *int) *funcScope {
+	scope := &funcScope{fn: fn, symbols: symbols, offset: offset}
+
+	for expr, lit := range functionLiterals {
+		if lit.fn == fn {
+			scope.closure = expr
+			break
+		}
+	}
+
+	scopes = append(scopes, scope)
+
+	return scope
+}
+
+func popScope () {
+	scopes = scopes[:len(scopes) - 1]
+}
+
+// currentScope returns the function being declared, if any
+func currentScope () *funcScope {
+	if len(scopes) == 0 {
+		return nil
+	}
+	return scopes[len(scopes) - 1]
+}
+
+// capturedVariables returns the names of the parameters, outputs and local
+// variables of `fn` that are used by its function literals
+func capturedVariables (fn *CXFunction) map[string]bool {
+	used := make(map[string]bool)
+	for _, expr := range fn.Expressions {
+		if lit, found := functionLiterals[expr]; found {
+			literalNames(lit.body, used)
+		}
+	}
+
+	captured := make(map[string]bool)
+	for _, inp := range append(fn.Inputs, fn.Outputs...) {
+		if used[inp.Name] {
+			captured[inp.Name] = true
+		}
+	}
+	for _, expr := range fn.Expressions {
+		if isLocalDeclaration(expr) && used[expr.Outputs[0].Name] {
+			captured[expr.Outputs[0].Name] = true
+		}
+	}
+
+	return captured
+}
+
+// adds the names used by `exprs`, and by the function literals in them, to `names`
+func literalNames (exprs []*CXExpression, names map[string]bool) {
+	for _, expr := range exprs {
+		for _, arg := range append(expr.Inputs, expr.Outputs...) {
+			argNames(arg, names)
+		}
+		if lit, found := functionLiterals[expr]; found {
+			literalNames(lit.body, names)
+		}
+	}
+}
+
+func argNames (arg *CXArgument, names map[string]bool) {
+	if arg.Name != "" {
+		names[arg.Name] = true
+	}
+	for _, idx := range arg.Indexes {
+		argNames(idx, names)
+	}
+	for _, fld := range arg.Fields {
+		for _, idx := range fld.Indexes {
+			argNames(idx, names)
+		}
+	}
+}
+
+func isLocalDeclaration (expr *CXExpression) bool {
+	return expr.Operator == nil && len(expr.Outputs) == 1 && len(expr.Inputs) == 0 && expr.Outputs[0].Name != ""
+}
+
+// markCaptured marks `sym` if it's a local variable captured by a function
+// literal of the function being declared
+func markCaptured (sym *CXArgument) {
+	if scope := currentScope(); scope != nil && scope.captured[sym.Name] {
+		sym.IsCaptured = true
+	}
+}
+
+// captureParameters moves the parameters of the function being declared
+// which are captured by its function literals to the heap. The frame holds
+// the addresses of their heap objects in new slots
+func captureParameters (scope *funcScope, captured map[string]bool) []*CXExpression {
+	var exprs []*CXExpression
+	for _, inp := range scope.fn.Inputs {
+		if !captured[inp.Name] {
+			continue
+		}
+
+		key := inp.Package.Name + "." + inp.Name
+
+		sym := *inp
+		// the original parameter is still a root of the function
+		sym.Name = MakeGenSym(LOCAL_PREFIX)
+		sym.IsCaptured = true
+		sym.Offset = *scope.offset
+		*scope.offset += TYPE_POINTER_SIZE
+		(*scope.symbols)[key] = &sym
+
+		exprs = append(exprs, escapeExpression(inp, inp.Offset, sym.Offset))
+	}
+
+	return exprs
+}
+
+// captureOutputs moves the outputs of the function being declared which
+// are captured by its function literals to the heap, where they're zeroed.
+// The outputs are read from there when the function returns, after running
+// its deferred calls, which can change them
+func captureOutputs (scope *funcScope, captured map[string]bool) []*CXExpression {
+	var exprs []*CXExpression
+	for _, out := range scope.fn.Outputs {
+		if !captured[out.Name] {
+			continue
+		}
+		out.IsCaptured = true
+
+		size := out.TotalSize
+		if out.IsSlice {
+			size = out.Size
+		}
+		zero := WritePrimary(TYPE_BYTE, make([]byte, size), false)[0].Outputs[0]
+
+		exprs = append(exprs, escapeExpression(zero, zero.Offset, out.Offset))
+	}
+
+	return exprs
+}
+
+// uncaptureDeclarations empties the declarations made by
+// `localDeclaration` for the local variables of `fn` that aren't captured,
+// as their initializers declare them. The expressions are kept, so the
+// jumps over them stay valid
+func uncaptureDeclarations (fn *CXFunction, captured map[string]bool) {
+	for _, expr := range fn.Expressions {
+		if initDeclarations[expr] && !captured[expr.Outputs[0].Name] {
+			expr.Outputs = nil
+		}
+	}
+}
+
+// captureDeclarations turns the declarations of the captured local
+// variables of the function being declared into expressions moving them to
+// the heap, where they're zeroed
+func captureDeclarations (scope *funcScope) {
+	for _, expr := range scope.fn.Expressions {
+		if !isLocalDeclaration(expr) {
+			continue
+		}
+		sym := expr.Outputs[0]
+		if !sym.IsCaptured || (*scope.symbols)[sym.Package.Name + "." + sym.Name] != sym {
+			continue
+		}
+
+		size := sym.TotalSize
+		if sym.IsSlice {
+			size = sym.Size
+		}
+		zero := WritePrimary(TYPE_BYTE, make([]byte, size), false)[0].Outputs[0]
+
+		escape := escapeExpression(zero, zero.Offset, sym.Offset)
+		expr.Operator = escape.Operator
+		expr.Inputs = escape.Inputs
+		expr.Outputs = escape.Outputs
+	}
+}
+
+// escapeExpression copies the `arg` at `from` to a new heap object, whose
+// address is written at `to` in the frame
+func escapeExpression (arg *CXArgument, from int, to int) *CXExpression {
+	expr := MakeExpression(Natives[OP_IDENTITY], arg.FileName, arg.FileLine)
+	expr.Package = arg.Package
+
+	inp := MakeArgument("", arg.FileName, arg.FileLine).AddType(TypeNames[TYPE_BYTE])
+	inp.Package = arg.Package
+	inp.Offset = from
+	inp.Size = arg.Size
+	inp.TotalSize = arg.TotalSize
+	if arg.IsSlice {
+		inp.TotalSize = arg.Size
+	}
+
+	out := MakeArgument("", arg.FileName, arg.FileLine).AddType(TypeNames[TYPE_FUNC])
+	out.Package = arg.Package
+	out.Offset = to
+	out.DoesEscape = true
+
+	expr.AddInput(inp)
+	expr.AddOutput(out)
+
+	return expr
+}
+
+// captureSymbol makes the function literal declared in `scopes[i]` capture
+// the variable `name` of the functions enclosing it, returning its symbol
+// in the function literal
+func captureSymbol (i int, pkg *CXPackage, name string) *CXArgument {
+	if i < 1 || scopes[i].closure == nil {
+		return nil
+	}
+
+	key := pkg.Name + "." + name
+
+	sym, found := (*scopes[i - 1].symbols)[key]
+	if !found {
+		sym = captureSymbol(i - 1, pkg, name)
+	}
+	if sym == nil || !sym.IsCaptured {
+		// globals and functions are not captured
+		return nil
+	}
+
+	scope := scopes[i]
+
+	capt := *sym
+	capt.Name = name
+	capt.Offset = *scope.offset
+	capt.DereferenceOperations = nil
+	capt.DereferenceLevels = 0
+	capt.Indexes = nil
+	capt.Fields = nil
+	*scope.offset += TYPE_POINTER_SIZE
+
+	scope.fn.AddInput(&capt)
+	(*scope.symbols)[key] = &capt
+
+	// the closure gets the address of the variable's heap object
+	addr := MakeArgument("", CurrentFile, LineNo).AddType(TypeNames[TYPE_FUNC])
+	addr.Package = pkg
+	addr.Offset = sym.Offset
+	scope.closure.AddInput(addr)
+
+	return &capt
+}
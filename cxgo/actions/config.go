@@ -6,6 +6,7 @@ import (
 
 var PRGRM *CXProgram
 var DataOffset int = STACK_SIZE + TYPE_POINTER_SIZE // to be able to handle nil pointers
+var nilMapValueSize int // size of PRGRM.NilMapValue
 
 var CurrentFile string
 var LineNo int = 0
@@ -16,12 +17,18 @@ var ReplMode bool
 var HelpMode bool
 var InterpretMode bool
 var CompileMode bool
+var OptimizeMode bool
+var VetMode bool
 var ReplTargetFn string = ""
 var ReplTargetStrct string = ""
 var ReplTargetMod string = ""
 
 var FoundCompileErrors bool
 
+// set while the first pass of the parser runs. The second pass parses the
+// same code, so some errors are only reported by it
+var InFirstPass bool
+
 var InREPL bool = false
 
 var SysInitExprs []*CXExpression
@@ -53,6 +60,10 @@ const (
 	OP_GT
 	OP_LTEQ
 	OP_GTEQ
+
+	// only used when folding constants
+	OP_LOGICAL_AND
+	OP_LOGICAL_OR
 )
 
 const (
@@ -0,0 +1,611 @@
+package actions
+
+import (
+	"fmt"
+	"math"
+
+	. "github.com/skycoin/cx/cx"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// next value to be assigned to an enum's constant
+var enumValue int32
+
+// Constants are declared and folded in the first pass (cxgo0), so their
+// values are already known when the function bodies are parsed. Every
+// expression in the first pass is folded if possible, and nil is used to
+// represent a value that is not known at compile time.
+
+func ConstantPrimary(typ int, byts []byte) *CXConstant {
+	return &CXConstant{Type: typ, Value: byts}
+}
+
+func ConstantIdentifier(ident string) *CXConstant {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	if cnst, err := pkg.GetConstant(ident); err == nil {
+		return cnst
+	}
+
+	return nil
+}
+
+// UntypedConstant makes the constant of a literal without a suffix, which
+// adopts the type of the typed constants it's used with. Untyped integers
+// and floating-point numbers are folded as i64 and f64 until they're
+// declared
+func UntypedConstant(cnst *CXConstant) *CXConstant {
+	var typ int
+	switch {
+	case isIntConstant(cnst):
+		typ = TYPE_I64
+	case isFloatConstant(cnst):
+		typ = TYPE_F64
+	default:
+		return cnst
+	}
+	untyped, err := untypedConstant(cnst, typ)
+	if err != nil {
+		return invalidConstant(err)
+	}
+	return untyped
+}
+
+func ConstantUnaryOperation(op string, cnst *CXConstant) *CXConstant {
+	if cnst == nil || cnst.Error != nil {
+		return cnst
+	}
+
+	var result *CXConstant
+	switch op {
+	case "+":
+		if isIntConstant(cnst) || isFloatConstant(cnst) {
+			return cnst
+		}
+	case "-":
+		if isIntConstant(cnst) {
+			val := constantInt(cnst)
+			if val == math.MinInt64 {
+				return overflowConstant(cnst.Type)
+			}
+			result = intConstant(cnst.Type, -val)
+		}
+		if isFloatConstant(cnst) {
+			result = makeFloatConstant(cnst.Type, -constantFloat(cnst))
+		}
+	case "!":
+		if cnst.Type == TYPE_BOOL {
+			return makeBoolConstant(!constantBool(cnst))
+		}
+	}
+
+	if result != nil && result.Error == nil {
+		result.IsUntyped = cnst.IsUntyped
+	}
+	return result
+}
+
+func ConstantOperation(left *CXConstant, right *CXConstant, op int) *CXConstant {
+	if left == nil || right == nil {
+		return nil
+	}
+	if left.Error != nil {
+		return left
+	}
+	if right.Error != nil {
+		return right
+	}
+
+	if op == OP_BITSHL || op == OP_BITSHR {
+		// the count of a shift can be of any integer type
+		return constantShift(left, right, op)
+	}
+
+	left, right, err := unifyConstants(left, right)
+	if err != nil {
+		return invalidConstant(err)
+	}
+
+	result := foldConstants(left, right, op)
+	if result != nil && result.Error == nil && result.Type != TYPE_BOOL {
+		result.IsUntyped = left.IsUntyped && right.IsUntyped
+	}
+	return result
+}
+
+// unifyConstants converts the operands of a binary operation to the same
+// type. An untyped operand is converted to the type of the other one, and
+// two untyped numbers are converted to f64 if any of them is a
+// floating-point number
+func unifyConstants(left *CXConstant, right *CXConstant) (*CXConstant, *CXConstant, error) {
+	var err error
+	switch {
+	case left.IsUntyped && right.IsUntyped && left.Type != right.Type:
+		typ := TYPE_F64
+		if isIntConstant(left) && isIntConstant(right) {
+			typ = TYPE_I64
+		}
+		if left, err = untypedConstant(left, typ); err != nil {
+			return nil, nil, err
+		}
+		if right, err = untypedConstant(right, typ); err != nil {
+			return nil, nil, err
+		}
+	case left.IsUntyped:
+		if left, err = ConvertConstant(left, right.Type); err != nil {
+			return nil, nil, err
+		}
+	case right.IsUntyped:
+		if right, err = ConvertConstant(right, left.Type); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if left.Type != right.Type {
+		return nil, nil, fmt.Errorf("mismatched types '%s' and '%s' in constant expression", TypeNames[left.Type], TypeNames[right.Type])
+	}
+	return left, right, nil
+}
+
+// foldConstants folds the binary operation `op` of two constants of the
+// same type
+func foldConstants(left *CXConstant, right *CXConstant, op int) *CXConstant {
+	switch {
+	case isIntConstant(left):
+		l, r := constantInt(left), constantInt(right)
+
+		switch op {
+		case OP_ADD:
+			sum := l + r
+			if (r > 0 && sum < l) || (r < 0 && sum > l) {
+				return overflowConstant(left.Type)
+			}
+			return intConstant(left.Type, sum)
+		case OP_SUB:
+			diff := l - r
+			if (r > 0 && diff > l) || (r < 0 && diff < l) {
+				return overflowConstant(left.Type)
+			}
+			return intConstant(left.Type, diff)
+		case OP_MUL:
+			prod := l * r
+			if l != 0 && (prod/l != r || (l == -1 && r == math.MinInt64)) {
+				return overflowConstant(left.Type)
+			}
+			return intConstant(left.Type, prod)
+		case OP_DIV:
+			if r == 0 {
+				return invalidConstant(fmt.Errorf("division by zero in constant expression"))
+			}
+			if l == math.MinInt64 && r == -1 {
+				return overflowConstant(left.Type)
+			}
+			return intConstant(left.Type, l/r)
+		case OP_MOD:
+			if r == 0 {
+				return invalidConstant(fmt.Errorf("division by zero in constant expression"))
+			}
+			if r == -1 {
+				return intConstant(left.Type, 0)
+			}
+			return intConstant(left.Type, l%r)
+		case OP_BITAND:
+			return makeIntConstant(left.Type, l&r)
+		case OP_BITOR:
+			return makeIntConstant(left.Type, l|r)
+		case OP_BITXOR:
+			return makeIntConstant(left.Type, l^r)
+		case OP_BITCLEAR:
+			return makeIntConstant(left.Type, l&^r)
+		case OP_EQUAL:
+			return makeBoolConstant(l == r)
+		case OP_UNEQUAL:
+			return makeBoolConstant(l != r)
+		case OP_LT:
+			return makeBoolConstant(l < r)
+		case OP_GT:
+			return makeBoolConstant(l > r)
+		case OP_LTEQ:
+			return makeBoolConstant(l <= r)
+		case OP_GTEQ:
+			return makeBoolConstant(l >= r)
+		}
+	case isFloatConstant(left):
+		l, r := constantFloat(left), constantFloat(right)
+
+		switch op {
+		case OP_ADD:
+			return floatConstant(left.Type, l+r)
+		case OP_SUB:
+			return floatConstant(left.Type, l-r)
+		case OP_MUL:
+			return floatConstant(left.Type, l*r)
+		case OP_DIV:
+			if r == 0 {
+				return invalidConstant(fmt.Errorf("division by zero in constant expression"))
+			}
+			return floatConstant(left.Type, l/r)
+		case OP_EQUAL:
+			return makeBoolConstant(l == r)
+		case OP_UNEQUAL:
+			return makeBoolConstant(l != r)
+		case OP_LT:
+			return makeBoolConstant(l < r)
+		case OP_GT:
+			return makeBoolConstant(l > r)
+		case OP_LTEQ:
+			return makeBoolConstant(l <= r)
+		case OP_GTEQ:
+			return makeBoolConstant(l >= r)
+		}
+	case left.Type == TYPE_STR:
+		l, r := constantStr(left), constantStr(right)
+
+		switch op {
+		case OP_ADD:
+			return makeStrConstant(l + r)
+		case OP_EQUAL:
+			return makeBoolConstant(l == r)
+		case OP_UNEQUAL:
+			return makeBoolConstant(l != r)
+		case OP_LT:
+			return makeBoolConstant(l < r)
+		case OP_GT:
+			return makeBoolConstant(l > r)
+		case OP_LTEQ:
+			return makeBoolConstant(l <= r)
+		case OP_GTEQ:
+			return makeBoolConstant(l >= r)
+		}
+	case left.Type == TYPE_BOOL:
+		l, r := constantBool(left), constantBool(right)
+
+		switch op {
+		case OP_EQUAL:
+			return makeBoolConstant(l == r)
+		case OP_UNEQUAL:
+			return makeBoolConstant(l != r)
+		case OP_LOGICAL_AND:
+			return makeBoolConstant(l && r)
+		case OP_LOGICAL_OR:
+			return makeBoolConstant(l || r)
+		}
+	}
+
+	return nil
+}
+
+// constantShift folds a shift, whose result has the type of `left`
+func constantShift(left *CXConstant, right *CXConstant, op int) *CXConstant {
+	if !isIntConstant(left) || !isIntConstant(right) {
+		return nil
+	}
+
+	l, r := constantInt(left), constantInt(right)
+	if r < 0 {
+		return invalidConstant(fmt.Errorf("negative shift count %d in constant expression", r))
+	}
+
+	var result *CXConstant
+	if op == OP_BITSHL {
+		if l != 0 && (r >= 63 || (l<<uint64(r))>>uint64(r) != l) {
+			return overflowConstant(left.Type)
+		}
+		result = intConstant(left.Type, l<<uint64(r))
+	} else {
+		result = makeIntConstant(left.Type, l>>uint64(r))
+	}
+
+	if result.Error == nil {
+		result.IsUntyped = left.IsUntyped
+	}
+	return result
+}
+
+// ConvertConstant converts a constant to the type `typ` of a typed constant
+// declaration. Numeric constants can be converted to any other numeric type
+// as long as no information is lost
+func ConvertConstant(cnst *CXConstant, typ int) (*CXConstant, error) {
+	if cnst.Type == typ {
+		return cnst, nil
+	}
+
+	var conv *CXConstant
+	switch {
+	case isIntConstant(cnst) && isIntConstant(&CXConstant{Type: typ}):
+		conv = intConstant(typ, constantInt(cnst))
+	case isIntConstant(cnst) && isFloatConstant(&CXConstant{Type: typ}):
+		conv = floatConstant(typ, float64(constantInt(cnst)))
+	case isFloatConstant(cnst) && isFloatConstant(&CXConstant{Type: typ}):
+		conv = floatConstant(typ, constantFloat(cnst))
+	case isFloatConstant(cnst) && isIntConstant(&CXConstant{Type: typ}):
+		val := constantFloat(cnst)
+		if val != math.Trunc(val) {
+			return nil, fmt.Errorf("constant %v truncated to '%s'", val, TypeNames[typ])
+		}
+		if val < math.MinInt64 || val >= math.MaxInt64 {
+			return nil, fmt.Errorf("constant %v overflows '%s'", val, TypeNames[typ])
+		}
+		conv = intConstant(typ, int64(val))
+	default:
+		return nil, fmt.Errorf("cannot use constant of type '%s' as type '%s'", TypeNames[cnst.Type], TypeNames[typ])
+	}
+
+	if conv.Error != nil {
+		return nil, conv.Error
+	}
+	return conv, nil
+}
+
+func DeclareConstant(ident string, typ int, value *CXConstant, currentFile string, lineNo int) {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := pkg.GetConstant(ident); err == nil {
+		CompilationError(currentFile, lineNo, DIAG_REDECLARED, fmt.Sprintf("constant '%s' redeclared", ident))
+		return
+	}
+
+	if value == nil {
+		CompilationError(currentFile, lineNo, DIAG_INVALID, fmt.Sprintf("initializer of constant '%s' is not a constant expression", ident))
+		return
+	}
+
+	if value.Error != nil {
+		CompilationError(currentFile, lineNo, DIAG_TYPE, value.Error.Error())
+		return
+	}
+
+	isUntyped := typ == TYPE_UNDEFINED && value.IsUntyped
+	if isUntyped {
+		// the functions use the constant with the type of its literals
+		typ = defaultConstantType(value)
+	}
+
+	if typ != TYPE_UNDEFINED {
+		var err error
+		if value, err = ConvertConstant(value, typ); err != nil {
+			CompilationError(currentFile, lineNo, DIAG_TYPE, err.Error())
+			return
+		}
+	}
+
+	pkg.AddConstant(&CXConstant{Name: ident, Type: value.Type, Value: value.Value, IsUntyped: isUntyped})
+}
+
+// DeclareEnumConstant declares the next constant of an enum. Enum constants
+// are of type i32 and, as in C, each of them is the previous one plus one
+// unless a value is provided
+func DeclareEnumConstant(ident string, value *CXConstant, currentFile string, lineNo int) {
+	if value != nil {
+		if value.Error != nil {
+			CompilationError(currentFile, lineNo, DIAG_TYPE, value.Error.Error())
+			return
+		}
+		cnst, err := ConvertConstant(value, TYPE_I32)
+		if err != nil {
+			CompilationError(currentFile, lineNo, DIAG_TYPE, err.Error())
+			return
+		}
+		enumValue = int32(constantInt(cnst))
+	}
+
+	DeclareConstant(ident, TYPE_I32, makeIntConstant(TYPE_I32, int64(enumValue)), currentFile, lineNo)
+	enumValue++
+}
+
+func EndEnumDeclaration() {
+	enumValue = 0
+}
+
+// ConstantArrayLength returns the length of an array whose length is given
+// by the constant `ident`, e.g. [N]i32
+func ConstantArrayLength(ident string) (int, error) {
+	cnst := ConstantIdentifier(ident)
+	if cnst == nil {
+		return 0, fmt.Errorf("array length '%s' is not a constant", ident)
+	}
+	if !isIntConstant(cnst) {
+		return 0, fmt.Errorf("array length '%s' must be an integer constant; its type is '%s'", ident, TypeNames[cnst.Type])
+	}
+
+	val := constantInt(cnst)
+	if val < 0 {
+		return 0, fmt.Errorf("array length '%s' is negative (%d)", ident, val)
+	}
+	if val > math.MaxInt32 {
+		return 0, fmt.Errorf("array length '%s' is too large (%d)", ident, val)
+	}
+	return int(val), nil
+}
+
+// ArrayLengthAt is like ConstantArrayLength, but the error is reported at
+// the span of the length
+func ArrayLengthAt(ident string, span Span) int {
+	length, err := ConstantArrayLength(ident)
+	if err != nil {
+		CompilationErrorAt(CurrentFile, LineNo, span, DIAG_TYPE, err.Error())
+	}
+	return length
+}
+
+// defaultConstantType returns the type of the literals that an untyped
+// constant is folded from
+func defaultConstantType(cnst *CXConstant) int {
+	switch {
+	case isIntConstant(cnst):
+		return TYPE_I32
+	case isFloatConstant(cnst):
+		return TYPE_F32
+	}
+	return cnst.Type
+}
+
+func untypedConstant(cnst *CXConstant, typ int) (*CXConstant, error) {
+	conv, err := ConvertConstant(cnst, typ)
+	if err != nil {
+		return nil, err
+	}
+	return &CXConstant{Type: conv.Type, Value: conv.Value, IsUntyped: true}, nil
+}
+
+func invalidConstant(err error) *CXConstant {
+	return &CXConstant{Type: TYPE_UNDEFINED, Error: err}
+}
+
+func overflowConstant(typ int) *CXConstant {
+	return invalidConstant(fmt.Errorf("constant expression overflows '%s'", TypeNames[typ]))
+}
+
+// intFits tells if `val` is in the range of the integer type `typ`
+func intFits(typ int, val int64) bool {
+	switch typ {
+	case TYPE_BYTE, TYPE_UI8:
+		return val >= 0 && val <= math.MaxUint8
+	case TYPE_I8:
+		return val >= math.MinInt8 && val <= math.MaxInt8
+	case TYPE_I16:
+		return val >= math.MinInt16 && val <= math.MaxInt16
+	case TYPE_UI16:
+		return val >= 0 && val <= math.MaxUint16
+	case TYPE_I32:
+		return val >= math.MinInt32 && val <= math.MaxInt32
+	case TYPE_UI32:
+		return val >= 0 && val <= math.MaxUint32
+	case TYPE_UI64:
+		return val >= 0
+	}
+	return true
+}
+
+// intConstant is like makeIntConstant, but the constant is invalid if `val`
+// overflows `typ`
+func intConstant(typ int, val int64) *CXConstant {
+	if !intFits(typ, val) {
+		return invalidConstant(fmt.Errorf("constant %d overflows '%s'", val, TypeNames[typ]))
+	}
+	return makeIntConstant(typ, val)
+}
+
+// floatConstant is like makeFloatConstant, but the constant is invalid if
+// `val` overflows `typ`
+func floatConstant(typ int, val float64) *CXConstant {
+	if math.IsInf(val, 0) || (typ == TYPE_F32 && math.Abs(val) > math.MaxFloat32) {
+		return invalidConstant(fmt.Errorf("constant %v overflows '%s'", val, TypeNames[typ]))
+	}
+	return makeFloatConstant(typ, val)
+}
+
+func isIntConstant(cnst *CXConstant) bool {
+	switch cnst.Type {
+	case TYPE_BYTE, TYPE_I8, TYPE_I16, TYPE_I32, TYPE_I64,
+		TYPE_UI8, TYPE_UI16, TYPE_UI32, TYPE_UI64:
+		return true
+	}
+	return false
+}
+
+func isFloatConstant(cnst *CXConstant) bool {
+	return cnst.Type == TYPE_F32 || cnst.Type == TYPE_F64
+}
+
+func constantInt(cnst *CXConstant) int64 {
+	switch cnst.Type {
+	case TYPE_BYTE, TYPE_UI8:
+		return int64(cnst.Value[0])
+	case TYPE_I8:
+		return int64(int8(cnst.Value[0]))
+	case TYPE_I16:
+		var val int16
+		encoder.DeserializeAtomic(cnst.Value, &val)
+		return int64(val)
+	case TYPE_UI16:
+		var val uint16
+		encoder.DeserializeAtomic(cnst.Value, &val)
+		return int64(val)
+	case TYPE_UI32:
+		var val uint32
+		encoder.DeserializeAtomic(cnst.Value, &val)
+		return int64(val)
+	case TYPE_UI64:
+		var val uint64
+		encoder.DeserializeAtomic(cnst.Value, &val)
+		return int64(val)
+	case TYPE_I32:
+		var val int32
+		encoder.DeserializeAtomic(cnst.Value, &val)
+		return int64(val)
+	case TYPE_I64:
+		var val int64
+		encoder.DeserializeAtomic(cnst.Value, &val)
+		return val
+	}
+	panic("constant is not an integer")
+}
+
+func constantFloat(cnst *CXConstant) float64 {
+	switch cnst.Type {
+	case TYPE_F32:
+		var val float32
+		encoder.DeserializeAtomic(cnst.Value, &val)
+		return float64(val)
+	case TYPE_F64:
+		var val float64
+		encoder.DeserializeAtomic(cnst.Value, &val)
+		return val
+	}
+	panic("constant is not a floating-point number")
+}
+
+func constantBool(cnst *CXConstant) bool {
+	return cnst.Value[0] != 0
+}
+
+func constantStr(cnst *CXConstant) string {
+	var val string
+	encoder.DeserializeRaw(cnst.Value, &val)
+	return val
+}
+
+func makeIntConstant(typ int, val int64) *CXConstant {
+	switch typ {
+	case TYPE_BYTE:
+		return &CXConstant{Type: typ, Value: encoder.Serialize(byte(val))}
+	case TYPE_I8:
+		return &CXConstant{Type: typ, Value: encoder.Serialize(int8(val))}
+	case TYPE_I16:
+		return &CXConstant{Type: typ, Value: encoder.Serialize(int16(val))}
+	case TYPE_I32:
+		return &CXConstant{Type: typ, Value: encoder.Serialize(int32(val))}
+	case TYPE_UI8:
+		return &CXConstant{Type: typ, Value: encoder.Serialize(uint8(val))}
+	case TYPE_UI16:
+		return &CXConstant{Type: typ, Value: encoder.Serialize(uint16(val))}
+	case TYPE_UI32:
+		return &CXConstant{Type: typ, Value: encoder.Serialize(uint32(val))}
+	case TYPE_UI64:
+		return &CXConstant{Type: typ, Value: encoder.Serialize(uint64(val))}
+	default:
+		return &CXConstant{Type: typ, Value: encoder.Serialize(val)}
+	}
+}
+
+func makeFloatConstant(typ int, val float64) *CXConstant {
+	if typ == TYPE_F32 {
+		return &CXConstant{Type: typ, Value: encoder.Serialize(float32(val))}
+	}
+	return &CXConstant{Type: typ, Value: encoder.Serialize(val)}
+}
+
+func makeBoolConstant(val bool) *CXConstant {
+	return &CXConstant{Type: TYPE_BOOL, Value: encoder.Serialize(val)}
+}
+
+func makeStrConstant(val string) *CXConstant {
+	return &CXConstant{Type: TYPE_STR, Value: encoder.Serialize(val)}
+}
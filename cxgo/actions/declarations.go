@@ -1,6 +1,7 @@
 package actions
 
 import (
+	"fmt"
 	. "github.com/skycoin/cx/cx"
 )
 
@@ -152,6 +153,98 @@ func DeclareStruct (ident string, strctFlds []*CXArgument) {
 	}
 }
 
+// DeclareInterface declares the interface `ident`. Its methods are declared
+// as functions without expressions whose receiver is the interface, so
+// they're found like the methods of structs
+func DeclareInterface (ident string, methods []*CXArgument) {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+	strct, err := PRGRM.GetStruct(ident, pkg.Name)
+	if err != nil {
+		panic(err)
+	}
+
+	strct.Fields = nil
+	strct.IsInterface = true
+	strct.Size = INTERFACE_SIZE
+
+	for _, method := range methods {
+		fnName := ident + "." + method.Name
+		fn, err := PRGRM.GetFunction(fnName, pkg.Name)
+		if err != nil {
+			fn = MakeFunction(fnName)
+			pkg.AddFunction(fn)
+		}
+
+		// the methods are declared again in the second pass. The parameters
+		// have no names, so they're not added with AddInput and AddOutput
+		rcvr := DeclarationSpecifiersStruct(ident, pkg.Name, false)
+		fn.Inputs = append([]*CXArgument{rcvr}, method.Inputs...)
+		fn.Outputs = append([]*CXArgument{}, method.Outputs...)
+	}
+}
+
+// DeclareNamedType declares the type `ident` with the values of the type
+// `declSpec`. A named type is distinct from its underlying type and has its
+// own methods, while an alias is just another name for `declSpec`
+func DeclareNamedType (ident string, declSpec *CXArgument, isAlias bool) {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+	strct, err := PRGRM.GetStruct(ident, pkg.Name)
+	if err != nil {
+		panic(err)
+	}
+
+	// the underlying type of a named type declared with another named type
+	// is the underlying type of the latter
+	declSpec.NamedType = nil
+
+	strct.Fields = nil
+	strct.Underlying = declSpec
+	strct.IsAlias = isAlias
+	strct.Size = declSpec.TotalSize
+}
+
+// CheckInterfaceMethods checks that the methods of the interface `ident`
+// have different names
+func CheckInterfaceMethods (ident string, methods []*CXArgument) {
+	declared := make(map[string]bool)
+	for _, method := range methods {
+		if declared[method.Name] {
+			CompilationErrorAt(method.FileName, method.FileLine, method.Span, DIAG_REDECLARED, fmt.Sprintf("duplicate method '%s' in interface '%s'", method.Name, ident))
+		}
+		declared[method.Name] = true
+	}
+}
+
+// CheckEmbeddedFields checks that the embedded fields of the struct `ident`
+// are structs
+func CheckEmbeddedFields (ident string, fields []*CXArgument) {
+	for _, fld := range fields {
+		if fld.IsEmbedded && (fld.CustomType == nil || fld.CustomType.IsInterface) {
+			CompilationErrorAt(fld.FileName, fld.FileLine, fld.Span, DIAG_TYPE, fmt.Sprintf("embedded field '%s' in struct '%s' is not a struct", fld.Name, ident))
+		}
+	}
+}
+
+// MethodSignature returns the signature of the method `ident` of an interface
+func MethodSignature (ident string, inputs, outputs []*CXArgument) *CXArgument {
+	sig := DeclarationSpecifiersFunc(inputs, outputs)
+	sig.Name = ident
+	return sig
+}
+
+// NamedTypeParameters reports the parameters or results of the code of
+// `span`, which belong to a function type or to a method of an interface,
+// being named, as only their types are listed there
+func NamedTypeParameters (span Span) {
+	CompilationErrorAt(CurrentFile, LineNo, span, DIAG_SYNTAX, "the parameters and results of function types and interface methods can't be named")
+}
+
 func DeclarePackage(ident string) {
 	if pkg, err := PRGRM.GetPackage(ident); err != nil {
 		pkg := MakePackage(ident)
@@ -165,6 +258,13 @@ func DeclarePackage(ident string) {
 
 func DeclareImport (ident string, currentFile string, lineNo int) {
 	if pkg, err := PRGRM.GetCurrentPackage(); err == nil {
+		if pkg.ImportDecls == nil {
+			pkg.ImportDecls = make(map[string]*CXImport)
+		}
+		if _, found := pkg.ImportDecls[ident]; !found {
+			pkg.ImportDecls[ident] = &CXImport{FileName: currentFile, FileLine: lineNo}
+		}
+
 		if _, err := pkg.GetImport(ident); err != nil {
 			
 			if imp, err := PRGRM.GetPackage(ident); err == nil {
@@ -181,7 +281,7 @@ func DeclareImport (ident string, currentFile string, lineNo int) {
 						AffordanceStructs(imp)
 					}
 				} else {
-					println(CompilationError(currentFile, lineNo), err.Error())
+					CompilationError(currentFile, lineNo, DIAG_UNDECLARED, err.Error())
 				}
 			}
 		}
@@ -190,6 +290,13 @@ func DeclareImport (ident string, currentFile string, lineNo int) {
 	}
 }
 
+// UseImport records that the code of `pkg` refers to its import `ident`
+func UseImport (pkg *CXPackage, ident string) {
+	if imp, found := pkg.ImportDecls[ident]; found {
+		imp.IsUsed = true
+	}
+}
+
 func DeclareLocal (declarator *CXArgument, declaration_specifiers *CXArgument, initializer []*CXExpression, doesInitialize bool) []*CXExpression {
 	if doesInitialize {
 		declaration_specifiers.IsLocalDeclaration = true
@@ -206,8 +313,10 @@ func DeclareLocal (declarator *CXArgument, declaration_specifiers *CXArgument, i
 
 				expr.AddOutput(declaration_specifiers)
 				expr.AddInput(initializer[len(initializer)-1].Outputs[0])
+				// e.g. string literals are assigned by reference
+				declaration_specifiers.PassBy = initializer[len(initializer)-1].Outputs[0].PassBy
 
-				return []*CXExpression{expr}
+				return append(localDeclaration(declaration_specifiers), expr)
 			} else {
 				// then it's an expression (it has an operator)
 				declaration_specifiers.Name = declarator.Name
@@ -220,7 +329,7 @@ func DeclareLocal (declarator *CXArgument, declaration_specifiers *CXArgument, i
 				// exprs := $5
 				// exprs = append(exprs, expr)
 
-				return initializer
+				return append(localDeclaration(declaration_specifiers), initializer...)
 			}
 		} else {
 			panic(err)
@@ -245,6 +354,61 @@ func DeclareLocal (declarator *CXArgument, declaration_specifiers *CXArgument, i
 	}
 }
 
+// the declarations made by `localDeclaration`
+var initDeclarations map[*CXExpression]bool = make(map[*CXExpression]bool)
+
+// localDeclaration declares the local variable initialized by the output
+// `declSpec`, so the variable exists before its initializer is run. This
+// is needed to escape the variables captured by function literals, and the
+// declaration is emptied by `uncaptureDeclarations` if it's not captured
+func localDeclaration (declSpec *CXArgument) []*CXExpression {
+	expr := MakeExpression(nil, declSpec.FileName, declSpec.FileLine)
+	expr.Package = declSpec.Package
+
+	decl := *declSpec
+	decl.DeclarationSpecifiers = append([]int{}, declSpec.DeclarationSpecifiers...)
+	expr.AddOutput(&decl)
+
+	initDeclarations[expr] = true
+
+	return []*CXExpression{expr}
+}
+
+// the spans of the innermost blocks declaring the local variables
+var declarationBlocks map[*CXArgument]Span = make(map[*CXArgument]Span)
+
+// DeclareInBlock records the block spanning `span` as the block of the
+// local variables declared by `exprs` whose block isn't known yet, i.e. the
+// ones that aren't declared by the blocks nested in it
+func DeclareInBlock (exprs []*CXExpression, span Span) {
+	for _, expr := range exprs {
+		if expr.Operator != nil || len(expr.Inputs) > 0 {
+			continue
+		}
+		for _, out := range expr.Outputs {
+			if _, found := declarationBlocks[out]; !found {
+				declarationBlocks[out] = span
+			}
+		}
+	}
+}
+
+// inSiblingBlock tells if the local variable `decl` is declared out of the
+// block of `prev`, which declares a variable with the same name. Then
+// `decl` is a new variable
+func inSiblingBlock (prev, decl *CXArgument) bool {
+	prevBlock, found := declarationBlocks[prev]
+	if !found {
+		// e.g. a parameter
+		return false
+	}
+	block, found := declarationBlocks[decl]
+	if !found || prev.FileName != decl.FileName {
+		return false
+	}
+	return block.Offset < prevBlock.Offset || block.EndOffset > prevBlock.EndOffset
+}
+
 func DeclarationSpecifiers(declSpec *CXArgument, arraySize int, opTyp int) *CXArgument {
 	switch opTyp {
 	case DECL_POINTER:
@@ -301,6 +465,94 @@ func DeclarationSpecifiers(declSpec *CXArgument, arraySize int, opTyp int) *CXAr
 	return nil
 }
 
+// DeclarationSpecifiersMap turns the declaration specifiers of the values
+// of a map into the declaration specifiers of the map. The keys of a map
+// can be of any basic type, and its values of any basic type or struct.
+// Pointers, arrays, slices, maps and channels have to be wrapped in a
+// struct to be stored in a map
+func DeclarationSpecifiersMap(keyTyp int, declSpec *CXArgument) *CXArgument {
+	if keyTyp == TYPE_AFF {
+		if !InFirstPass {
+			CompilationErrorAt(declSpec.FileName, declSpec.FileLine, declSpec.Span, DIAG_TYPE, fmt.Sprintf("invalid map key type '%s'", TypeNames[keyTyp]))
+		}
+		return declSpec
+	}
+	if declSpec.IsPointer || declSpec.IsArray || declSpec.IsSlice || declSpec.IsMap || declSpec.IsChan {
+		if !InFirstPass {
+			CompilationErrorAt(declSpec.FileName, declSpec.FileLine, declSpec.Span, DIAG_TYPE, "invalid map value type: pointers, arrays, slices, maps and channels have to be wrapped in a struct")
+		}
+		return declSpec
+	}
+
+	declSpec.DeclarationSpecifiers = append(declSpec.DeclarationSpecifiers, DECL_MAP)
+
+	arg := declSpec
+	arg.IsMap = true
+	arg.MapKeyType = keyTyp
+
+	// a map is a pointer to a heap object
+	arg.Size = TYPE_POINTER_SIZE
+	arg.TotalSize = TYPE_POINTER_SIZE
+
+	return arg
+}
+
+func DeclarationSpecifiersChan(declSpec *CXArgument) *CXArgument {
+	if declSpec.IsPointer || declSpec.IsArray || declSpec.IsSlice || declSpec.IsMap || declSpec.IsChan {
+		if !InFirstPass {
+			CompilationErrorAt(declSpec.FileName, declSpec.FileLine, declSpec.Span, DIAG_TYPE, "invalid channel element type")
+		}
+		return declSpec
+	}
+
+	declSpec.DeclarationSpecifiers = append(declSpec.DeclarationSpecifiers, DECL_CHAN)
+
+	arg := declSpec
+	arg.IsChan = true
+
+	// a channel is a pointer to a heap object
+	arg.Size = TYPE_POINTER_SIZE
+	arg.TotalSize = TYPE_POINTER_SIZE
+
+	return arg
+}
+
+// DeclarationSpecifiersFunc builds the type of the function values that
+// take `inputs` and return `outputs`
+func DeclarationSpecifiersFunc(inputs, outputs []*CXArgument) *CXArgument {
+	arg := MakeArgument("", CurrentFile, LineNo)
+	arg.AddType(TypeNames[TYPE_FUNC])
+	arg.Type = TYPE_FUNC
+	arg.Inputs = inputs
+	arg.Outputs = outputs
+
+	// a function value is a pointer to a closure
+	arg.Size = TYPE_POINTER_SIZE
+
+	return DeclarationSpecifiers(arg, 0, DECL_BASIC)
+}
+
+// VariadicParameter makes `declSpec` the type of a variadic parameter, which
+// receives the rest of the arguments of a call as a slice
+func VariadicParameter(declSpec *CXArgument) *CXArgument {
+	arg := DeclarationSpecifiers(declSpec, 0, DECL_SLICE)
+	arg.IsVariadic = true
+	return arg
+}
+
+// EmbeddedField makes `declSpec` the type of a field declared without a
+// name, which is named after its type. The fields and methods of embedded
+// structs are promoted to the struct declaring them
+func EmbeddedField(declSpec *CXArgument) *CXArgument {
+	if declSpec.CustomType == nil {
+		declSpec.Name = TypeNames[declSpec.Type]
+	} else {
+		declSpec.Name = declSpec.CustomType.Name
+	}
+	declSpec.IsEmbedded = true
+	return declSpec
+}
+
 func DeclarationSpecifiersBasic(typ int) *CXArgument {
 	arg := MakeArgument("", CurrentFile, LineNo)
 	arg.AddType(TypeNames[typ])
@@ -321,7 +573,12 @@ func DeclarationSpecifiersStruct (ident string, pkgName string, isExternal bool)
 		// custom type in an imported package
 		if pkg, err := PRGRM.GetCurrentPackage(); err == nil {
 			if imp, err := pkg.GetImport(pkgName); err == nil {
+				UseImport(pkg, pkgName)
 				if strct, err := PRGRM.GetStruct(ident, imp.Name); err == nil {
+					if strct.Underlying != nil {
+						return namedTypeSpecifiers(strct, pkg)
+					}
+
 					arg := MakeArgument("", CurrentFile, LineNo)
 					arg.Type = TYPE_CUSTOM
 					arg.CustomType = strct
@@ -333,7 +590,7 @@ func DeclarationSpecifiersStruct (ident string, pkgName string, isExternal bool)
 
 					return arg
 				} else {
-					println(CompilationError(CurrentFile, LineNo), err.Error())
+					CompilationError(CurrentFile, LineNo, DIAG_UNDECLARED, err.Error())
 					return nil
 				}
 			} else {
@@ -346,6 +603,10 @@ func DeclarationSpecifiersStruct (ident string, pkgName string, isExternal bool)
 		// custom type in the current package
 		if pkg, err := PRGRM.GetCurrentPackage(); err == nil {
 			if strct, err := PRGRM.GetStruct(ident, pkg.Name); err == nil {
+				if strct.Underlying != nil {
+					return namedTypeSpecifiers(strct, pkg)
+				}
+
 				arg := MakeArgument("", CurrentFile, LineNo)
 				arg.Type = TYPE_CUSTOM
 				arg.DeclarationSpecifiers = append(arg.DeclarationSpecifiers, DECL_STRUCT)
@@ -355,6 +616,9 @@ func DeclarationSpecifiersStruct (ident string, pkgName string, isExternal bool)
 				arg.Package = pkg
 
 				return arg
+			} else if ident == "error" {
+				// predeclared, unless a package declares its own `error` type
+				return DeclarationSpecifiersBasic(TYPE_STR)
 			} else {
 				panic("type '" + ident + "' does not exist")
 			}
@@ -363,3 +627,19 @@ func DeclarationSpecifiersStruct (ident string, pkgName string, isExternal bool)
 		}
 	}
 }
+
+// namedTypeSpecifiers builds the type of the values of the named type or
+// alias `strct`, which is a copy of its underlying type
+func namedTypeSpecifiers(strct *CXStruct, pkg *CXPackage) *CXArgument {
+	arg := *strct.Underlying
+	arg.ElementID = MakeElementID()
+	arg.FileName = CurrentFile
+	arg.FileLine = LineNo
+	arg.Package = pkg
+	arg.Lengths = append([]int{}, arg.Lengths...)
+	arg.DeclarationSpecifiers = append([]int{}, arg.DeclarationSpecifiers...)
+	if !strct.IsAlias {
+		arg.NamedType = strct
+	}
+	return &arg
+}
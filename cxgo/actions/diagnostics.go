@@ -0,0 +1,175 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	. "github.com/skycoin/cx/cx"
+)
+
+// severities of the diagnostics
+const (
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+)
+
+// Diagnostic is a message about the code of a program found while compiling
+// it. The columns are 0 when the code of the diagnostic is unknown, and the
+// end is the position after the last character of that code
+type Diagnostic struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"end_line"`
+	EndColumn int    `json:"end_column"`
+	Severity  string `json:"severity"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+}
+
+func (diag Diagnostic) String() string {
+	if diag.File == "" {
+		// the code typed in the REPL doesn't have a file
+		return diag.Severity + ": " + diag.Message
+	}
+	pos := diag.File + ":" + strconv.Itoa(diag.Line)
+	if diag.Column > 0 {
+		pos += ":" + strconv.Itoa(diag.Column)
+	}
+	return diag.Severity + ": " + pos + " " + diag.Message
+}
+
+func (diag Diagnostic) span() Span {
+	if diag.Column == 0 {
+		return Span{}
+	}
+	return Span{Line: diag.Line, Column: diag.Column, EndLine: diag.EndLine, EndColumn: diag.EndColumn}
+}
+
+// Diagnostics holds the diagnostics found since they were last reported
+var Diagnostics []Diagnostic
+
+// JSONDiagnostics makes ReportDiagnostics write a JSON array instead of one
+// diagnostic per line
+var JSONDiagnostics bool
+
+// CompilationError records an error found at a line of a file, so the
+// compilation fails once all the files are parsed
+func CompilationError (currentFile string, lineNo int, code string, msg string) {
+	CompilationErrorAt(currentFile, lineNo, Span{}, code, msg)
+}
+
+// CompilationErrorAt is like CompilationError, but it also records the span
+// of the code with the error. The line is only used if the span is unknown
+func CompilationErrorAt (currentFile string, lineNo int, span Span, code string, msg string) {
+	FoundCompileErrors = true
+	addDiagnostic(currentFile, lineNo, span, SEVERITY_ERROR, code, msg)
+}
+
+// CompilationWarning records a problem found in the code of a span that
+// doesn't stop the program from being compiled
+func CompilationWarning (currentFile string, lineNo int, span Span, code string, msg string) {
+	addDiagnostic(currentFile, lineNo, span, SEVERITY_WARNING, code, msg)
+}
+
+func addDiagnostic (currentFile string, lineNo int, span Span, severity string, code string, msg string) {
+	diag := Diagnostic{File: currentFile, Line: lineNo, Severity: severity, Code: code, Message: msg}
+	if span.IsValid() {
+		diag.Line, diag.Column = span.Line, span.Column
+		diag.EndLine, diag.EndColumn = span.EndLine, span.EndColumn
+	}
+
+	// some declarations are processed by both passes of the parser
+	for _, d := range Diagnostics {
+		if d == diag {
+			return
+		}
+	}
+	Diagnostics = append(Diagnostics, diag)
+}
+
+// RecoverCompilationError is deferred while parsing a file to report a
+// failure of the compiler as an error of that file. A failure after another
+// error is most likely caused by it, so it's not reported then
+func RecoverCompilationError (currentFile string) {
+	if r := recover(); r != nil {
+		if !FoundCompileErrors {
+			CompilationError(currentFile, LineNo, DIAG_INTERNAL, fmt.Sprintf("%v", r))
+		}
+		FoundCompileErrors = true
+	}
+}
+
+// RecoverExpressions is deferred by the actions building the expressions of
+// a statement. If one of them fails, the failure is reported like in
+// RecoverCompilationError, at the code of `given` if it's known, and
+// `result` is set to `given`, so the parser goes on with the rest of the
+// file instead of stopping at that statement
+func RecoverExpressions (result *[]*CXExpression, given []*CXExpression) {
+	if r := recover(); r != nil {
+		if !FoundCompileErrors && len(given) > 0 {
+			expr := given[len(given)-1]
+			CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_INTERNAL, fmt.Sprintf("%v", r))
+		} else if !FoundCompileErrors {
+			CompilationError(CurrentFile, LineNo, DIAG_INTERNAL, fmt.Sprintf("%v", r))
+		}
+		FoundCompileErrors = true
+		*result = given
+	}
+}
+
+// ReportDiagnostics writes the diagnostics found so far sorted by their
+// location, and then forgets them
+func ReportDiagnostics (w io.Writer) {
+	if len(Diagnostics) == 0 {
+		return
+	}
+
+	sort.SliceStable(Diagnostics, func(i, j int) bool {
+		a, b := Diagnostics[i], Diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	if JSONDiagnostics {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		enc.Encode(Diagnostics)
+	} else {
+		for _, diag := range Diagnostics {
+			fmt.Fprintln(w, diag)
+			if excerpt := SourceExcerpt(diag.File, diag.span()); excerpt != "" {
+				fmt.Fprintln(w, excerpt)
+			}
+		}
+	}
+
+	Diagnostics = nil
+}
+
+// CheckProgram records the mistakes that Check finds in a complete program
+// as diagnostics
+func CheckProgram (prgrm *CXProgram) {
+	for _, err := range prgrm.Check() {
+		if err.IsWarning {
+			CompilationWarning(err.FileName, err.FileLine, err.Span, err.Code, err.Message)
+		} else {
+			CompilationErrorAt(err.FileName, err.FileLine, err.Span, err.Code, err.Message)
+		}
+	}
+}
+
+// VetProgram records the suspicious code that Vet finds in a complete
+// program as warnings
+func VetProgram (prgrm *CXProgram) {
+	for _, err := range prgrm.Vet() {
+		CompilationWarning(err.FileName, err.FileLine, err.Span, err.Code, err.Message)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"github.com/skycoin/skycoin/src/cipher/encoder"
 )
 
-func IterationExpressions(init []*CXExpression, cond []*CXExpression, incr []*CXExpression, statements []*CXExpression) []*CXExpression {
+func IterationExpressions(init []*CXExpression, cond []*CXExpression, incr []*CXExpression, statements []*CXExpression) (result []*CXExpression) {
+	defer RecoverExpressions(&result, nil)
+
 	jmpFn := Natives[OP_JMP]
 
 	pkg, err := PRGRM.GetCurrentPackage()
@@ -28,6 +30,11 @@ func IterationExpressions(init []*CXExpression, cond []*CXExpression, incr []*CX
 	downExpr := MakeExpression(jmpFn, CurrentFile, LineNo)
 	downExpr.Package = pkg
 
+	if NoValue(cond) {
+		// the body is still parsed, but it can't be compiled
+		return append(append(init, cond...), append(statements, incr...)...)
+	}
+
 	if len(cond[len(cond)-1].Outputs) < 1 {
 		predicate := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo).AddType(TypeNames[cond[len(cond)-1].Operator.Outputs[0].Type])
 		predicate.Package = pkg
@@ -45,9 +52,11 @@ func IterationExpressions(init []*CXExpression, cond []*CXExpression, incr []*CX
 	elseLines := len(incr) + len(statements) + 1
 
 	// processing possible breaks
+	// once processed, they're unmarked so enclosing statements don't process them again
 	for i, stat := range statements {
 		if stat.IsBreak {
 			stat.ThenLines = elseLines - i - 1
+			stat.IsBreak = false
 		}
 	}
 
@@ -55,6 +64,7 @@ func IterationExpressions(init []*CXExpression, cond []*CXExpression, incr []*CX
 	for i, stat := range statements {
 		if stat.IsContinue {
 			stat.ThenLines = len(statements) - i - 1
+			stat.IsContinue = false
 		}
 	}
 
@@ -99,7 +109,9 @@ func ContinueExpressions () []*CXExpression {
 	return exprs
 }
 
-func SelectionExpressions (condExprs []*CXExpression, thenExprs []*CXExpression, elseExprs []*CXExpression) []*CXExpression {
+func SelectionExpressions (condExprs []*CXExpression, thenExprs []*CXExpression, elseExprs []*CXExpression) (result []*CXExpression) {
+	defer RecoverExpressions(&result, nil)
+
 	jmpFn := Natives[OP_JMP]
 	pkg, err := PRGRM.GetCurrentPackage()
 	if err != nil {
@@ -108,6 +120,11 @@ func SelectionExpressions (condExprs []*CXExpression, thenExprs []*CXExpression,
 	ifExpr := MakeExpression(jmpFn, CurrentFile, LineNo)
 	ifExpr.Package = pkg
 
+	if NoValue(condExprs) {
+		// the branches are still parsed, but they can't be compiled
+		return append(condExprs, append(thenExprs, elseExprs...)...)
+	}
+
 	var predicate *CXArgument
 	if condExprs[len(condExprs)-1].Operator == nil && !condExprs[len(condExprs)-1].IsMethodCall {
 		// then it's a literal
@@ -159,14 +176,45 @@ func SelectionExpressions (condExprs []*CXExpression, thenExprs []*CXExpression,
 	return exprs
 }
 
+// NoValue reports the last of `exprs` being used as a value if it's a call
+// to a function without outputs, e.g. `printf` in `1 + printf("a")`, which
+// is then kept as a statement of its own
+func NoValue (exprs []*CXExpression) bool {
+	expr := exprs[len(exprs)-1]
+	if expr.Operator == nil || len(expr.Outputs) > 0 || len(expr.Operator.Outputs) > 0 {
+		return false
+	}
+
+	CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_TYPE, "'" + ExprOpName(expr) + "' doesn't return a value to be used")
+	return true
+}
+
+// the type of the temporary variable holding the output of the native
+// call `expr`, which is an operand. Natives without inputs, such as
+// `recover`, have outputs of a known type
+func operandType (expr *CXExpression) int {
+	if len(expr.Inputs) == 0 {
+		return expr.Operator.Outputs[0].Type
+	}
+	return expr.Inputs[0].Type
+}
+
 func UndefinedTypeOperation (leftExprs []*CXExpression, rightExprs []*CXExpression, operator *CXFunction) (out []*CXExpression) {
+	defer RecoverExpressions(&out, leftExprs)
+
 	pkg, err := PRGRM.GetCurrentPackage()
 	if err != nil {
 		panic(err)
 	}
 
+	if leftNoValue, rightNoValue := NoValue(leftExprs), NoValue(rightExprs); leftNoValue {
+		return leftExprs
+	} else if rightNoValue {
+		return rightExprs
+	}
+
 	if len(leftExprs[len(leftExprs)-1].Outputs) < 1 {
-		name := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo).AddType(TypeNames[leftExprs[len(leftExprs)-1].Inputs[0].Type])
+		name := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo).AddType(TypeNames[operandType(leftExprs[len(leftExprs)-1])])
 		
 		name.Size = leftExprs[len(leftExprs)-1].Operator.Outputs[0].Size
 		name.TotalSize = leftExprs[len(leftExprs)-1].Operator.Outputs[0].Size
@@ -179,7 +227,7 @@ func UndefinedTypeOperation (leftExprs []*CXExpression, rightExprs []*CXExpressi
 	}
 
 	if len(rightExprs[len(rightExprs)-1].Outputs) < 1 {
-		name := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo).AddType(TypeNames[rightExprs[len(rightExprs)-1].Inputs[0].Type])
+		name := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo).AddType(TypeNames[operandType(rightExprs[len(rightExprs)-1])])
 
 		name.Size = rightExprs[len(rightExprs)-1].Operator.Outputs[0].Size
 		name.TotalSize = rightExprs[len(rightExprs)-1].Operator.Outputs[0].Size
@@ -195,7 +243,11 @@ func UndefinedTypeOperation (leftExprs []*CXExpression, rightExprs []*CXExpressi
 	expr.IsUndType = true
 	expr.Package = pkg
 
-	if len(leftExprs[len(leftExprs)-1].Outputs[0].Indexes) > 0 || leftExprs[len(leftExprs)-1].Operator != nil {
+	if leftExprs[len(leftExprs)-1].Operator == nil && leftExprs[len(leftExprs)-1].IsMethodCall {
+		// then it's a method call
+		expr.AddInput(MethodCallOutput(leftExprs[len(leftExprs)-1]))
+		out = append(out, leftExprs...)
+	} else if len(leftExprs[len(leftExprs)-1].Outputs[0].Indexes) > 0 || leftExprs[len(leftExprs)-1].Operator != nil {
 		// then it's a function call or an array access
 		expr.AddInput(leftExprs[len(leftExprs)-1].Outputs[0])
 		
@@ -208,7 +260,11 @@ func UndefinedTypeOperation (leftExprs []*CXExpression, rightExprs []*CXExpressi
 		expr.Inputs = append(expr.Inputs, leftExprs[len(leftExprs)-1].Outputs[0])
 	}
 	
-	if len(rightExprs[len(rightExprs)-1].Outputs[0].Indexes) > 0 || rightExprs[len(rightExprs)-1].Operator != nil {
+	if rightExprs[len(rightExprs)-1].Operator == nil && rightExprs[len(rightExprs)-1].IsMethodCall {
+		// then it's a method call
+		expr.AddInput(MethodCallOutput(rightExprs[len(rightExprs)-1]))
+		out = append(out, rightExprs...)
+	} else if len(rightExprs[len(rightExprs)-1].Outputs[0].Indexes) > 0 || rightExprs[len(rightExprs)-1].Operator != nil {
 		// then it's a function call or an array access
 		expr.AddInput(rightExprs[len(rightExprs)-1].Outputs[0])
 		
@@ -268,7 +324,13 @@ func ShorthandExpression(leftExprs []*CXExpression, rightExprs []*CXExpression,
 	return UndefinedTypeOperation(leftExprs, rightExprs, operator)
 }
 
-func UnaryExpression(op string, prevExprs []*CXExpression) []*CXExpression {
+func UnaryExpression(op string, prevExprs []*CXExpression) (result []*CXExpression) {
+	defer RecoverExpressions(&result, prevExprs)
+
+	if NoValue(prevExprs) {
+		return prevExprs
+	}
+
 	exprOut := prevExprs[len(prevExprs)-1].Outputs[0]
 	// exprInp := prevExprs[len(prevExprs)-1].Inputs[0]
 	switch op {
@@ -296,3 +358,139 @@ func UnaryExpression(op string, prevExprs []*CXExpression) []*CXExpression {
 	}
 	return prevExprs
 }
+
+// SendExpressions sends the value of `valExprs` to the channel `chanExprs`
+func SendExpressions (chanExprs []*CXExpression, valExprs []*CXExpression) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	// the parser could be on the next line already
+	expr := MakeExpression(Natives[OP_SEND], CurrentFile, chanExprs[len(chanExprs) - 1].FileLine)
+	expr.Package = pkg
+
+	return FunctionCall([]*CXExpression{expr}, append(chanExprs, valExprs...))
+}
+
+// ReceiveExpression receives a value from the channel `chanExprs`. The type
+// of its output is known once the channel's type is known, see
+// ProcessChanOperation
+func ReceiveExpression (chanExprs []*CXExpression) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	expr := MakeExpression(Natives[OP_RECV], CurrentFile, chanExprs[len(chanExprs) - 1].FileLine)
+	expr.Package = pkg
+
+	return FunctionCall([]*CXExpression{expr}, chanExprs)
+}
+
+// RangeExpressions lowers `for key, value := range rangeExprs {...}` to a
+// C-style loop over the positions of the entries of a map, the elements of
+// an array or a slice, or the runes of a string. The bytes of a string are
+// ranged over by converting it first, e.g. `range []byte(str)`. The parser
+// can't tell them apart, so the key and the value are read as the ones of a
+// map entry until the type being ranged over is known (see ProcessRangeEntry)
+func RangeExpressions (key []*CXExpression, value []*CXExpression, rangeExprs []*CXExpression, statements []*CXExpression) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	rangeExpr := rangeExprs[len(rangeExprs) - 1]
+	// string literals can be ranged over too
+	if rangeExpr.Operator == nil && (len(rangeExpr.Outputs) < 1 || (rangeExpr.Outputs[0].Name == "" && rangeExpr.Outputs[0].Type != TYPE_STR)) {
+		CompilationError(CurrentFile, LineNo, DIAG_INVALID, "range expression must be a variable or an operation")
+		return nil
+	}
+
+	// the value ranged over, e.g. a variable or []byte(str), is evaluated
+	// once and ranged over through a copy, so assigning to the variable in
+	// the loop doesn't change what's ranged over
+	valName := MakeGenSym(LOCAL_PREFIX)
+	init := Assignment(PrimaryIdentifier(valName), ":=", rangeExprs)
+	rangeArg := PrimaryIdentifier(valName)[0].Outputs[0]
+
+	idxName := MakeGenSym(LOCAL_PREFIX)
+	// the number of entries when the loop starts, so appending in the loop
+	// doesn't make it range over the appended elements
+	lenName := MakeGenSym(LOCAL_PREFIX)
+	// the position of the next entry, and the rune at idxName if it's a string
+	nextName := MakeGenSym(LOCAL_PREFIX)
+	runeName := MakeGenSym(LOCAL_PREFIX)
+
+	lenExpr := MakeExpression(Natives[OP_RANGE_LEN], CurrentFile, LineNo)
+	lenExpr.Package = pkg
+	lenExpr.AddInput(copyRangeArgument(rangeArg, -1, ""))
+
+	init = append(init, Assignment(PrimaryIdentifier(idxName), ":=", WritePrimary(TYPE_I32, encoder.SerializeAtomic(int32(0)), false))...)
+	init = append(init, Assignment(PrimaryIdentifier(lenName), ":=", []*CXExpression{lenExpr})...)
+	init = append(init, DeclareLocal(MakeArgument(nextName, CurrentFile, LineNo), DeclarationSpecifiersBasic(TYPE_I32), nil, false)...)
+	init = append(init, DeclareLocal(MakeArgument(runeName, CurrentFile, LineNo), DeclarationSpecifiersBasic(TYPE_I32), nil, false)...)
+
+	// the condition skips the deleted entries of a map, and finds the next
+	// position before running the statements, so continuing jumps to it
+	nextExpr := MakeExpression(Natives[OP_RANGE_NEXT], CurrentFile, LineNo)
+	nextExpr.Package = pkg
+	nextExpr.AddInput(copyRangeArgument(rangeArg, -1, ""))
+	nextExpr.AddInput(PrimaryIdentifier(idxName)[0].Outputs[0])
+	nextExpr.AddOutput(PrimaryIdentifier(idxName)[0].Outputs[0])
+	nextExpr.AddOutput(PrimaryIdentifier(nextName)[0].Outputs[0])
+	nextExpr.AddOutput(PrimaryIdentifier(runeName)[0].Outputs[0])
+
+	cond := append([]*CXExpression{nextExpr}, UndefinedTypeOperation(PrimaryIdentifier(idxName), PrimaryIdentifier(lenName), Natives[OP_UND_LT])...)
+
+	incr := Assignment(PrimaryIdentifier(idxName), "=", PrimaryIdentifier(nextName))
+
+	var body []*CXExpression
+	if key != nil && key[len(key) - 1].Outputs[0].Name != "_" {
+		from := MakeExpression(nil, CurrentFile, LineNo)
+		from.Package = pkg
+		from.AddOutput(copyRangeArgument(rangeArg, DEREF_MAP_KEY, idxName))
+
+		body = append(body, Assignment(key, ":=", []*CXExpression{from})...)
+	}
+	if value != nil && value[len(value) - 1].Outputs[0].Name != "_" {
+		val := copyRangeArgument(rangeArg, DEREF_MAP_VALUE, idxName)
+		val.RangeRune = PrimaryIdentifier(runeName)[0].Outputs[0]
+
+		from := MakeExpression(nil, CurrentFile, LineNo)
+		from.Package = pkg
+		from.AddOutput(val)
+
+		body = append(body, Assignment(value, ":=", []*CXExpression{from})...)
+	}
+
+	return IterationExpressions(init, cond, incr, append(body, statements...))
+}
+
+// copyRangeArgument copies the argument being ranged over. If `derefOp` is
+// not negative, the copy accesses the entry at the index stored in `idxName`
+func copyRangeArgument (arg *CXArgument, derefOp int, idxName string) *CXArgument {
+	cpy := *arg
+	cpy.Fields = nil
+	cpy.DereferenceOperations = append([]int{}, arg.DereferenceOperations...)
+	for _, fld := range arg.Fields {
+		fldCpy := *fld
+		fldCpy.DereferenceOperations = append([]int{}, fld.DereferenceOperations...)
+		cpy.Fields = append(cpy.Fields, &fldCpy)
+	}
+
+	if derefOp >= 0 {
+		elt := &cpy
+		if len(cpy.Fields) > 0 {
+			elt = cpy.Fields[len(cpy.Fields) - 1]
+		}
+
+		elt.DereferenceOperations = append(elt.DereferenceOperations, derefOp)
+		elt.Indexes = append(elt.Indexes, PrimaryIdentifier(idxName)[0].Outputs[0])
+
+		// string literals are passed by reference, but not their entries
+		cpy.PassBy = PASSBY_VALUE
+	}
+
+	return &cpy
+}
@@ -2,6 +2,9 @@ package actions
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
 	. "github.com/skycoin/cx/cx"
 )
 
@@ -11,10 +14,23 @@ func FunctionHeader (ident string, receiver []*CXArgument, isMethod bool) *CXFun
 			panic("method has multiple receivers")
 		}
 		if pkg, err := PRGRM.GetCurrentPackage(); err == nil {
-			fnName := receiver[0].CustomType.Name + "." + ident
+			rcvrType := ReceiverType(receiver[0])
+			if rcvrType == nil {
+				CompilationError(CurrentFile, LineNo, DIAG_TYPE, fmt.Sprintf("invalid receiver type '%s'", TypeNames[receiver[0].Type]))
+				return MakeFunction(ident)
+			}
+
+			fnName := rcvrType.Name + "." + ident
+
+			if rcvrType.IsInterface {
+				CompilationError(CurrentFile, LineNo, DIAG_TYPE, fmt.Sprintf("invalid receiver type '%s' (it's an interface)", rcvrType.Name))
+				// it's not added to the package, so it doesn't replace the interface's method
+				return MakeFunction(fnName)
+			}
 
 			if fn, err := PRGRM.GetFunction(fnName, pkg.Name); err == nil {
 				fn.AddInput(receiver[0])
+				pkg.CurrentFunction = fn
 				return fn
 			} else {
 				fn := MakeFunction(fnName)
@@ -28,6 +44,7 @@ func FunctionHeader (ident string, receiver []*CXArgument, isMethod bool) *CXFun
 	} else {
 		if pkg, err := PRGRM.GetCurrentPackage(); err == nil {
 			if fn, err := PRGRM.GetFunction(ident, pkg.Name); err == nil {
+				pkg.CurrentFunction = fn
 				return fn
 			} else {
 				fn := MakeFunction(ident)
@@ -69,9 +86,8 @@ func FunctionAddParameters (fn *CXFunction, inputs, outputs []*CXArgument) {
 }
 
 func FunctionDeclaration (fn *CXFunction, inputs, outputs []*CXArgument, exprs []*CXExpression) {
-	if FoundCompileErrors {
-		return
-	}
+	// the errors of the following functions are reported too
+	defer RecoverCompilationError(CurrentFile)
 
 	FunctionAddParameters(fn, inputs, outputs)
 
@@ -86,17 +102,31 @@ func FunctionDeclaration (fn *CXFunction, inputs, outputs []*CXArgument, exprs [
 	var symbols map[string]*CXArgument = make(map[string]*CXArgument, 0)
 	var symbolsScope map[string]bool = make(map[string]bool, 0)
 
+	scope := pushScope(fn, &symbols, &offset)
+	captured := capturedVariables(fn)
+	uncaptureDeclarations(fn, captured)
+
 	FunctionProcessParameters(&symbols, &symbolsScope, &offset, fn, fn.Inputs)
 	FunctionProcessParameters(&symbols, &symbolsScope, &offset, fn, fn.Outputs)
 
+	escapes := append(captureParameters(scope, captured), captureOutputs(scope, captured)...)
+	scope.captured = captured
+
 	for i, expr := range fn.Expressions {
 		// ProcessShortDeclaration(expr)
+
+		declareFunctionLiteral(expr)
 		
 		ProcessMethodCall(expr, &symbols, &offset, true)
 		ProcessExpressionArguments(&symbols, &symbolsScope, &offset, fn, expr.Inputs, expr, true)
+		ProcessPointerReceiver(expr)
+		ProcessChanOperation(expr)
+		ProcessFuncCall(expr)
+		ProcessSliceExpression(expr)
 		ProcessExpressionArguments(&symbols, &symbolsScope, &offset, fn, expr.Outputs, expr, false)
 
 		ProcessPointerStructs(expr)
+		ProcessUntypedConstants(expr)
 		
 		SetCorrectArithmeticOp(expr)
 		ProcessTempVariable(expr)
@@ -104,18 +134,59 @@ func FunctionDeclaration (fn *CXFunction, inputs, outputs []*CXArgument, exprs [
 		ProcessStringAssignment(expr)
 
 		// process short declaration
-		if len(expr.Outputs) > 0 && len(expr.Inputs) > 0 && expr.Outputs[0].IsShortDeclaration && !expr.IsStructLiteral {
-			fn.Expressions[i - 1].Outputs[0].Type = fn.Expressions[i].Inputs[0].Type
-			fn.Expressions[i].Outputs[0].Type = fn.Expressions[i].Inputs[0].Type
+		// (the symbols declared with the outputs of function calls already have their types)
+		if len(expr.Outputs) > 0 && len(expr.Inputs) > 0 && expr.Outputs[0].IsShortDeclaration && !expr.IsStructLiteral && !expr.IsConversion && expr.Operator.IsNative {
+			typ := expr.Inputs[0].Type
+			if len(expr.Operator.Outputs) > 0 && expr.Operator.Outputs[0].Type != TYPE_UNDEFINED {
+				// then the native returns a value of its own type, e.g. len
+				typ = expr.Operator.Outputs[0].Type
+			}
+			fn.Expressions[i - 1].Outputs[0].Type = typ
+			fn.Expressions[i].Outputs[0].Type = typ
+
+			ProcessShortDeclaration(&symbols, &offset, expr)
 		}
 
 		CheckTypes(expr)
 	}
 
+	captureDeclarations(scope)
+	fn.Expressions = append(escapes, fn.Expressions...)
+	fn.Length = len(fn.Expressions)
+	popScope()
+
+	AddLocalPointers(fn, symbols)
+
 	fn.Size = offset
+
+	// function values and captured variables use the data segment too
+	PRGRM.HeapStartsAt = DataOffset
 }
 
-func FunctionCall (exprs []*CXExpression, args []*CXExpression) []*CXExpression {
+// AddLocalPointers adds the local variables that can hold references to
+// heap objects as roots of `fn`, in the order they are in its frame
+func AddLocalPointers(fn *CXFunction, symbols map[string]*CXArgument) {
+	var locals []*CXArgument
+	for _, sym := range symbols {
+		// globals are roots on their own, and they're stored after
+		// the stack, whatever its configured size
+		if sym.Offset < PRGRM.StackSize {
+			locals = append(locals, sym)
+		}
+	}
+
+	sort.Slice(locals, func(i, j int) bool {
+		return locals[i].Offset < locals[j].Offset
+	})
+
+	for _, sym := range locals {
+		AddPointer(fn, sym)
+	}
+}
+
+func FunctionCall (exprs []*CXExpression, args []*CXExpression) (result []*CXExpression) {
+	defer RecoverExpressions(&result, exprs)
+
 	expr := exprs[len(exprs)-1]
 	
 	if expr.Operator == nil {
@@ -125,9 +196,11 @@ func FunctionCall (exprs []*CXExpression, args []*CXExpression) []*CXExpression
 		if op, err := PRGRM.GetFunction(opName, opPkg.Name); err == nil {
 			expr.Operator = op
 		} else if expr.Outputs[0].Fields == nil {
-			// then it's not a possible method call
-			println(CompilationError(CurrentFile, LineNo), err.Error())
-			return nil
+			// then it's not a possible method call, but it can be a
+			// call through a function value
+			expr.Operator = Natives[OP_CALL]
+			expr.Inputs = expr.Outputs
+			expr.Outputs = nil
 		} else {
 			expr.IsMethodCall = true
 		}
@@ -139,11 +212,22 @@ func FunctionCall (exprs []*CXExpression, args []*CXExpression) []*CXExpression
 
 	var nestedExprs []*CXExpression
 	for _, inpExpr := range args {
-		if inpExpr.Operator == nil {
+		if inpExpr.Operator == nil && inpExpr.IsMethodCall {
+			if out := MethodCallOutput(inpExpr); !isUsedAsInput(out, args) {
+				// then it's not part of another argument, e.g. `f(g(r.Area()))`
+				expr.AddInput(out)
+			}
+			nestedExprs = append(nestedExprs, inpExpr)
+		} else if inpExpr.Operator == nil {
 			// then it's a literal
 			expr.AddInput(inpExpr.Outputs[0])
 		} else {
 			// then it's a function call
+			if NoValue([]*CXExpression{inpExpr}) {
+				// the call can't be compiled, and the argument
+				// is reported again if the call is an operand
+				return []*CXExpression{inpExpr}
+			}
 			if len(inpExpr.Outputs) < 1 {
 				var out *CXArgument
 
@@ -175,6 +259,9 @@ func FunctionCall (exprs []*CXExpression, args []*CXExpression) []*CXExpression
 					}
 
 					out.Type = inpExpr.Operator.Outputs[0].Type
+					out.DeclarationSpecifiers = inpExpr.Operator.Outputs[0].DeclarationSpecifiers
+					out.Inputs = inpExpr.Operator.Outputs[0].Inputs
+					out.Outputs = inpExpr.Operator.Outputs[0].Outputs
 					out.PreviouslyDeclared = true
 				}
 
@@ -185,14 +272,210 @@ func FunctionCall (exprs []*CXExpression, args []*CXExpression) []*CXExpression
 			if len(inpExpr.Outputs) > 0 && inpExpr.IsArrayLiteral {
 				expr.AddInput(inpExpr.Outputs[0])
 			}
+			if len(inpExpr.Outputs) > 0 && inpExpr.IsConversion && !isUsedAsInput(inpExpr.Outputs[0], args) {
+				// then it's a conversion, unless it's part of another argument
+				expr.AddInput(inpExpr.Outputs[0])
+			}
 			nestedExprs = append(nestedExprs, inpExpr)
 
 		}
 	}
+
+	if expr.Operator == Natives[OP_PANIC] && len(expr.Inputs) == 1 {
+		// `panic(value)` raises a panic, while `panic(a, b, message)`
+		// asserts that `a` and `b` are equal
+		expr.Operator = Natives[OP_PANIC_VALUE]
+	}
+
+	functionCalls[expr] = true
 	
 	return append(nestedExprs, exprs...)
 }
 
+// isUsedAsInput checks if `arg` is an input of any of `exprs`
+func isUsedAsInput (arg *CXArgument, exprs []*CXExpression) bool {
+	for _, expr := range exprs {
+		for _, inp := range expr.Inputs {
+			if inp == arg {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MethodCallOutput makes the receiver of the nested method call `expr` its
+// first input, and returns the temporary variable holding its output, which
+// adopts the type of the method's output when the method is found (see
+// ProcessMethodCall)
+func MethodCallOutput (expr *CXExpression) *CXArgument {
+	if IsTempVar(expr.Outputs[0].Name) {
+		// then it already has an output
+		return expr.Outputs[0]
+	}
+
+	out := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, expr.FileLine).AddType(TypeNames[TYPE_UNDEFINED])
+	out.Package = expr.Package
+	out.PreviouslyDeclared = true
+
+	expr.Inputs = append(expr.Outputs, expr.Inputs...)
+	expr.Outputs = []*CXArgument{out}
+
+	return out
+}
+
+// valueExpressions groups a list of expressions, such as the one
+// built by argument_expression_list, by the value each of them produces
+func valueExpressions (exprs []*CXExpression) [][]*CXExpression {
+	var values [][]*CXExpression
+	var nestedExprs []*CXExpression
+	for _, expr := range exprs {
+		nestedExprs = append(nestedExprs, expr)
+		if expr.Operator == nil || len(expr.Outputs) < 1 {
+			values = append(values, nestedExprs)
+			nestedExprs = nil
+		}
+	}
+	return values
+}
+
+func ReturnExpressions (retExprs []*CXExpression) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+	fn, err := pkg.GetCurrentFunction()
+	if err != nil {
+		panic(err)
+	}
+
+	values := valueExpressions(retExprs)
+
+	var exprs []*CXExpression
+
+	if len(values) == 1 && len(fn.Outputs) > 1 {
+		// then it can be a function call returning all of fn's outputs, e.g. return foo()
+		expr := values[0][len(values[0]) - 1]
+		if expr.Operator != nil && !expr.Operator.IsNative && len(expr.Operator.Outputs) == len(fn.Outputs) {
+			for _, out := range fn.Outputs {
+				expr.AddOutput(PrimaryIdentifier(out.Name)[0].Outputs[0])
+			}
+			exprs = append(exprs, values[0]...)
+			values = nil
+		}
+	}
+
+	if values != nil && len(values) != len(fn.Outputs) {
+		var plural1 string
+		var plural2 string = "s"
+		var plural3 string = "were"
+		if len(fn.Outputs) != 1 {
+			plural1 = "s"
+		}
+		if len(values) == 1 {
+			plural2 = ""
+			plural3 = "was"
+		}
+		CompilationErrorAt(CurrentFile, retExprs[0].FileLine, retExprs[0].Span, DIAG_ARGUMENTS, fmt.Sprintf("function '%s' expects to return %d output%s, but %d value%s %s provided", fn.Name, len(fn.Outputs), plural1, len(values), plural2, plural3))
+		return nil
+	}
+
+	for i, value := range values {
+		expr := value[len(value) - 1]
+		if expr.Operator != nil && !expr.Operator.IsNative && len(expr.Operator.Outputs) > 0 {
+			// user-defined functions' outputs are already known; natives and
+			// identifiers are checked by CheckTypes once their types are resolved
+			expectedType := TypeNames[fn.Outputs[i].Type]
+			if fn.Outputs[i].CustomType != nil {
+				expectedType = fn.Outputs[i].CustomType.Name
+			}
+			receivedType := TypeNames[expr.Operator.Outputs[0].Type]
+			if expr.Operator.Outputs[0].CustomType != nil {
+				receivedType = expr.Operator.Outputs[0].CustomType.Name
+			}
+			if receivedType != expectedType {
+				CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_TYPE, fmt.Sprintf("function '%s' expects to return '%s' as its output %d, but '%s' returns '%s'", fn.Name, expectedType, i + 1, expr.Operator.Name, receivedType))
+			}
+		}
+
+		to := PrimaryIdentifier(fn.Outputs[i].Name)
+		to[0].Outputs[0].FileLine = expr.FileLine
+
+		exprs = append(exprs, Assignment(to, "=", value)...)
+	}
+
+	expr := MakeExpression(Natives[OP_JMP], CurrentFile, LineNo)
+
+	// simulating a label so it gets executed without evaluating a predicate
+	expr.Label = MakeGenSym(LABEL_PREFIX)
+	expr.ThenLines = MAX_INT32
+	expr.Package = pkg
+
+	arg := MakeArgument("", CurrentFile, LineNo).AddType("bool")
+	arg.Package = pkg
+
+	expr.AddInput(arg)
+
+	return append(exprs, expr)
+}
+
+// GoExpressions marks the function call at the end of `exprs` to be run in a
+// new thread. Its arguments are still evaluated by the calling thread
+func GoExpressions (exprs []*CXExpression) []*CXExpression {
+	if len(exprs) == 0 {
+		return nil
+	}
+
+	expr := exprs[len(exprs) - 1]
+	if expr.Operator == nil && !expr.IsMethodCall {
+		CompilationErrorAt(CurrentFile, expr.FileLine, expr.Span, DIAG_INVALID, "expression in go statement must be a function call")
+		return nil
+	}
+	if expr.Operator != nil && expr.Operator.IsNative && expr.Operator != Natives[OP_CALL] {
+		CompilationErrorAt(CurrentFile, expr.FileLine, expr.Span, DIAG_INVALID, fmt.Sprintf("cannot use native function '%s' in go statement", OpNames[expr.Operator.OpCode]))
+		return nil
+	}
+
+	expr.IsGoCall = true
+
+	return exprs
+}
+
+// DeferExpressions marks the function call at the end of `exprs` to be run
+// when the function returns. Its arguments are evaluated now, so they are
+// copied to variables whose values are kept with the deferred call
+func DeferExpressions (exprs []*CXExpression) []*CXExpression {
+	if len(exprs) == 0 {
+		return nil
+	}
+
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	expr := exprs[len(exprs) - 1]
+	if expr.Operator == nil && !expr.IsMethodCall {
+		CompilationErrorAt(CurrentFile, expr.FileLine, expr.Span, DIAG_INVALID, "expression in defer statement must be a function call")
+		return nil
+	}
+
+	exprs = exprs[:len(exprs) - 1]
+	for i, inp := range expr.Inputs {
+		val := MakeExpression(nil, CurrentFile, expr.FileLine)
+		val.Package = pkg
+		val.Outputs = []*CXArgument{inp}
+
+		name := MakeGenSym(LOCAL_PREFIX)
+		exprs = append(exprs, Assignment(PrimaryIdentifier(name), ":=", []*CXExpression{val})...)
+		expr.Inputs[i] = PrimaryIdentifier(name)[0].Outputs[0]
+	}
+
+	expr.IsDeferCall = true
+
+	return append(exprs, expr)
+}
+
 // Depending on the operator, we're going to return the input's size or a prefixed size (like a Boolean)
 func undOutputSize (expr *CXExpression) int {
 	switch expr.Operator.OpCode {
@@ -218,6 +501,10 @@ func ProcessPointerStructs (expr *CXExpression) {
 		if arg.IsStruct && arg.IsPointer && len(arg.Fields) > 0 && arg.DereferenceLevels == 0 {
 			arg.DereferenceLevels++
 			arg.DereferenceOperations = append(arg.DereferenceOperations, DEREF_POINTER)
+		} else if arg.IsStruct && len(arg.Fields) > 0 && len(arg.Indexes) > 0 && IsIndexedPointer(arg) {
+			// fields of an element of a slice or array of pointers, e.g. `ps[0].r`
+			arg.DereferenceLevels++
+			arg.DereferenceOperations = append(arg.DereferenceOperations, DEREF_POINTER)
 		}
 	}
 }
@@ -225,9 +512,10 @@ func ProcessPointerStructs (expr *CXExpression) {
 func ProcessExpressionArguments (symbols *map[string]*CXArgument, symbolsScope *map[string]bool, offset *int, fn *CXFunction, args []*CXArgument, expr *CXExpression, isInput bool) {
 	for _, arg := range args {		
 		ProcessLocalDeclaration(symbols, symbolsScope, arg)
+		ProcessConstant(symbols, expr, arg, isInput)
 
 		if !isInput {
-			CheckRedeclared(symbols, expr, arg)
+			CheckRedeclared(symbols, fn, expr, arg)
 		}
 		
 		if !isInput {
@@ -249,28 +537,31 @@ func ProcessExpressionArguments (symbols *map[string]*CXArgument, symbolsScope *
 		ProcessSlice(arg)
 		
 		for _, idx := range arg.Indexes {
+			ProcessConstant(symbols, expr, idx, true)
 			UpdateSymbolsTable(symbols, idx, offset, true)
 			GiveOffset(symbols, idx, offset, true)
 		}
 		for _, fld := range arg.Fields {
 			for _, idx := range fld.Indexes {
+				ProcessConstant(symbols, expr, idx, true)
 				UpdateSymbolsTable(symbols, idx, offset, true)
 				GiveOffset(symbols, idx, offset, true)
 			}
 		}
 
-		SetFinalSize(symbols, arg)
+		ProcessRangeEntry(symbols, offset, arg)
+		ProcessMapIndex(arg, isInput)
 
-		AddPointer(fn, arg)
+		SetFinalSize(symbols, arg)
 	}
 }
 
 // this function adds the roots (pointers) for some GC algorithms
 func AddPointer(fn *CXFunction, sym *CXArgument) {
-	if sym.IsPointer && sym.Name != "" {
+	if HasPointers(sym) && sym.Name != "" {
 		var found bool
 		for _, ptr := range fn.ListOfPointers {
-			if sym.Name == ptr.Name {
+			if sym.Name == ptr.Name && sym.Offset == ptr.Offset {
 				found = true
 				break
 			}
@@ -281,10 +572,17 @@ func AddPointer(fn *CXFunction, sym *CXArgument) {
 	}
 }
 
-func CheckRedeclared (symbols *map[string]*CXArgument, expr *CXExpression, sym *CXArgument) {
+func CheckRedeclared (symbols *map[string]*CXArgument, fn *CXFunction, expr *CXExpression, sym *CXArgument) {
 	if expr.Operator == nil && len(expr.Outputs) > 0 && len(expr.Inputs) == 0 {
-		if _, found := (*symbols)[sym.Package.Name+"."+sym.Name]; found {
-			println(CompilationError(sym.FileName, sym.FileLine), fmt.Sprintf("'%s' redeclared", sym.Name))
+		if prev, found := (*symbols)[sym.Package.Name+"."+sym.Name]; found {
+			if inSiblingBlock(prev, sym) {
+				// the variable of the other block is still a root
+				// of fn, and `sym` gets its own offset
+				AddPointer(fn, prev)
+				delete(*symbols, sym.Package.Name+"."+sym.Name)
+				return
+			}
+			CompilationErrorAt(sym.FileName, sym.FileLine, sym.Span, DIAG_REDECLARED, fmt.Sprintf("'%s' redeclared", sym.Name))
 		}
 	}
 }
@@ -298,6 +596,9 @@ func ProcessLocalDeclaration (symbols *map[string]*CXArgument, symbolsScope *map
 
 func FunctionProcessParameters (symbols *map[string]*CXArgument, symbolsScope *map[string]bool, offset *int, fn *CXFunction, params []*CXArgument) {
 	for _, param := range params {
+		// the parameters shadow the globals of the package and the
+		// variables of the functions enclosing a function literal
+		param.IsLocalDeclaration = true
 		ProcessLocalDeclaration(symbols, symbolsScope, param)
 
 		UpdateSymbolsTable(symbols, param, offset, false)
@@ -331,9 +632,26 @@ func ProcessGoTos (fn *CXFunction, exprs []*CXExpression) {
 func CheckTypes(expr *CXExpression) {
 	if expr.Operator != nil {
 		opName := ExprOpName(expr)
+		variadic := variadicParameter(expr.Operator.Inputs)
+
+		if expr.IsSpreadCall && variadic == nil && expr.Operator != Natives[OP_CALL] {
+			CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, fmt.Sprintf("cannot use '...' in call to non-variadic function '%s'", opName))
+			return
+		}
 
 		// checking if number of inputs is less than the required number of inputs
-		if len(expr.Inputs) != len(expr.Operator.Inputs) {
+		if variadic != nil && !expr.IsSpreadCall {
+			// the variadic parameter can receive any number of arguments
+			if len(expr.Inputs) < len(expr.Operator.Inputs) - 1 {
+				var plural1 string
+				if len(expr.Operator.Inputs) - 1 != 1 {
+					plural1 = "s"
+				}
+
+				CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, fmt.Sprintf("operator '%s' expects at least %d input%s, but %d input arguments were provided", opName, len(expr.Operator.Inputs) - 1, plural1, len(expr.Inputs)))
+				return
+			}
+		} else if len(expr.Inputs) != len(expr.Operator.Inputs) {
 			if !(len(expr.Operator.Inputs) > 0 && expr.Operator.Inputs[len(expr.Operator.Inputs) - 1].Type != TYPE_UNDEFINED) {
 				// if the last input is of type TYPE_UNDEFINED then it might be a variadic function, such as printf
 			} else {
@@ -349,13 +667,15 @@ func CheckTypes(expr *CXExpression) {
 					plural3 = "was"
 				}
 
-				println(CompilationError(expr.FileName, expr.FileLine), fmt.Sprintf("operator '%s' expects %d input%s, but %d input argument%s %s provided", opName, len(expr.Operator.Inputs), plural1, len(expr.Inputs), plural2, plural3))
+				CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, fmt.Sprintf("operator '%s' expects %d input%s, but %d input argument%s %s provided", opName, len(expr.Operator.Inputs), plural1, len(expr.Inputs), plural2, plural3))
 				return
 			}
 		}
 
 		// checking if number of expr.Outputs match number of Operator.Outputs
-		if len(expr.Outputs) != len(expr.Operator.Outputs) {
+		// (a receive can also return whether the channel is open, or nothing,
+		// and calls through function values are checked by ProcessFuncCall)
+		if len(expr.Outputs) != len(expr.Operator.Outputs) && !(expr.Operator == Natives[OP_RECV] && len(expr.Outputs) <= 2) && !(expr.Operator == Natives[OP_TYPE_ASSERT] && len(expr.Outputs) == 2) && !(expr.Operator == Natives[OP_RECOVER] && len(expr.Outputs) == 0) && expr.Operator != Natives[OP_CALL] {
 			var plural1 string
 			var plural2 string = "s"
 			var plural3 string = "were"
@@ -366,67 +686,61 @@ func CheckTypes(expr *CXExpression) {
 				plural2 = ""
 				plural3 = "was"
 			}
-			println(CompilationError(expr.FileName, expr.FileLine), fmt.Sprintf("operator '%s' expects to return %d output%s, but %d receiving argument%s %s provided", opName, len(expr.Operator.Outputs), plural1, len(expr.Outputs), plural2, plural3)) 
+			CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, fmt.Sprintf("operator '%s' expects to return %d output%s, but %d receiving argument%s %s provided", opName, len(expr.Operator.Outputs), plural1, len(expr.Outputs), plural2, plural3)) 
 		}
 	}
 
-	if expr.Operator != nil && expr.Operator.IsNative && expr.Operator.OpCode == OP_IDENTITY {
+	if expr.IsConversion {
+		checkConversion(expr)
+	} else if expr.Operator != nil && expr.Operator.IsNative && expr.Operator.OpCode == OP_IDENTITY {
 		for i, _ := range expr.Inputs {
-			var expectedType string
-			var receivedType string
-			if GetAssignmentElement(expr.Outputs[i]).CustomType != nil {
-				// then it's custom type
-				expectedType = GetAssignmentElement(expr.Outputs[i]).CustomType.Name
-			} else {
-				// then it's native type
-				expectedType = TypeNames[GetAssignmentElement(expr.Outputs[i]).Type]
-			}
-			
-
-			if GetAssignmentElement(expr.Inputs[i]).CustomType != nil {
-				// then it's custom type
-				receivedType = GetAssignmentElement(expr.Inputs[i]).CustomType.Name
-			} else {
-				// then it's native type
-				receivedType = TypeNames[GetAssignmentElement(expr.Inputs[i]).Type]
-			}
+			expectedType := argTypeName(GetAssignmentElement(expr.Outputs[i]))
+			receivedType := argTypeName(GetAssignmentElement(expr.Inputs[i]))
 
 			// if GetAssignmentElement(expr.Outputs[i]).Type != GetAssignmentElement(inp).Type {
-			if receivedType != expectedType {
+			if !isAssignable(GetAssignmentElement(expr.Inputs[i]), GetAssignmentElement(expr.Outputs[i])) && !checkInterfaceValue(expr.Inputs[i], expr.Outputs[i]) {
 				if expr.IsStructLiteral {
-					println(CompilationError(expr.Outputs[i].FileName, expr.Outputs[i].FileLine), fmt.Sprintf("field '%s' in struct literal of type '%s' expected argument of type '%s'; '%s' was provided", expr.Outputs[i].Fields[0].Name, expr.Outputs[i].CustomType.Name, expectedType, receivedType))
+					CompilationErrorAt(expr.Outputs[i].FileName, expr.Outputs[i].FileLine, expr.Outputs[i].Span, DIAG_TYPE, fmt.Sprintf("field '%s' in struct literal of type '%s' expected argument of type '%s'; '%s' was provided", expr.Outputs[i].Fields[0].Name, expr.Outputs[i].CustomType.Name, expectedType, receivedType))
 				} else {
-					println(CompilationError(expr.Outputs[i].FileName, expr.Outputs[i].FileLine), fmt.Sprintf("trying to assign argument of type '%s' to symbol '%s' of type '%s'", receivedType, GetAssignmentElement(expr.Outputs[i]).Name, expectedType))
+					CompilationErrorAt(expr.Outputs[i].FileName, expr.Outputs[i].FileLine, expr.Outputs[i].Span, DIAG_TYPE, fmt.Sprintf("trying to assign argument of type '%s' to symbol '%s' of type '%s'", receivedType, GetAssignmentElement(expr.Outputs[i]).Name, expectedType))
 				}
 			}
 		}
 	}
 
-	// checking inputs matching operator's inputs
-	if expr.Operator != nil {
+	if expr.IsSwitchCase {
+		checkSwitchCase(expr)
+	}
+
+	if expr.Operator == Natives[OP_RECV] {
+		CheckReceiveTypes(expr)
+	}
+
+	if expr.Operator == Natives[OP_TYPE_ASSERT] {
+		CheckTypeAssertion(expr)
+	}
+
+	// checking inputs matching operator's inputs, which checkConversion
+	// already did for conversions
+	if expr.Operator != nil && !expr.IsConversion {
 		// then it's a function call and not a declaration
 		for i, inp := range expr.Operator.Inputs {
-
-			var expectedType string
-			var receivedType string
-			if expr.Operator.Inputs[i].CustomType != nil {
-				// then it's custom type
-				expectedType = expr.Operator.Inputs[i].CustomType.Name
-			} else {
-				// then it's native type
-				expectedType = TypeNames[expr.Operator.Inputs[i].Type]
+			if inp.IsVariadic {
+				checkVariadicArguments(expr, ExprOpName(expr), inp, expr.Inputs[i:])
+				break
 			}
 
-			if GetAssignmentElement(expr.Inputs[i]).CustomType != nil {
-				// then it's custom type
-				receivedType = GetAssignmentElement(expr.Inputs[i]).CustomType.Name
-			} else {
-				// then it's native type
-				receivedType = TypeNames[GetAssignmentElement(expr.Inputs[i]).Type]
+			expectedType := argTypeName(expr.Operator.Inputs[i])
+			receivedType := argTypeName(GetAssignmentElement(expr.Inputs[i]))
+
+			assignable := isAssignable(GetAssignmentElement(expr.Inputs[i]), inp)
+			if expr.Operator.IsNative {
+				// the natives work on the underlying types
+				assignable = underlyingTypeName(inp) == underlyingTypeName(GetAssignmentElement(expr.Inputs[i]))
 			}
 			
 			// if inp.Type != expr.Inputs[i].Type && inp.Type != TYPE_UNDEFINED {
-			if expectedType != receivedType && inp.Type != TYPE_UNDEFINED {
+			if !assignable && inp.Type != TYPE_UNDEFINED && !checkInterfaceValue(expr.Inputs[i], inp) {
 				var opName string
 				if expr.Operator.IsNative {
 					opName = OpNames[expr.Operator.OpCode]
@@ -434,12 +748,90 @@ func CheckTypes(expr *CXExpression) {
 					opName = expr.Operator.Name
 				}
 
-				println(CompilationError(expr.Inputs[i].FileName, expr.Inputs[i].FileLine), fmt.Sprintf("function '%s' expected input argument of type '%s'; '%s' was provided", opName, expectedType, receivedType))
+				CompilationErrorAt(expr.Inputs[i].FileName, expr.Inputs[i].FileLine, expr.Inputs[i].Span, DIAG_TYPE, fmt.Sprintf("function '%s' expected input argument of type '%s'; '%s' was provided", opName, expectedType, receivedType))
 			}
 		}
 	}
 }
 
+// checkSwitchCase checks if the case value compared by `expr` has the type
+// of the tag of its switch statement
+func checkSwitchCase (expr *CXExpression) {
+	tag, value := expr.Inputs[0], expr.Inputs[1]
+	if isAssignable(GetAssignmentElement(value), GetAssignmentElement(tag)) || checkInterfaceValue(value, tag) {
+		return
+	}
+
+	span := value.Span
+	if !span.IsValid() {
+		span = expr.Span
+	}
+	CompilationErrorAt(expr.FileName, expr.FileLine, span, DIAG_TYPE, fmt.Sprintf("case value of type '%s' can't be compared with the switch tag of type '%s'", argTypeName(GetAssignmentElement(value)), argTypeName(GetAssignmentElement(tag))))
+}
+
+// variadicParameter returns the variadic parameter of the function with the
+// inputs `params`, or nil if it's not variadic
+func variadicParameter (params []*CXArgument) *CXArgument {
+	if len(params) > 0 && params[len(params) - 1].IsVariadic {
+		return params[len(params) - 1]
+	}
+	return nil
+}
+
+// variadicElement returns the type of the arguments received by the variadic
+// parameter `param`, which is a slice of them
+func variadicElement (param *CXArgument) *CXArgument {
+	elt := *param
+	elt.IsVariadic = false
+	elt.IsSlice = false
+	elt.IsArray = len(param.Lengths) > 1
+	elt.IsReference = false
+	elt.PassBy = PASSBY_VALUE
+	elt.Lengths = param.Lengths[1:]
+	elt.DeclarationSpecifiers = param.DeclarationSpecifiers[:len(param.DeclarationSpecifiers) - 1]
+	elt.Size = param.TotalSize
+	return &elt
+}
+
+// checks if the value of `arg` is a slice, and not one of its elements
+func isSliceValue (arg *CXArgument) bool {
+	elt := GetAssignmentElement(arg)
+	return elt.IsSlice && len(elt.Indexes) < len(elt.Lengths)
+}
+
+// checkVariadicArguments checks the arguments `args` received by the variadic
+// parameter `param` of the function `opName` called by `expr`
+func checkVariadicArguments (expr *CXExpression, opName string, param *CXArgument, args []*CXArgument) {
+	elt := variadicElement(param)
+	expectedType := argTypeName(elt)
+
+	if expr.IsSpreadCall {
+		// the slice is passed as is
+		arg := args[0]
+		receivedType := argTypeName(GetAssignmentElement(arg))
+		if !isSliceValue(arg) || receivedType != expectedType {
+			if isSliceValue(arg) {
+				receivedType = "[]" + receivedType
+			}
+			CompilationErrorAt(arg.FileName, arg.FileLine, arg.Span, DIAG_TYPE, fmt.Sprintf("function '%s' expected input argument of type '[]%s'; '%s' was provided", opName, expectedType, receivedType))
+		}
+		return
+	}
+
+	for _, arg := range args {
+		receivedType := argTypeName(GetAssignmentElement(arg))
+		if checkInterfaceValue(arg, elt) {
+			continue
+		}
+		if receivedType != expectedType || isSliceValue(arg) != (len(elt.Lengths) > 0 && elt.Lengths[0] == 0) {
+			if isSliceValue(arg) {
+				receivedType = "[]" + receivedType
+			}
+			CompilationErrorAt(arg.FileName, arg.FileLine, arg.Span, DIAG_TYPE, fmt.Sprintf("function '%s' expected input argument of type '%s'; '%s' was provided", opName, expectedType, receivedType))
+		}
+	}
+}
+
 func ProcessStringAssignment (expr *CXExpression) {
 	if expr.Operator == Natives[OP_IDENTITY] {
 		for i, out := range expr.Outputs {
@@ -456,6 +848,634 @@ func ProcessStringAssignment (expr *CXExpression) {
 	}
 }
 
+// ProcessMapIndex turns the indexing of a map, which the parser can't tell
+// apart from the indexing of an array, into a map lookup. If the map is
+// being assigned to, the key is inserted if it's not found
+func ProcessMapIndex (arg *CXArgument, isInput bool) {
+	for _, elt := range append([]*CXArgument{arg}, arg.Fields...) {
+		if !elt.IsMap {
+			continue
+		}
+		if len(elt.Indexes) == 0 {
+			continue
+		}
+
+		// isEntry is true if the map is being iterated
+		var isKey, isEntry bool
+		for i, op := range elt.DereferenceOperations {
+			switch op {
+			case DEREF_ARRAY, DEREF_MAP, DEREF_MAP_INSERT:
+				if isInput {
+					elt.DereferenceOperations[i] = DEREF_MAP
+				} else {
+					elt.DereferenceOperations[i] = DEREF_MAP_INSERT
+				}
+			case DEREF_MAP_KEY:
+				isKey = true
+				isEntry = true
+			case DEREF_MAP_VALUE:
+				isEntry = true
+			}
+		}
+
+		if len(elt.Indexes) > 1 {
+			CompilationErrorAt(elt.FileName, elt.FileLine, elt.Span, DIAG_INVALID, fmt.Sprintf("invalid operation: map '%s' can only be indexed once", elt.Name))
+		}
+
+		key := GetAssignmentElement(elt.Indexes[0])
+		if !isEntry && key.Type != TYPE_UNDEFINED && key.Type != TYPE_IDENTIFIER && key.Type != elt.MapKeyType {
+			CompilationErrorAt(elt.FileName, elt.FileLine, elt.Span, DIAG_TYPE, fmt.Sprintf("cannot use argument of type '%s' as key of map '%s' of type '%s'", TypeNames[key.Type], elt.Name, TypeNames[elt.MapKeyType]))
+		}
+
+		if isKey {
+			elt.Type = elt.MapKeyType
+			elt.CustomType = nil
+			elt.Size = GetArgSize(elt.Type)
+		} else if elt.CustomType != nil {
+			elt.Size = elt.CustomType.Size
+		} else {
+			elt.Size = GetArgSize(elt.Type)
+		}
+
+		if isInput && !isEntry {
+			reserveNilMapValue(elt.Size)
+		}
+
+		elt.IsMap = false
+		elt.IsPointer = elt.Type == TYPE_STR
+
+		if len(arg.Fields) == 0 || elt == arg.Fields[len(arg.Fields) - 1] {
+			// then the map's element is what's being accessed,
+			// not one of its fields
+			elt.TotalSize = elt.Size
+			arg.Type = elt.Type
+			arg.TotalSize = elt.Size
+		}
+	}
+}
+
+// reserveNilMapValue makes the zeroed value that reading a nil map returns
+// big enough for values of `size` bytes. It's reserved in the data segment,
+// so reading a nil map doesn't allocate a map
+func reserveNilMapValue (size int) {
+	if size <= nilMapValueSize {
+		return
+	}
+
+	if !PRGRM.GrowMemory(DataOffset + size) {
+		CompilationError(CurrentFile, LineNo, DIAG_LIMIT, fmt.Sprintf("data segment exceeds the maximum heap size (%d bytes)", PRGRM.MaxHeapSize))
+		return
+	}
+	PRGRM.NilMapValue = DataOffset
+	nilMapValueSize = size
+	DataOffset += size
+}
+
+// ProcessRangeEntry turns the key and the value of an entry ranged over,
+// which are read as the ones of a map entry (see RangeExpressions), into the
+// position of the entry and the element at that position if an array or a
+// slice is being ranged over, or the rune starting at it if it's a string
+func ProcessRangeEntry (symbols *map[string]*CXArgument, offset *int, arg *CXArgument) {
+	elt := GetAssignmentElement(arg)
+	if elt.IsMap {
+		return
+	}
+
+	derefIdx := -1
+	for i, op := range elt.DereferenceOperations {
+		if op == DEREF_MAP_KEY || op == DEREF_MAP_VALUE {
+			derefIdx = i
+		}
+	}
+	if derefIdx < 0 {
+		return
+	}
+
+	isStr := elt.Type == TYPE_STR && len(elt.Lengths) == 0
+	if len(elt.Lengths) == 0 && !isStr {
+		CompilationErrorAt(elt.FileName, elt.FileLine, elt.Span, DIAG_TYPE, fmt.Sprintf("cannot range over '%s'", elt.Name))
+		return
+	}
+
+	if elt.DereferenceOperations[derefIdx] == DEREF_MAP_KEY {
+		*arg = *elt.Indexes[len(elt.Indexes) - 1]
+		return
+	}
+
+	if isStr {
+		*arg = *arg.RangeRune
+		UpdateSymbolsTable(symbols, arg, offset, true)
+		GiveOffset(symbols, arg, offset, true)
+		return
+	}
+
+	elt.DereferenceOperations[derefIdx] = DEREF_ARRAY
+	if !elt.IsDereferenceFirst {
+		elt.IsArrayFirst = true
+	}
+}
+
+// IsMapElement checks if `arg` accesses an element of a map
+func IsMapElement (arg *CXArgument) bool {
+	for _, elt := range append([]*CXArgument{arg}, arg.Fields...) {
+		for _, op := range elt.DereferenceOperations {
+			if op == DEREF_MAP || op == DEREF_MAP_INSERT || op == DEREF_MAP_KEY || op == DEREF_MAP_VALUE {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ProcessShortDeclaration makes a short-declared symbol adopt the size of the
+// value being assigned to it, moving the symbol to a new offset in the
+// stack frame if it's bigger than the size it was given at its declaration.
+// This is needed for map elements, channels and values received from them
+func ProcessShortDeclaration (symbols *map[string]*CXArgument, offset *int, expr *CXExpression) {
+	out := expr.Outputs[0]
+	inp := GetAssignmentElement(expr.Inputs[0])
+
+	if len(out.Fields) > 0 || len(out.Indexes) > 0 {
+		return
+	}
+
+	isRecv := expr.Operator == Natives[OP_RECV] && inp.IsChan
+	isChan := expr.Operator == Natives[OP_IDENTITY] && inp.IsChan
+	isFunc := (expr.Operator == Natives[OP_IDENTITY] || expr.Operator == Natives[OP_CLOSURE]) && inp.Type == TYPE_FUNC
+	isCall := expr.Operator == Natives[OP_CALL] && inp.Type == TYPE_FUNC && len(inp.Outputs) > 0
+	// the first input of a type assertion holds the asserted type
+	isAssert := expr.Operator == Natives[OP_TYPE_ASSERT]
+	// a struct or interface value copied as a whole
+	isCustom := expr.Operator == Natives[OP_IDENTITY] && inp.CustomType != nil && !inp.IsMap && !inp.IsChan &&
+		len(inp.Indexes) == len(inp.Lengths) && (inp.DereferenceLevels == 0 || inp.IsSlice) && expr.Inputs[0].PassBy == PASSBY_VALUE
+	// a copy of a value of a named type has the same type
+	isNamed := expr.Operator == Natives[OP_IDENTITY] && !expr.IsConversion && GetNamedType(expr.Inputs[0]) != nil
+	// a slice copied as a whole, e.g. the result of a slice expression
+	isSlice := expr.Operator == Natives[OP_IDENTITY] && inp.IsSlice && len(inp.Indexes) == 0
+	// a map or an array copied as a whole
+	isMap := expr.Operator == Natives[OP_IDENTITY] && inp.IsMap && len(inp.Indexes) == 0
+	isArray := expr.Operator == Natives[OP_IDENTITY] && !inp.IsSlice && !inp.IsMap &&
+		len(inp.Lengths) > 0 && len(inp.Indexes) == 0
+	// a basic element of an array or a slice, e.g. `x := xs[i]` or the
+	// value of a range loop
+	isElement := expr.Operator == Natives[OP_IDENTITY] && inp.CustomType == nil && inp.Type != TYPE_FUNC && !inp.IsMap &&
+		len(inp.Indexes) > 0 && len(inp.Indexes) == len(inp.Lengths)
+	if !isRecv && !isChan && !isFunc && !isCall && !isAssert && !isCustom && !isNamed && !isSlice && !isMap && !isArray && !isElement && (expr.Operator != Natives[OP_IDENTITY] || !IsMapElement(expr.Inputs[0])) {
+		return
+	}
+
+	if isCall {
+		// then the symbol adopts the first output of the function value
+		inp = inp.Outputs[0]
+	}
+
+	sym, found := (*symbols)[out.Package.Name+"."+out.Name]
+	if !found {
+		return
+	}
+
+	size, totalSize := inp.Size, inp.TotalSize
+	if isRecv {
+		size = chanElementSize(inp)
+		totalSize = size
+		sym.DeclarationSpecifiers = chanElementSpecifiers(inp)
+	}
+	if isChan {
+		sym.IsChan = true
+		sym.DeclarationSpecifiers = inp.DeclarationSpecifiers
+	}
+	if isFunc || isCall {
+		sym.DeclarationSpecifiers = inp.DeclarationSpecifiers
+		sym.Inputs = inp.Inputs
+		sym.Outputs = inp.Outputs
+	}
+	if isCall {
+		sym.IsSlice = inp.IsSlice
+		sym.IsMap = inp.IsMap
+		sym.MapKeyType = inp.MapKeyType
+		sym.IsChan = inp.IsChan
+		sym.Lengths = inp.Lengths
+		sym.PassBy = inp.PassBy
+		if inp.IsSlice {
+			totalSize = size
+		}
+	}
+
+	sym.Type = inp.Type
+	sym.CustomType = inp.CustomType
+	sym.IsPointer = inp.Type == TYPE_STR
+	if isAssert || isCustom || isNamed {
+		sym.NamedType = inp.NamedType
+		sym.DeclarationSpecifiers = inp.DeclarationSpecifiers
+		sym.IsPointer = inp.IsPointer
+		sym.IndirectionLevels = inp.IndirectionLevels
+		sym.IsSlice = inp.IsSlice
+		sym.Lengths = inp.Lengths
+		sym.PassBy = inp.PassBy
+	}
+	if isElement {
+		specs := elementSpecifiers(inp)
+		sym.DeclarationSpecifiers = specs
+		sym.IsPointer = inp.Type == TYPE_STR || len(specs) > 0 && specs[len(specs) - 1] == DECL_POINTER
+		sym.IsSlice = false
+		sym.Lengths = nil
+		size = GetArgSize(inp.Type)
+		if sym.IsPointer {
+			size = TYPE_POINTER_SIZE
+		}
+		totalSize = size
+	}
+	if isCustom && len(inp.Indexes) > 0 {
+		// then it's an element of an array or a slice
+		specs := elementSpecifiers(inp)
+		sym.DeclarationSpecifiers = specs
+		sym.IsPointer = len(specs) > 0 && specs[len(specs) - 1] == DECL_POINTER
+		sym.IsSlice = false
+		sym.Lengths = nil
+	}
+
+	if isMap || isArray {
+		sym.IsMap = inp.IsMap
+		sym.MapKeyType = inp.MapKeyType
+		sym.IsArray = isArray
+		sym.IsPointer = inp.IsPointer
+		sym.DeclarationSpecifiers = inp.DeclarationSpecifiers
+		sym.Lengths = inp.Lengths
+		sym.PassBy = inp.PassBy
+	}
+
+	if isSlice {
+		sym.IsSlice = true
+		sym.IsArray = true
+		sym.IsReference = true
+		sym.NamedType = inp.NamedType
+		sym.DeclarationSpecifiers = inp.DeclarationSpecifiers
+		sym.Lengths = inp.Lengths
+		sym.PassBy = inp.PassBy
+	}
+
+	if isSlice && sym.TotalSize < TYPE_POINTER_SIZE && !sym.IsCaptured {
+		// the frame only holds the address of the slice's heap object
+		sym.Offset = *offset
+		*offset += TYPE_POINTER_SIZE
+	} else if !isSlice && sym.TotalSize < totalSize && !sym.IsCaptured {
+		sym.Offset = *offset
+		*offset += totalSize
+	}
+
+	sym.Size = size
+	sym.TotalSize = totalSize
+
+	CopyArgFields(out, sym)
+	if isSlice {
+		// the address of the slice's heap object is copied
+		ProcessSlice(out)
+		out.PassBy = PASSBY_VALUE
+	}
+}
+
+// elementSpecifiers returns the declaration specifiers of the element of an
+// array or a slice accessed by the indexes of `arg`
+func elementSpecifiers (arg *CXArgument) []int {
+	specs := arg.DeclarationSpecifiers
+	for i := 0; i < len(arg.Indexes) && len(specs) > 0; i++ {
+		if last := specs[len(specs) - 1]; last == DECL_ARRAY || last == DECL_SLICE {
+			specs = specs[:len(specs) - 1]
+		}
+	}
+	return specs
+}
+
+func chanElementSize (ch *CXArgument) int {
+	if ch.CustomType != nil && ch.Type == TYPE_CUSTOM {
+		return ch.CustomType.Size
+	}
+	return GetArgSize(ch.Type)
+}
+
+func chanElementSpecifiers (ch *CXArgument) []int {
+	specs := ch.DeclarationSpecifiers
+	if len(specs) > 0 && specs[len(specs) - 1] == DECL_CHAN {
+		specs = specs[:len(specs) - 1]
+	}
+	return specs
+}
+
+// ProcessChanOperation checks that the channel operations are given a
+// channel, and makes the temporary variables receiving from a channel adopt
+// the type of its elements, which is not known until the channel's symbol
+// is processed
+func ProcessChanOperation (expr *CXExpression) {
+	if expr.Operator == nil || !expr.Operator.IsNative {
+		return
+	}
+
+	var chans []*CXArgument
+	switch expr.Operator.OpCode {
+	case OP_SEND, OP_RECV, OP_CLOSE:
+		if len(expr.Inputs) > 0 {
+			chans = expr.Inputs[:1]
+		}
+	case OP_SELECT:
+		for c := 2; c < len(expr.Inputs); c += 2 {
+			chans = append(chans, expr.Inputs[c])
+		}
+	default:
+		return
+	}
+
+	for _, ch := range chans {
+		if elt := GetAssignmentElement(ch); !elt.IsChan {
+			CompilationErrorAt(ch.FileName, ch.FileLine, ch.Span, DIAG_TYPE, fmt.Sprintf("invalid operation: '%s' is not a channel", elt.Name))
+			return
+		}
+	}
+
+	if len(chans) == 0 {
+		return
+	}
+	ch := GetAssignmentElement(chans[0])
+
+	switch expr.Operator.OpCode {
+	case OP_SEND:
+		if len(expr.Inputs) < 2 {
+			return
+		}
+		val := GetAssignmentElement(expr.Inputs[1])
+		if val.Type != TYPE_UNDEFINED && val.Type != TYPE_IDENTIFIER && (val.Type != ch.Type || val.CustomType != ch.CustomType) {
+			CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_TYPE, fmt.Sprintf("cannot send argument of type '%s' to channel '%s' of type '%s'", argTypeName(val), ch.Name, argTypeName(ch)))
+		}
+	case OP_RECV:
+		if len(expr.Outputs) > 0 && IsTempVar(expr.Outputs[0].Name) {
+			out := expr.Outputs[0]
+			out.Type = ch.Type
+			out.CustomType = ch.CustomType
+			out.IsChan = false
+			out.IsPointer = ch.Type == TYPE_STR
+			out.Size = chanElementSize(ch)
+			out.TotalSize = out.Size
+			out.DeclarationSpecifiers = chanElementSpecifiers(ch)
+		}
+	}
+}
+
+// ProcessFuncCall checks the calls through function values against the
+// signatures of the function values, and makes the temporary variables
+// receiving function values adopt their signatures
+func ProcessFuncCall (expr *CXExpression) {
+	if expr.Operator == Natives[OP_CLOSURE] {
+		if len(expr.Outputs) > 0 && IsTempVar(expr.Outputs[0].Name) {
+			expr.Outputs[0].Inputs = expr.Inputs[0].Inputs
+			expr.Outputs[0].Outputs = expr.Inputs[0].Outputs
+		}
+		return
+	}
+	if expr.Operator != Natives[OP_CALL] || len(expr.Inputs) < 1 {
+		return
+	}
+
+	fn := GetAssignmentElement(expr.Inputs[0])
+	if fn.Type == TYPE_IDENTIFIER {
+		// it's undeclared, which is already reported
+		return
+	}
+	if fn.Type != TYPE_FUNC {
+		CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_INVALID, fmt.Sprintf("cannot call non-function '%s' of type '%s'", fn.Name, argTypeName(fn)))
+		return
+	}
+
+	args := expr.Inputs[1:]
+	variadic := variadicParameter(fn.Inputs)
+	if expr.IsSpreadCall && variadic == nil {
+		CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, fmt.Sprintf("cannot use '...' in call to non-variadic function value '%s'", fn.Name))
+		return
+	}
+
+	if variadic != nil && !expr.IsSpreadCall && len(args) >= len(fn.Inputs) - 1 {
+		// the variadic parameter can receive any number of arguments
+	} else if len(args) != len(fn.Inputs) {
+		var plural1 string
+		var plural2 string = "s"
+		var plural3 string = "were"
+		if len(fn.Inputs) != 1 {
+			plural1 = "s"
+		}
+		if len(args) == 1 {
+			plural2 = ""
+			plural3 = "was"
+		}
+		CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, fmt.Sprintf("function value '%s' expects %d input%s, but %d input argument%s %s provided", fn.Name, len(fn.Inputs), plural1, len(args), plural2, plural3))
+		return
+	}
+
+	for i, inp := range fn.Inputs {
+		if inp.IsVariadic {
+			checkVariadicArguments(expr, fn.Name, inp, args[i:])
+			break
+		}
+
+		arg := GetAssignmentElement(args[i])
+		if arg.Type == TYPE_UNDEFINED || arg.Type == TYPE_IDENTIFIER {
+			continue
+		}
+		if argTypeName(arg) != argTypeName(inp) {
+			CompilationErrorAt(args[i].FileName, args[i].FileLine, args[i].Span, DIAG_TYPE, fmt.Sprintf("function value '%s' expected input argument of type '%s'; '%s' was provided", fn.Name, argTypeName(inp), argTypeName(arg)))
+		}
+	}
+
+	if len(expr.Outputs) != len(fn.Outputs) {
+		var plural1 string
+		var plural2 string = "s"
+		var plural3 string = "were"
+		if len(fn.Outputs) != 1 {
+			plural1 = "s"
+		}
+		if len(expr.Outputs) == 1 {
+			plural2 = ""
+			plural3 = "was"
+		}
+		CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, fmt.Sprintf("function value '%s' returns %d output%s, but %d receiving argument%s %s provided", fn.Name, len(fn.Outputs), plural1, len(expr.Outputs), plural2, plural3))
+		return
+	}
+
+	for i, out := range expr.Outputs {
+		if !IsTempVar(out.Name) {
+			continue
+		}
+		sig := fn.Outputs[i]
+		out.Type = sig.Type
+		out.CustomType = sig.CustomType
+		out.Size = sig.Size
+		out.TotalSize = sig.TotalSize
+		out.IsSlice = sig.IsSlice
+		out.IsPointer = sig.IsPointer
+		out.IsMap = sig.IsMap
+		out.MapKeyType = sig.MapKeyType
+		out.IsChan = sig.IsChan
+		out.Lengths = sig.Lengths
+		out.PassBy = sig.PassBy
+		out.DeclarationSpecifiers = sig.DeclarationSpecifiers
+		out.Inputs = sig.Inputs
+		out.Outputs = sig.Outputs
+	}
+}
+
+// CheckReceiveTypes checks that the outputs of a receive can hold the value
+// received and whether the channel is open
+func CheckReceiveTypes (expr *CXExpression) {
+	if len(expr.Inputs) < 1 || !GetAssignmentElement(expr.Inputs[0]).IsChan {
+		return
+	}
+	ch := GetAssignmentElement(expr.Inputs[0])
+
+	if len(expr.Outputs) > 0 {
+		out := GetAssignmentElement(expr.Outputs[0])
+		if out.IsChan || out.Type != ch.Type || out.CustomType != ch.CustomType {
+			CompilationErrorAt(expr.Outputs[0].FileName, expr.Outputs[0].FileLine, expr.Outputs[0].Span, DIAG_TYPE, fmt.Sprintf("trying to assign argument of type '%s' to symbol '%s' of type '%s'", argTypeName(ch), out.Name, argTypeName(out)))
+		}
+	}
+	if len(expr.Outputs) > 1 {
+		ok := GetAssignmentElement(expr.Outputs[1])
+		if ok.Type != TYPE_BOOL {
+			CompilationErrorAt(expr.Outputs[1].FileName, expr.Outputs[1].FileLine, expr.Outputs[1].Span, DIAG_TYPE, fmt.Sprintf("trying to assign argument of type 'bool' to symbol '%s' of type '%s'", ok.Name, argTypeName(ok)))
+		}
+	}
+}
+
+// CheckTypeAssertion checks that a type assertion asserts the type of an
+// interface value, that the type can implement the interface, and that its
+// outputs can hold the value asserted and whether the assertion succeeded
+func CheckTypeAssertion (expr *CXExpression) {
+	typ, inp := expr.Inputs[0], expr.Inputs[1]
+	elt := GetAssignmentElement(inp)
+
+	if !IsInterfaceValue(inp) {
+		CompilationErrorAt(inp.FileName, inp.FileLine, inp.Span, DIAG_TYPE, fmt.Sprintf("invalid type assertion: '%s' is not an interface", argTypeName(elt)))
+		return
+	}
+	if !IsInterfaceValue(typ) {
+		if reason := Implements(typ, elt.CustomType); reason != "" {
+			CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_TYPE, fmt.Sprintf("impossible type assertion: '%s' does not implement '%s' (%s)", argTypeName(typ), argTypeName(elt), reason))
+		}
+	}
+
+	if len(expr.Outputs) > 0 {
+		out := GetAssignmentElement(expr.Outputs[0])
+		if argTypeName(out) != argTypeName(typ) || out.IsPointer != typ.IsPointer {
+			CompilationErrorAt(expr.Outputs[0].FileName, expr.Outputs[0].FileLine, expr.Outputs[0].Span, DIAG_TYPE, fmt.Sprintf("trying to assign argument of type '%s' to symbol '%s' of type '%s'", argTypeName(typ), out.Name, argTypeName(out)))
+		}
+	}
+	if len(expr.Outputs) > 1 {
+		ok := GetAssignmentElement(expr.Outputs[1])
+		if ok.Type != TYPE_BOOL {
+			CompilationErrorAt(expr.Outputs[1].FileName, expr.Outputs[1].FileLine, expr.Outputs[1].Span, DIAG_TYPE, fmt.Sprintf("trying to assign argument of type 'bool' to symbol '%s' of type '%s'", ok.Name, argTypeName(ok)))
+		}
+	}
+}
+
+// checkInterfaceValue reports if the value of `inp` can't be used as a value
+// of the interface of `out`. It returns false if `out` doesn't hold interface
+// values
+func checkInterfaceValue (inp, out *CXArgument) bool {
+	if !IsInterfaceVariable(out) {
+		return false
+	}
+	iface := GetAssignmentElement(out).CustomType
+	if reason := Implements(inp, iface); reason != "" {
+		CompilationErrorAt(inp.FileName, inp.FileLine, inp.Span, DIAG_TYPE, fmt.Sprintf("'%s' does not implement '%s' (%s)", argTypeName(GetAssignmentElement(inp)), iface.Name, reason))
+	}
+	return true
+}
+
+func argTypeName (arg *CXArgument) string {
+	if named := GetNamedType(arg); named != nil {
+		return named.Name
+	}
+	return underlyingTypeName(arg)
+}
+
+// underlyingTypeName is the name of the type of `arg` without its named
+// type, e.g. f64 for a value of type `Meters f64`
+func underlyingTypeName (arg *CXArgument) string {
+	if arg.CustomType != nil {
+		return arg.CustomType.Name
+	}
+	if arg.Type == TYPE_FUNC {
+		return funcTypeName(arg)
+	}
+	return TypeNames[arg.Type]
+}
+
+// funcTypeName writes the signature of the function values of the type of
+// `arg`, e.g. func(i32, str) (i32)
+func funcTypeName (arg *CXArgument) string {
+	var inps, outs []string
+	for _, inp := range arg.Inputs {
+		if inp.IsVariadic {
+			inps = append(inps, "..." + argTypeName(inp))
+			continue
+		}
+		inps = append(inps, argTypeName(inp))
+	}
+	for _, out := range arg.Outputs {
+		outs = append(outs, argTypeName(out))
+	}
+
+	name := fmt.Sprintf("func(%s)", strings.Join(inps, ", "))
+	if len(outs) > 0 {
+		name += fmt.Sprintf(" (%s)", strings.Join(outs, ", "))
+	}
+	return name
+}
+
+
+// isAssignable checks if the value `inp` can be assigned to a symbol of the
+// type of `typ`. A value of a named type can only be assigned to a symbol of
+// the same named type, and the other way around, unless the value is a
+// literal or a temporary result, or its type is composite and unnamed
+func isAssignable (inp *CXArgument, typ *CXArgument) bool {
+	if argTypeName(inp) == argTypeName(typ) {
+		return true
+	}
+	if underlyingTypeName(inp) != underlyingTypeName(typ) {
+		return false
+	}
+
+	unnamed := inp
+	if GetNamedType(inp) != nil {
+		unnamed = typ
+		if GetNamedType(typ) != nil {
+			return false
+		}
+	} else if inp.Name == "" || IsTempVar(inp.Name) {
+		return true
+	}
+	return len(unnamed.Lengths) > len(unnamed.Indexes)
+}
+
+// checkConversion checks that the value converted by `expr`, e.g. Meters(x),
+// has the same underlying type as the type it's converted to
+func checkConversion (expr *CXExpression) {
+	out := GetAssignmentElement(expr.Outputs[0])
+	if len(expr.Inputs) != 1 {
+		CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_ARGUMENTS, fmt.Sprintf("conversion to type '%s' expects 1 input argument, but %d were provided", argTypeName(out), len(expr.Inputs)))
+		return
+	}
+
+	inp := GetAssignmentElement(expr.Inputs[0])
+	if expr.Operator == Natives[OP_STR_BYTES] {
+		// []byte(str) copies the bytes of a string
+		if underlyingTypeName(inp) != TypeNames[TYPE_STR] || len(inp.Lengths) != len(inp.Indexes) {
+			CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_TYPE, fmt.Sprintf("cannot convert argument of type '%s' to type '[]byte'", argTypeName(inp)))
+		}
+		return
+	}
+	if underlyingTypeName(inp) != underlyingTypeName(out) || len(inp.Lengths) - len(inp.Indexes) != len(out.Lengths) - len(out.Indexes) {
+		CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_TYPE, fmt.Sprintf("cannot convert argument of type '%s' to type '%s'", argTypeName(inp), argTypeName(out)))
+	}
+}
+
 func ProcessSlice (inp *CXArgument) {
 	var elt *CXArgument
 
@@ -474,6 +1494,73 @@ func ProcessSlice (inp *CXArgument) {
 	}
 }
 
+// ProcessSliceExpression checks the value sliced by the slice expression
+// `expr`, and makes its temporary output a string if a string is sliced, or
+// a slice of the elements of the sliced array or slice
+func ProcessSliceExpression (expr *CXExpression) {
+	if expr.Operator != Natives[OP_SLICE] {
+		return
+	}
+
+	inp := GetAssignmentElement(expr.Inputs[0])
+	out := expr.Outputs[0]
+	isArray := len(inp.Lengths) > len(inp.Indexes)
+
+	if inp.Type == TYPE_STR && !isArray {
+		if !isOmittedBound(expr.Inputs[3]) {
+			CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_INVALID, fmt.Sprintf("invalid slice of string '%s': strings can't have a max bound", inp.Name))
+		}
+		if IsTempVar(out.Name) {
+			out.AddType(TypeNames[TYPE_STR])
+			out.IsPointer = true
+			out.PreviouslyDeclared = true
+		}
+		return
+	}
+	if !isArray || inp.IsMap || inp.IsChan {
+		CompilationErrorAt(expr.FileName, expr.FileLine, expr.Span, DIAG_TYPE, fmt.Sprintf("cannot slice '%s': it's not an array, a slice or a string", inp.Name))
+		return
+	}
+	if !IsTempVar(out.Name) {
+		// then it's assigned to a variable, which already has its type
+		return
+	}
+
+	lengths := inp.Lengths[len(inp.Indexes):]
+	eltSize := inp.TotalSize
+	if !inp.IsSlice || len(inp.Indexes) > 0 {
+		eltSize = inp.Size * TotalLength(lengths[1:])
+	}
+
+	specs := inp.DeclarationSpecifiers
+	if len(specs) > len(inp.Indexes) {
+		specs = specs[:len(specs) - len(inp.Indexes) - 1]
+	}
+
+	out.Type = inp.Type
+	out.CustomType = inp.CustomType
+	out.IsSlice = true
+	out.IsArray = true
+	out.IsReference = true
+	out.PassBy = PASSBY_REFERENCE
+	out.Lengths = append([]int{0}, lengths[1:]...)
+	out.DeclarationSpecifiers = append(append([]int{}, specs...), DECL_SLICE)
+	out.Size = TYPE_POINTER_SIZE
+	out.TotalSize = eltSize
+	out.PreviouslyDeclared = true
+}
+
+// isOmittedBound checks if `arg` is the literal written in place of a bound
+// left out of a slice expression
+func isOmittedBound (arg *CXArgument) bool {
+	if arg.Name != "" {
+		return false
+	}
+	var bound int32
+	encoder.DeserializeAtomic(PRGRM.Memory[arg.Offset : arg.Offset + TYPE_POINTER_SIZE], &bound)
+	return bound == SLICE_BOUND_OMITTED
+}
+
 func ProcessSliceAssignment (expr *CXExpression) {
 	if expr.Operator == Natives[OP_IDENTITY] {
 		var inp *CXArgument
@@ -507,14 +1594,18 @@ func UpdateSymbolsTable(symbols *map[string]*CXArgument, sym *CXArgument, offset
 		if _, found := (*symbols)[sym.Package.Name+"."+sym.Name]; !found {
 			if shouldExist {
 				// it should exist. error
-				println(CompilationError(sym.FileName, sym.FileLine) + " identifier '" + sym.Name + "' does not exist")
+				CompilationErrorAt(sym.FileName, sym.FileLine, sym.Span, DIAG_UNDECLARED, "identifier '" + sym.Name + "' does not exist")
 				return
 			}
 
 			sym.Offset = *offset
 			(*symbols)[sym.Package.Name+"."+sym.Name] = sym
+			markCaptured(sym)
 
-			if sym.IsSlice {
+			if sym.IsCaptured {
+				// the frame holds the address of its heap object
+				*offset += TYPE_POINTER_SIZE
+			} else if sym.IsSlice {
 				*offset += sym.Size
 			} else {
 				*offset += sym.TotalSize
@@ -543,18 +1634,14 @@ func ProcessMethodCall (expr *CXExpression, symbols *map[string]*CXArgument, off
 					} else {
 						// then we found an output
 						if len(out.Fields) > 0 {
-							strct := argOut.CustomType
-
-							if fn, err := strct.Package.GetMethod(strct.Name + "." + out.Fields[len(out.Fields) - 1].Name, strct.Name); err == nil {
+							if fn, err := resolveMethod(out, argOut); err == nil {
 								expr.Operator = fn
 							} else {
-								panic("")
+								panic(err)
 							}
 
 							expr.Inputs = append([]*CXArgument{out}, expr.Inputs...)
 
-							out.Fields = out.Fields[:len(out.Fields) - 1]
-							
 							expr.Outputs = expr.Outputs[1:]
 						}
 					}
@@ -565,38 +1652,22 @@ func ProcessMethodCall (expr *CXExpression, symbols *map[string]*CXArgument, off
 				// then we found an input
 
 				if len(inp.Fields) > 0 {
-					strct := argInp.CustomType
-
-					for _, fld := range inp.Fields {
-						if inFld, err := strct.GetField(fld.Name); err == nil {
-							if inFld.CustomType != nil {
-								strct = inFld.CustomType
-							}
-						}
-					}
-
-					if fn, err := strct.Package.GetMethod(strct.Name + "." + inp.Fields[len(inp.Fields) - 1].Name, strct.Name); err == nil {
+					if fn, err := resolveMethod(inp, argInp); err == nil {
 						expr.Operator = fn
 					} else {
 						panic(err)
 					}
-					
-					inp.Fields = inp.Fields[:len(inp.Fields) - 1]
 				} else if len(out.Fields) > 0 {
 					if argOut, found := (*symbols)[out.Package.Name + "." + out.Name]; found {
-						strct := argOut.CustomType
-
 						expr.Inputs = append(expr.Outputs[:1], expr.Inputs...)
 						
 						expr.Outputs = expr.Outputs[:len(expr.Outputs) - 1]
 						
-						if fn, err := strct.Package.GetMethod(strct.Name + "." + out.Fields[len(out.Fields) - 1].Name, strct.Name); err == nil {
+						if fn, err := resolveMethod(out, argOut); err == nil {
 							expr.Operator = fn
 						} else {
 							panic(err)
 						}
-						
-						out.Fields = out.Fields[:len(out.Fields) - 1]
 					} else {
 						panic("")
 					}
@@ -609,19 +1680,14 @@ func ProcessMethodCall (expr *CXExpression, symbols *map[string]*CXArgument, off
 				} else {
 					// then we found an output
 					if len(out.Fields) > 0 {
-						strct := argOut.CustomType
-
-						if fn, err := strct.Package.GetMethod(strct.Name + "." + out.Fields[len(out.Fields) - 1].Name, strct.Name); err == nil {
+						if fn, err := resolveMethod(out, argOut); err == nil {
 							expr.Operator = fn
 						} else {
-							panic("")
+							panic(err)
 						}
 
 						expr.Inputs = append([]*CXArgument{out}, expr.Inputs...)
 
-						
-						out.Fields = out.Fields[:len(out.Fields) - 1]
-						
 						expr.Outputs = expr.Outputs[1:]
 						// expr.Outputs = nil
 					}
@@ -631,6 +1697,24 @@ func ProcessMethodCall (expr *CXExpression, symbols *map[string]*CXArgument, off
 			}
 		}
 
+		// the output of a nested method call adopts the type of the method's output
+		if len(expr.Outputs) > 0 && expr.Outputs[0].Type == TYPE_UNDEFINED && len(expr.Operator.Outputs) > 0 {
+			out := expr.Outputs[0]
+			methodOut := expr.Operator.Outputs[0]
+
+			out.Type = methodOut.Type
+			out.CustomType = methodOut.CustomType
+			out.Size = methodOut.Size
+			out.TotalSize = methodOut.TotalSize
+			out.Lengths = methodOut.Lengths
+			out.IsSlice = methodOut.IsSlice
+			out.IsPointer = methodOut.IsPointer
+			out.PassBy = methodOut.PassBy
+			out.DeclarationSpecifiers = methodOut.DeclarationSpecifiers
+			out.Inputs = methodOut.Inputs
+			out.Outputs = methodOut.Outputs
+		}
+
 		// checking if receiver is sent as pointer or not
 		if expr.Operator.Inputs[0].IsPointer {
 			expr.Inputs[0].PassBy = PASSBY_REFERENCE
@@ -638,6 +1722,18 @@ func ProcessMethodCall (expr *CXExpression, symbols *map[string]*CXArgument, off
 	}
 }
 
+// ProcessPointerReceiver passes the receiver of a call to a method with a
+// pointer receiver as it is if it's already a pointer, instead of its address
+func ProcessPointerReceiver (expr *CXExpression) {
+	if !expr.IsMethodCall || len(expr.Inputs) == 0 || expr.Inputs[0].PassBy != PASSBY_REFERENCE {
+		return
+	}
+
+	if IsIndexedPointer(GetAssignmentElement(expr.Inputs[0])) {
+		expr.Inputs[0].PassBy = PASSBY_VALUE
+	}
+}
+
 func GiveOffset (symbols *map[string]*CXArgument, sym *CXArgument, offset *int, shouldExist bool) {
 	if sym.Name != "" {
 		if !sym.IsLocalDeclaration {
@@ -655,11 +1751,13 @@ func ProcessTempVariable (expr *CXExpression) {
 	if expr.Operator != nil && (expr.Operator == Natives[OP_IDENTITY] || IsUndOp(expr.Operator)) && len(expr.Outputs) > 0 && len(expr.Inputs) > 0 {
 		name := expr.Outputs[0].Name
 		arg := expr.Outputs[0]
-		if IsTempVar(name) {
+		if IsTempVar(name) && !IsMapElement(arg) && !expr.IsConversion {
 			// then it's a temporary variable and it needs to adopt its input's type
 			arg.Type = expr.Inputs[0].Type
 			arg.Size = expr.Inputs[0].Size
 			arg.TotalSize = expr.Inputs[0].TotalSize
+			arg.Inputs = expr.Inputs[0].Inputs
+			arg.Outputs = expr.Inputs[0].Outputs
 			arg.PreviouslyDeclared = true
 		}
 	}
@@ -671,12 +1769,19 @@ func CopyArgFields (sym *CXArgument, arg *CXArgument) {
 	sym.IndirectionLevels = arg.IndirectionLevels
 
 	sym.IsSlice = arg.IsSlice
+	sym.IsMap = arg.IsMap
+	sym.MapKeyType = arg.MapKeyType
+	sym.IsChan = arg.IsChan
 	sym.CustomType = arg.CustomType
+	sym.NamedType = arg.NamedType
 
 	sym.Lengths = arg.Lengths
 	sym.Package = arg.Package
 	sym.DoesEscape = arg.DoesEscape
+	sym.IsCaptured = arg.IsCaptured
 	sym.Size = arg.Size
+	sym.Inputs = arg.Inputs
+	sym.Outputs = arg.Outputs
 
 	if arg.Type == TYPE_STR {
 		sym.IsPointer = true
@@ -708,10 +1813,12 @@ func CopyArgFields (sym *CXArgument, arg *CXArgument) {
 func ProcessSymbolFields (sym *CXArgument, arg *CXArgument) {
 	if len(sym.Fields) > 0 {
 		if arg.CustomType == nil || len(arg.CustomType.Fields) == 0 {
-			println(CompilationError(sym.FileName, sym.FileLine), fmt.Sprintf("'%s' has no fields", sym.Name))
+			CompilationErrorAt(sym.FileName, sym.FileLine, sym.Span, DIAG_TYPE, fmt.Sprintf("'%s' has no fields", sym.Name))
 			return
 		}
 		
+		sym.Fields = promoteFields(arg.CustomType, sym.Fields)
+
 		// checking if fields do exist in their CustomType
 		// and assigning that CustomType to the sym.Field
 		strct := arg.CustomType
@@ -729,7 +1836,7 @@ func ProcessSymbolFields (sym *CXArgument, arg *CXArgument) {
 				if method, methodErr := strct.Package.GetMethod(receiverType + "." + methodName, receiverType); methodErr == nil {
 					fld.Type = method.Outputs[0].Type
 				} else {
-					println(CompilationError(fld.FileName, fld.FileLine), err.Error())
+					CompilationErrorAt(fld.FileName, fld.FileLine, fld.Span, DIAG_UNDECLARED, err.Error())
 				}
 				
 				
@@ -739,7 +1846,7 @@ func ProcessSymbolFields (sym *CXArgument, arg *CXArgument) {
 		strct = arg.CustomType
 		// then we copy all the type struct fields
 		// to the respective sym.Fields
-		for _, nameFld := range sym.Fields {
+		for i, nameFld := range sym.Fields {
 			if nameFld.CustomType != nil {
 				strct = nameFld.CustomType
 			}
@@ -753,6 +1860,7 @@ func ProcessSymbolFields (sym *CXArgument, arg *CXArgument) {
 					nameFld.DereferenceLevels = sym.DereferenceLevels
 					nameFld.IsPointer = fld.IsPointer
 					nameFld.CustomType = fld.CustomType
+					nameFld.NamedType = fld.NamedType
 					
 					// sym.DereferenceOperations = append(sym.DereferenceOperations, DEREF_FIELD)
 					
@@ -761,8 +1869,20 @@ func ProcessSymbolFields (sym *CXArgument, arg *CXArgument) {
 						nameFld.DereferenceLevels++
 					}
 
+					// a field selected through a pointer field,
+					// e.g. `h.next.val`, is read where it points to
+					if fld.IsPointer && !fld.IsSlice && fld.CustomType != nil && i < len(sym.Fields) - 1 {
+						nameFld.DereferenceOperations = append(nameFld.DereferenceOperations, DEREF_POINTER)
+						nameFld.DereferenceLevels++
+					}
+
 					nameFld.PassBy = fld.PassBy
 					nameFld.IsSlice = fld.IsSlice
+					nameFld.IsMap = fld.IsMap
+					nameFld.MapKeyType = fld.MapKeyType
+					nameFld.IsChan = fld.IsChan
+					nameFld.Inputs = fld.Inputs
+					nameFld.Outputs = fld.Outputs
 					
 					if fld.Type == TYPE_STR || fld.Type == TYPE_AFF {
 						nameFld.PassBy = PASSBY_REFERENCE
@@ -782,6 +1902,75 @@ func ProcessSymbolFields (sym *CXArgument, arg *CXArgument) {
 	}
 }
 
+// promoteFields returns the fields `fields`, selected from a value of
+// `strct`, with the embedded fields the fields and methods promoted from
+// them are selected through
+func promoteFields (strct *CXStruct, fields []*CXArgument) []*CXArgument {
+	var promoted []*CXArgument
+	for i, fld := range fields {
+		if strct == nil {
+			return append(promoted, fields[i:]...)
+		}
+
+		path, err := strct.GetPromoted(fld.Name)
+		if err != nil {
+			CompilationErrorAt(fld.FileName, fld.FileLine, fld.Span, DIAG_UNDECLARED, err.Error())
+		}
+		for _, emb := range path {
+			embFld := MakeArgument(emb.Name, fld.FileName, fld.FileLine)
+			embFld.AddType(TypeNames[TYPE_IDENTIFIER])
+			promoted = append(promoted, embFld)
+			strct = emb.CustomType
+		}
+		promoted = append(promoted, fld)
+
+		if inFld, err := strct.GetField(fld.Name); err == nil {
+			strct = inFld.CustomType
+		} else {
+			strct = nil
+		}
+	}
+	return promoted
+}
+
+// resolveMethod returns the method called through the last field of the
+// receiver `rcvr`, declared as `decl`, and removes it from its fields
+func resolveMethod (rcvr *CXArgument, decl *CXArgument) (*CXFunction, error) {
+	rcvr.Fields = promoteFields(decl.CustomType, rcvr.Fields)
+	last := len(rcvr.Fields) - 1
+
+	// the type of the receiver depends on its indexes, e.g. the elements
+	// of a named slice type are not of the named type
+	typ := *decl
+	typ.Indexes = rcvr.Indexes
+	strct := ReceiverType(&typ)
+
+	for _, fld := range rcvr.Fields[:last] {
+		if strct == nil {
+			break
+		}
+		if strct.Underlying != nil && strct.Underlying.CustomType != nil {
+			// the fields of a named struct type
+			strct = strct.Underlying.CustomType
+		}
+		if inFld, err := strct.GetField(fld.Name); err == nil {
+			typ = *inFld
+			typ.Indexes = fld.Indexes
+			if fldType := ReceiverType(&typ); fldType != nil {
+				strct = fldType
+			}
+		}
+	}
+
+	name := rcvr.Fields[last].Name
+	rcvr.Fields = rcvr.Fields[:last]
+
+	if strct == nil {
+		return nil, fmt.Errorf("method '%s' not found in type '%s'", name, TypeNames[typ.Type])
+	}
+	return strct.Package.GetMethod(strct.Name + "." + name, strct.Name)
+}
+
 func SetFinalSize (symbols *map[string]*CXArgument, sym *CXArgument) {
 	var finalSize int = sym.TotalSize
 
@@ -797,10 +1986,126 @@ func SetFinalSize (symbols *map[string]*CXArgument, sym *CXArgument) {
 
 func GetGlobalSymbol(symbols *map[string]*CXArgument, symPackage *CXPackage, symName string) {
 	if _, found := (*symbols)[symPackage.Name + "." + symName]; !found {
+		if capt := captureSymbol(len(scopes) - 1, symPackage, symName); capt != nil {
+			// then it's a variable of a function enclosing a function literal
+			return
+		}
 		if glbl, err := symPackage.GetGlobal(symName); err == nil {
 			(*symbols)[symPackage.Name + "." + symName] = glbl
+		} else if fn, err := PRGRM.GetFunction(symName, symPackage.Name); err == nil {
+			// then the function is used as a value
+			(*symbols)[symPackage.Name + "." + symName] = FunctionValue(fn)
+		}
+	}
+}
+
+// ProcessConstant inlines the value of the constant that `arg` names, as
+// if it were a literal. Local variables, parameters, outputs and globals
+// shadow the constants of their package, so `arg` must not name any of them
+func ProcessConstant (symbols *map[string]*CXArgument, expr *CXExpression, arg *CXArgument, isInput bool) {
+	if arg.Name == "" || arg.IsLocalDeclaration || arg.IsShortDeclaration {
+		return
+	}
+
+	GetGlobalSymbol(symbols, arg.Package, arg.Name)
+	if _, found := (*symbols)[arg.Package.Name+"."+arg.Name]; found {
+		return
+	}
+
+	cnst, err := arg.Package.GetConstant(arg.Name)
+	if err != nil {
+		return
+	}
+
+	if !isInput {
+		// the constant is still inlined, so it's not reported as undeclared too
+		CompilationErrorAt(arg.FileName, arg.FileLine, arg.Span, DIAG_INVALID, fmt.Sprintf("cannot assign to constant '%s'", arg.Name))
+	}
+
+	if cnst.Type == TYPE_STR && expr.Operator == Natives[OP_IDENTITY] {
+		// the constant was parsed as an identifier, whose value is
+		// copied, but the address of a str literal is assigned
+		for i, inp := range expr.Inputs {
+			if inp == arg && i < len(expr.Outputs) {
+				GetAssignmentElement(expr.Outputs[i]).PassBy = PASSBY_REFERENCE
+			}
+		}
+	}
+
+	inlineConstant(arg, cnst)
+}
+
+// inlineConstant replaces `arg` with a literal holding the value of `cnst`
+func inlineConstant (arg *CXArgument, cnst *CXConstant) {
+	lit := WritePrimary(cnst.Type, cnst.Value, false)[0].Outputs[0]
+	lit.FileName = arg.FileName
+	lit.FileLine = arg.FileLine
+	lit.Span = arg.Span
+	lit.Indexes = arg.Indexes
+	lit.DereferenceOperations = arg.DereferenceOperations
+	lit.DereferenceLevels = arg.DereferenceLevels
+	lit.IsArrayFirst = arg.IsArrayFirst
+	lit.IsUntyped = cnst.IsUntyped
+
+	*arg = *lit
+}
+
+// ProcessUntypedConstants converts the untyped constants inlined in `expr`
+// to the numeric type of the symbol they're assigned to, of the parameter
+// they're sent to or of the other operand of an arithmetic or comparison
+// operator, e.g. N in `var y i64 = N`
+func ProcessUntypedConstants (expr *CXExpression) {
+	if expr.Operator == nil {
+		return
+	}
+
+	switch {
+	case expr.Operator == Natives[OP_IDENTITY]:
+		for i, inp := range expr.Inputs {
+			if i < len(expr.Outputs) && !expr.Outputs[i].IsShortDeclaration {
+				convertUntypedConstant(inp, GetAssignmentElement(expr.Outputs[i]))
+			}
+		}
+	case expr.IsUndType:
+		if len(expr.Inputs) != 2 || expr.Operator.OpCode == OP_UND_BITSHL || expr.Operator.OpCode == OP_UND_BITSHR {
+			return
 		}
+		convertUntypedConstant(expr.Inputs[0], GetAssignmentElement(expr.Inputs[1]))
+		convertUntypedConstant(expr.Inputs[1], GetAssignmentElement(expr.Inputs[0]))
+	default:
+		for i, inp := range expr.Inputs {
+			if i < len(expr.Operator.Inputs) && !expr.Operator.Inputs[i].IsVariadic {
+				convertUntypedConstant(inp, expr.Operator.Inputs[i])
+			}
+		}
+	}
+}
+
+// convertUntypedConstant converts `arg`, if it's an untyped constant, to
+// the numeric type of `to`. Values of any other type are left for
+// CheckTypes to report
+func convertUntypedConstant (arg *CXArgument, to *CXArgument) {
+	if !arg.IsUntyped || to.IsUntyped || to.Type == arg.Type {
+		return
 	}
+	if to.IsMap || to.IsChan || len(to.Lengths) != len(to.Indexes) || (to.IsPointer && to.DereferenceLevels == 0) {
+		return
+	}
+
+	typ := &CXConstant{Type: to.Type}
+	if !isIntConstant(typ) && !isFloatConstant(typ) {
+		return
+	}
+
+	val := &CXConstant{Type: arg.Type, Value: PRGRM.Memory[arg.Offset : arg.Offset+arg.TotalSize]}
+	cnst, err := ConvertConstant(val, to.Type)
+	if err != nil {
+		CompilationErrorAt(arg.FileName, arg.FileLine, arg.Span, DIAG_TYPE, err.Error())
+		// a zero of the expected type, so the types aren't reported as mismatched too
+		cnst = &CXConstant{Type: to.Type, Value: make([]byte, GetArgSize(to.Type))}
+	}
+
+	inlineConstant(arg, cnst)
 }
 
 func PreFinalSize (finalSize *int, sym *CXArgument, arg *CXArgument) {
@@ -0,0 +1,197 @@
+package actions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	. "github.com/skycoin/cx/cx"
+)
+
+// the types of the integer literals with each suffix
+var intLiteralSuffixes = map[string]int{
+	"":   TYPE_I32,
+	"B":  TYPE_BYTE,
+	"H":  TYPE_I16,
+	"L":  TYPE_I64,
+	"UB": TYPE_UI8,
+	"UH": TYPE_UI16,
+	"U":  TYPE_UI32,
+	"UL": TYPE_UI64,
+}
+
+// ParseIntLiteral parses an integer literal, e.g. 1_000, 0xFFL, 0o17 or
+// 0b1010UB, and returns its type and the bits of its value
+func ParseIntLiteral (lit string) (int, uint64, error) {
+	digits := strings.TrimPrefix(lit, "-")
+	isNegative := len(digits) < len(lit)
+
+	base, baseName := 10, "decimal"
+	if len(digits) > 1 && digits[0] == '0' {
+		// `0B` is a byte, so binary literals use a lowercase `b`
+		switch digits[1] {
+		case 'x', 'X':
+			base, baseName = 16, "hexadecimal"
+		case 'o', 'O':
+			base, baseName = 8, "octal"
+		case 'b':
+			base, baseName = 2, "binary"
+		}
+		if base != 10 {
+			digits = digits[2:]
+		}
+	}
+
+	end := 0
+	for end < len(digits) && (isDigit(digits[end], 16) && base == 16 || isDigit(digits[end], 10) || digits[end] == '_') {
+		end++
+	}
+
+	typ, ok := intLiteralSuffixes[digits[end:]]
+	if !ok {
+		return TYPE_I32, 0, fmt.Errorf("invalid suffix '%s' in %s literal '%s'", digits[end:], baseName, lit)
+	}
+	digits = digits[:end]
+
+	if strings.Trim(digits, "_") == "" {
+		return typ, 0, fmt.Errorf("%s literal '%s' has no digits", baseName, lit)
+	}
+	if !checkSeparators(digits, base != 10) {
+		return typ, 0, fmt.Errorf("'_' must separate successive digits in literal '%s'", lit)
+	}
+	digits = strings.Replace(digits, "_", "", -1)
+
+	for i := 0; i < len(digits); i++ {
+		if !isDigit(digits[i], base) {
+			return typ, 0, fmt.Errorf("invalid digit '%c' in %s literal '%s'", digits[i], baseName, lit)
+		}
+	}
+
+	val, err := strconv.ParseUint(digits, base, 64)
+	if err != nil || val > maxIntLiteral(typ, isNegative) {
+		return typ, 0, fmt.Errorf("integer literal '%s' overflows %s", lit, TypeNames[typ])
+	}
+
+	if isNegative {
+		return typ, uint64(-int64(val)), nil
+	}
+	return typ, val, nil
+}
+
+// ParseCharLiteral parses a character literal, e.g. 'a' or '\n', whose type
+// is i32, or byte if it has the suffix `B`, e.g. 'a'B
+func ParseCharLiteral (lit string) (int, uint64, error) {
+	typ := TYPE_I32
+	if strings.HasSuffix(lit, "B") {
+		typ = TYPE_BYTE
+		lit = lit[:len(lit) - 1]
+	}
+
+	if !strings.HasSuffix(lit, "'") || len(lit) < 2 {
+		return typ, 0, fmt.Errorf("unterminated character literal %s", lit)
+	}
+	body := lit[1 : len(lit) - 1]
+	if body == "" {
+		return typ, 0, fmt.Errorf("empty character literal %s", lit)
+	}
+
+	char, _, tail, err := strconv.UnquoteChar(body, '\'')
+	if err != nil {
+		return typ, 0, fmt.Errorf("invalid escape sequence in character literal %s", lit)
+	}
+	if tail != "" {
+		return typ, 0, fmt.Errorf("more than one character in character literal %s", lit)
+	}
+	if typ == TYPE_BYTE && char > 255 {
+		return typ, 0, fmt.Errorf("character literal %sB overflows byte", lit)
+	}
+
+	return typ, uint64(char), nil
+}
+
+// ParseFloatLiteral parses a floating-point literal, e.g. 1_000.5 or 2.5D
+func ParseFloatLiteral (lit string, bitSize int) (float64, error) {
+	digits := strings.TrimSuffix(strings.TrimPrefix(lit, "-"), "D")
+	for _, part := range strings.Split(digits, ".") {
+		if !checkSeparators(part, false) {
+			return 0, fmt.Errorf("'_' must separate successive digits in literal '%s'", lit)
+		}
+	}
+
+	val, err := strconv.ParseFloat(strings.Replace(strings.TrimSuffix(lit, "D"), "_", "", -1), bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("floating-point literal '%s' overflows f%d", lit, bitSize)
+	}
+	return val, nil
+}
+
+// ParseStringLiteral parses a double-quoted string literal, whose escape
+// sequences are replaced by the characters they represent
+func ParseStringLiteral (lit string) (string, error) {
+	if len(lit) < 2 || !strings.HasSuffix(lit, "\"") {
+		return "", fmt.Errorf("unterminated string literal")
+	}
+	str, err := strconv.Unquote(lit)
+	if err != nil {
+		return "", fmt.Errorf("invalid escape sequence in string literal %s", lit)
+	}
+	return str, nil
+}
+
+// ParseRawStringLiteral parses a backquoted string literal, which can span
+// several lines and has no escape sequences
+func ParseRawStringLiteral (lit string) string {
+	lit = strings.TrimPrefix(lit, "`")
+	lit = strings.TrimSuffix(lit, "`")
+	// carriage returns are discarded, like in Go
+	return strings.Replace(lit, "\r", "", -1)
+}
+
+// returns the largest magnitude of an integer literal of type `typ`
+func maxIntLiteral (typ int, isNegative bool) uint64 {
+	bits := uint(GetArgSize(typ) * 8)
+	switch typ {
+	case TYPE_UI8, TYPE_UI16, TYPE_UI32, TYPE_UI64:
+		if isNegative {
+			return 0
+		}
+		return uint64(1) << bits - 1
+	case TYPE_BYTE:
+		// byte literals have always wrapped around, so they only need to
+		// fit in an i32, e.g. 256B is 0B
+		bits = 32
+	}
+	if isNegative {
+		return uint64(1) << (bits - 1)
+	}
+	return uint64(1) << (bits - 1) - 1
+}
+
+// checks if `ch` is a digit in base `base`
+func isDigit (ch byte, base int) bool {
+	var val int
+	switch {
+	case ch >= '0' && ch <= '9':
+		val = int(ch - '0')
+	case ch >= 'a' && ch <= 'f':
+		val = int(ch - 'a') + 10
+	case ch >= 'A' && ch <= 'F':
+		val = int(ch - 'A') + 10
+	default:
+		return false
+	}
+	return val < base
+}
+
+// checks that the `_` separators in `digits` are between digits. They can
+// also come right after the prefix of a hexadecimal, octal or binary literal
+func checkSeparators (digits string, isPrefixed bool) bool {
+	for i := 0; i < len(digits); i++ {
+		if digits[i] != '_' {
+			continue
+		}
+		if i == len(digits) - 1 || digits[i + 1] == '_' || (i == 0 && !isPrefixed) {
+			return false
+		}
+	}
+	return true
+}
@@ -156,6 +156,7 @@ func PrimaryStructLiteralExternal (impName string, ident string, strctFlds []*CX
 	var result []*CXExpression
 	if pkg, err := PRGRM.GetCurrentPackage(); err == nil {
 		if _, err := pkg.GetImport(impName); err == nil {
+			UseImport(pkg, impName)
 			if strct, err := PRGRM.GetStruct(ident, impName); err == nil {
 				for _, expr := range strctFlds {
 					fld := MakeArgument("", CurrentFile, LineNo)
@@ -280,3 +281,152 @@ func ArrayLiteralExpression (arrSize int, typSpec int, exprs []*CXExpression) []
 
 	return result
 }
+
+// MapLiteralExpression inserts the entries of a map literal in a new map.
+// `entries` holds the key and the value expressions of each entry, one after the other
+func MapLiteralExpression (keyTyp int, valSpec *CXArgument, entries [][]*CXExpression) []*CXExpression {
+	var result []*CXExpression
+
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	mapSpec := DeclarationSpecifiersMap(keyTyp, valSpec)
+
+	symName := MakeGenSym(LOCAL_PREFIX)
+
+	// adding the declaration
+	mapVarExpr := MakeExpression(nil, CurrentFile, LineNo)
+	mapVarExpr.Package = pkg
+	mapVar := MakeArgument(symName, CurrentFile, LineNo)
+	copyMapSpec(mapVar, mapSpec)
+	mapVar.Package = pkg
+	mapVar.PreviouslyDeclared = true
+	mapVarExpr.Outputs = append(mapVarExpr.Outputs, mapVar)
+
+	result = append(result, mapVarExpr)
+
+	// the declaration doesn't clear the map if the literal is
+	// evaluated more than once (e.g. in a loop), so we do it here
+	nilMap := WritePrimary(TYPE_I32, encoder.SerializeAtomic(int32(NULL_HEAP_ADDRESS)), false)[0].Outputs[0]
+	nilMap.Type = mapSpec.Type
+	nilMap.CustomType = mapSpec.CustomType
+
+	clrExpr := MakeExpression(Natives[OP_IDENTITY], CurrentFile, LineNo)
+	clrExpr.Package = pkg
+	clrExpr.AddInput(nilMap)
+	clrExpr.AddOutput(PrimaryIdentifier(symName)[0].Outputs[0])
+
+	result = append(result, clrExpr)
+
+	for c := 0; c + 1 < len(entries); c += 2 {
+		key, val := entries[c], entries[c + 1]
+
+		to := PostfixExpressionArray(PrimaryIdentifier(symName), key)
+
+		if val[len(val) - 1].IsStructLiteral {
+			result = append(result, StructLiteralAssignment(to, val)...)
+		} else {
+			result = append(result, Assignment(to, "=", val)...)
+		}
+	}
+
+	symInput := MakeArgument(symName, CurrentFile, LineNo)
+	copyMapSpec(symInput, mapSpec)
+	symInput.Package = pkg
+
+	symOutput := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo)
+	copyMapSpec(symOutput, mapSpec)
+	symOutput.Package = pkg
+	symOutput.PreviouslyDeclared = true
+
+	symExpr := MakeExpression(Natives[OP_IDENTITY], CurrentFile, LineNo)
+	symExpr.Package = pkg
+	symExpr.Outputs = append(symExpr.Outputs, symOutput)
+	symExpr.Inputs = append(symExpr.Inputs, symInput)
+
+	result = append(result, symExpr)
+
+	return result
+}
+
+func copyMapSpec (arg *CXArgument, mapSpec *CXArgument) {
+	arg.Type = mapSpec.Type
+	arg.CustomType = mapSpec.CustomType
+	arg.IsMap = true
+	arg.MapKeyType = mapSpec.MapKeyType
+	arg.Size = TYPE_POINTER_SIZE
+	arg.TotalSize = TYPE_POINTER_SIZE
+	arg.DeclarationSpecifiers = mapSpec.DeclarationSpecifiers
+}
+
+// MakeChanExpression makes a channel of type `chanSpec` with room for the
+// number of elements given by `sizeExprs`, or an unbuffered one if it's nil
+func MakeChanExpression (chanSpec *CXArgument, sizeExprs []*CXExpression) []*CXExpression {
+	var result []*CXExpression
+
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	if !chanSpec.IsChan {
+		CompilationError(CurrentFile, LineNo, DIAG_TYPE, "cannot make a value that is not a channel")
+		// a value is returned anyway so the parser can continue
+		return WritePrimary(TYPE_I32, encoder.SerializeAtomic(int32(0)), false)
+	}
+
+	if sizeExprs == nil {
+		sizeExprs = WritePrimary(TYPE_I32, encoder.SerializeAtomic(int32(0)), false)
+	}
+
+	symName := MakeGenSym(LOCAL_PREFIX)
+
+	// adding the declaration
+	chanVarExpr := MakeExpression(nil, CurrentFile, LineNo)
+	chanVarExpr.Package = pkg
+	chanVar := MakeArgument(symName, CurrentFile, LineNo)
+	copyChanSpec(chanVar, chanSpec)
+	chanVar.Package = pkg
+	chanVar.PreviouslyDeclared = true
+	chanVarExpr.Outputs = append(chanVarExpr.Outputs, chanVar)
+
+	result = append(result, chanVarExpr)
+
+	makeExpr := MakeExpression(Natives[OP_MAKE], CurrentFile, LineNo)
+	makeExpr.Package = pkg
+	result = append(result, FunctionCall([]*CXExpression{makeExpr}, sizeExprs)...)
+
+	makeOutput := MakeArgument(symName, CurrentFile, LineNo)
+	copyChanSpec(makeOutput, chanSpec)
+	makeOutput.Package = pkg
+	makeExpr.AddOutput(makeOutput)
+
+	symInput := MakeArgument(symName, CurrentFile, LineNo)
+	copyChanSpec(symInput, chanSpec)
+	symInput.Package = pkg
+
+	symOutput := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo)
+	copyChanSpec(symOutput, chanSpec)
+	symOutput.Package = pkg
+	symOutput.PreviouslyDeclared = true
+
+	symExpr := MakeExpression(Natives[OP_IDENTITY], CurrentFile, LineNo)
+	symExpr.Package = pkg
+	symExpr.Outputs = append(symExpr.Outputs, symOutput)
+	symExpr.Inputs = append(symExpr.Inputs, symInput)
+
+	result = append(result, symExpr)
+
+	return result
+}
+
+func copyChanSpec (arg *CXArgument, chanSpec *CXArgument) {
+	arg.Type = chanSpec.Type
+	arg.CustomType = chanSpec.CustomType
+	arg.IsChan = true
+	arg.Size = TYPE_POINTER_SIZE
+	arg.TotalSize = TYPE_POINTER_SIZE
+	arg.DeclarationSpecifiers = chanSpec.DeclarationSpecifiers
+}
@@ -1,6 +1,7 @@
 package actions
 
 import (
+	"fmt"
 	. "github.com/skycoin/cx/cx"
 )
 
@@ -185,8 +186,12 @@ func WritePrimary(typ int, byts []byte, isGlobal bool) []*CXExpression {
 			arg.TotalSize = TYPE_POINTER_SIZE
 		}
 
-		for i, byt := range byts {
-			PRGRM.Memory[DataOffset + i] = byt
+		if PRGRM.GrowMemory(DataOffset + size) {
+			for i, byt := range byts {
+				PRGRM.Memory[DataOffset + i] = byt
+			}
+		} else {
+			CompilationError(CurrentFile, LineNo, DIAG_LIMIT, fmt.Sprintf("data segment exceeds the maximum heap size (%d bytes)", PRGRM.MaxHeapSize))
 		}
 		DataOffset += size
 		
@@ -199,9 +204,40 @@ func WritePrimary(typ int, byts []byte, isGlobal bool) []*CXExpression {
 	}
 }
 
-func CompilationError (currentFile string, lineNo int) string {
-	FoundCompileErrors = true
-	return ErrorHeader(currentFile, lineNo)
+// SetSpans sets the span of the expressions and arguments that don't have
+// one yet, which were created by the rule of the grammar whose code is the
+// span
+func SetSpans (exprs []*CXExpression, span Span) {
+	if !span.IsValid() {
+		return
+	}
+	for _, expr := range exprs {
+		if !expr.Span.IsValid() {
+			expr.Span = span
+			expr.FileLine = span.Line
+		}
+		SetArgumentSpans(span, expr.Inputs...)
+		SetArgumentSpans(span, expr.Outputs...)
+	}
+}
+
+// SetArgumentSpans is like SetSpans, for arguments and the arguments of
+// their indexes and fields
+func SetArgumentSpans (span Span, args ...*CXArgument) {
+	if !span.IsValid() {
+		return
+	}
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		if !arg.Span.IsValid() {
+			arg.Span = span
+			arg.FileLine = span.Line
+		}
+		SetArgumentSpans(span, arg.Indexes...)
+		SetArgumentSpans(span, arg.Fields...)
+	}
 }
 
 func TotalLength(lengths []int) int {
@@ -336,6 +372,8 @@ func AffordanceStructs (pkg *CXPackage) {
 
 func PrimaryIdentifier (ident string) []*CXExpression {
 	if pkg, err := PRGRM.GetCurrentPackage(); err == nil {
+		// if `ident` is a constant, it's inlined by ProcessConstant once
+		// the local variables of the function are known
 		arg := MakeArgument(ident, CurrentFile, LineNo)
 		arg.AddType(TypeNames[TYPE_IDENTIFIER])
 		// arg.Typ = "ident"
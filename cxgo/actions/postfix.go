@@ -2,12 +2,13 @@ package actions
 
 import (
 	"fmt"
-	"os"
 	"github.com/skycoin/skycoin/src/cipher/encoder"
 	. "github.com/skycoin/cx/cx"	
 )
 
-func PostfixExpressionArray (prevExprs []*CXExpression, postExprs []*CXExpression) []*CXExpression {
+func PostfixExpressionArray (prevExprs []*CXExpression, postExprs []*CXExpression) (result []*CXExpression) {
+	defer RecoverExpressions(&result, prevExprs)
+
 	var elt *CXArgument
 	if len(prevExprs[len(prevExprs)-1].Outputs[0].Fields) > 0 {
 		elt = prevExprs[len(prevExprs)-1].Outputs[0].Fields[len(prevExprs[len(prevExprs)-1].Outputs[0].Fields) - 1]
@@ -74,6 +75,33 @@ func PostfixExpressionArray (prevExprs []*CXExpression, postExprs []*CXExpressio
 	return prevExprs
 }
 
+// PostfixExpressionSlice slices the value of `prevExprs` from `lo` to `hi`,
+// with a capacity up to `max`, e.g. s[1:3] or s[:2:4]. Any of the bounds
+// can be nil if it was left out
+func PostfixExpressionSlice (prevExprs []*CXExpression, lo, hi, max []*CXExpression) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	if lo == nil {
+		lo = WritePrimary(TYPE_I32, encoder.SerializeAtomic(int32(0)), false)
+	}
+	if hi == nil {
+		hi = WritePrimary(TYPE_I32, encoder.SerializeAtomic(int32(SLICE_BOUND_OMITTED)), false)
+	}
+	if max == nil {
+		max = WritePrimary(TYPE_I32, encoder.SerializeAtomic(int32(SLICE_BOUND_OMITTED)), false)
+	}
+
+	// the slice expression can end in a line after the sliced value
+	expr := MakeExpression(Natives[OP_SLICE], CurrentFile, prevExprs[len(prevExprs) - 1].FileLine)
+	expr.Package = pkg
+
+	args := append(append(append(prevExprs, lo...), hi...), max...)
+	return FunctionCall([]*CXExpression{expr}, args)
+}
+
 func PostfixExpressionNative (typCode int, opStrCode string) []*CXExpression {
 	// these will always be native functions
 	if opCode, ok := OpCodes[TypeNames[typCode]+"."+opStrCode]; ok {
@@ -86,13 +114,17 @@ func PostfixExpressionNative (typCode int, opStrCode string) []*CXExpression {
 
 		return []*CXExpression{expr}
 	} else {
-		println(CompilationError(CurrentFile, LineNo) + " function '" + TypeNames[typCode]+"."+opStrCode + "' does not exist")
+		CompilationError(CurrentFile, LineNo, DIAG_UNDECLARED, "function '" + TypeNames[typCode]+"."+opStrCode + "' does not exist")
 		return nil
 		// panic(ok)
 	}
 }
 
 func PostfixExpressionEmptyFunCall (prevExprs []*CXExpression) []*CXExpression {
+	if producesFunctionValue(prevExprs[len(prevExprs) - 1]) {
+		return functionValueCall(prevExprs, nil)
+	}
+
 	if prevExprs[len(prevExprs) - 1].Outputs != nil && len(prevExprs[len(prevExprs) - 1].Outputs[0].Fields) > 0 {
 		// then it's a method call or function in field
 		// prevExprs[len(prevExprs) - 1].IsMethodCall = true
@@ -121,6 +153,14 @@ func PostfixExpressionEmptyFunCall (prevExprs []*CXExpression) []*CXExpression {
 }
 
 func PostfixExpressionFunCall (prevExprs []*CXExpression, args []*CXExpression) []*CXExpression {
+	if producesFunctionValue(prevExprs[len(prevExprs) - 1]) {
+		return functionValueCall(prevExprs, args)
+	}
+
+	if typ := conversionType(prevExprs[len(prevExprs) - 1]); typ != nil {
+		return conversion(typ, args)
+	}
+
 	if prevExprs[len(prevExprs) - 1].Outputs != nil && len(prevExprs[len(prevExprs) - 1].Outputs[0].Fields) > 0 {
 		// then it's a method
 		// prevExprs[len(prevExprs) - 1].IsMethodCall = true
@@ -140,6 +180,106 @@ func PostfixExpressionFunCall (prevExprs []*CXExpression, args []*CXExpression)
 	return FunctionCall(prevExprs, args)
 }
 
+// PostfixExpressionConversion converts the value of `args` to the basic
+// type `typ`, e.g. f64(m)
+func PostfixExpressionConversion (typ int, args []*CXExpression) []*CXExpression {
+	return conversion(DeclarationSpecifiersBasic(typ), args)
+}
+
+// PostfixExpressionSliceConversion converts the value of `args` to a slice
+// of the basic type `typ`. Only strings can be converted, to byte slices
+// holding a copy of their bytes, e.g. []byte(str)
+func PostfixExpressionSliceConversion (typ int, args []*CXExpression) []*CXExpression {
+	if typ != TYPE_BYTE {
+		CompilationError(CurrentFile, LineNo, DIAG_TYPE, fmt.Sprintf("cannot convert to type '[]%s'", TypeNames[typ]))
+		return nil
+	}
+
+	exprs := conversion(DeclarationSpecifiers(DeclarationSpecifiersBasic(typ), 0, DECL_SLICE), args)
+	exprs[len(exprs) - 1].Operator = Natives[OP_STR_BYTES]
+	return exprs
+}
+
+// conversionType returns the type of the named type or alias called by
+// `expr`, e.g. Meters(x), or nil if it's not a type
+func conversionType (expr *CXExpression) *CXArgument {
+	if expr.Operator != nil || len(expr.Outputs) == 0 || expr.Outputs[0].Fields != nil {
+		return nil
+	}
+
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+	if strct, err := PRGRM.GetStruct(expr.Outputs[0].Name, pkg.Name); err != nil || strct.Underlying == nil {
+		return nil
+	}
+	return DeclarationSpecifiersStruct(expr.Outputs[0].Name, "", false)
+}
+
+// conversion converts the value of `args` to the type `typ`. The value is
+// copied to a temporary variable of that type
+func conversion (typ *CXArgument, args []*CXExpression) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	expr := MakeExpression(Natives[OP_IDENTITY], CurrentFile, LineNo)
+	expr.Package = pkg
+	expr.IsConversion = true
+
+	exprs := FunctionCall([]*CXExpression{expr}, args)
+
+	typ.Name = MakeGenSym(LOCAL_PREFIX)
+	typ.Package = pkg
+	typ.PreviouslyDeclared = true
+	expr.AddOutput(typ)
+
+	return exprs
+}
+
+// PostfixExpressionSpreadCall is a call whose last argument is a slice
+// passed as the variadic parameter of the function, e.g. f(xs...)
+func PostfixExpressionSpreadCall (prevExprs []*CXExpression, args []*CXExpression) []*CXExpression {
+	exprs := PostfixExpressionFunCall(prevExprs, args)
+	exprs[len(exprs) - 1].IsSpreadCall = true
+	return exprs
+}
+
+// producesFunctionValue checks if `expr` is a function literal or a
+// function call, whose output can be called
+func producesFunctionValue (expr *CXExpression) bool {
+	return expr.Operator == Natives[OP_CLOSURE] || (functionCalls[expr] && len(expr.Outputs) == 0)
+}
+
+// functionValueCall calls the function value produced by the last of `prevExprs`
+func functionValueCall (prevExprs []*CXExpression, args []*CXExpression) []*CXExpression {
+	prevExpr := prevExprs[len(prevExprs) - 1]
+
+	out := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, prevExpr.FileLine).AddType(TypeNames[TYPE_FUNC])
+	out.Package = prevExpr.Package
+	out.PreviouslyDeclared = true
+	out.DeclarationSpecifiers = []int{DECL_BASIC}
+
+	if prevExpr.Operator == Natives[OP_CLOSURE] {
+		out.Inputs = prevExpr.Inputs[0].Inputs
+		out.Outputs = prevExpr.Inputs[0].Outputs
+	} else if !prevExpr.Operator.IsNative && len(prevExpr.Operator.Outputs) > 0 {
+		out.Inputs = prevExpr.Operator.Outputs[0].Inputs
+		out.Outputs = prevExpr.Operator.Outputs[0].Outputs
+	}
+	// calls through function values have their outputs typed by ProcessFuncCall
+
+	prevExpr.AddOutput(out)
+
+	expr := MakeExpression(Natives[OP_CALL], CurrentFile, LineNo)
+	expr.Package = prevExpr.Package
+	expr.AddInput(out)
+
+	return FunctionCall(append(prevExprs, expr), args)
+}
+
 func PostfixExpressionIncDec (prevExprs []*CXExpression, isInc bool) []*CXExpression {
 	pkg, err := PRGRM.GetCurrentPackage()
 	if err != nil {
@@ -166,6 +306,24 @@ func PostfixExpressionIncDec (prevExprs []*CXExpression, isInc bool) []*CXExpres
 	return exprs
 }
 
+// TypeAssertion asserts that the interface value of `prevExprs` holds a
+// value of the type `typ`. `typ` is the first input, so the temporary
+// variables created for its output adopt its type (see FunctionCall)
+func TypeAssertion (prevExprs []*CXExpression, typ *CXArgument) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	expr := MakeExpression(Natives[OP_TYPE_ASSERT], CurrentFile, LineNo)
+	expr.Package = pkg
+
+	typ.Package = pkg
+	expr.AddInput(typ)
+
+	return FunctionCall([]*CXExpression{expr}, prevExprs)
+}
+
 func PostfixExpressionField (prevExprs []*CXExpression, ident string) {
 	left := prevExprs[len(prevExprs)-1].Outputs[0]
 
@@ -185,6 +343,7 @@ func PostfixExpressionField (prevExprs []*CXExpression, ident string) {
 			if imp, err := pkg.GetImport(left.Name); err == nil {
 				// the external property will be propagated to the following arguments
 				// this way we avoid considering these arguments as module names
+				UseImport(pkg, left.Name)
 
 				if IsCorePackage(left.Name) {
 					if code, ok := ConstCodes[left.Name+"."+ident]; ok {
@@ -203,6 +362,13 @@ func PostfixExpressionField (prevExprs []*CXExpression, ident string) {
 
 				left.Package = imp
 
+				if cnst, err := imp.GetConstant(ident); err == nil {
+					// then it's a constant, which is inlined
+					val := WritePrimary(cnst.Type, cnst.Value, false)
+					prevExprs[len(prevExprs)-1].Outputs[0] = val[0].Outputs[0]
+					return
+				}
+
 				if glbl, err := imp.GetGlobal(ident); err == nil {
 					// then it's a global
 					// prevExprs[len(prevExprs)-1].Outputs[0] = glbl
@@ -228,8 +394,7 @@ func PostfixExpressionField (prevExprs []*CXExpression, ident string) {
 			} else {
 				// then left is not a package name
 				if IsCorePackage(left.Name) {
-					println(CompilationError(left.FileName, left.FileLine), fmt.Sprintf("identifier '%s' does not exist", left.Name))
-					os.Exit(CX_COMPILATION_ERROR)
+					CompilationErrorAt(left.FileName, left.FileLine, left.Span, DIAG_UNDECLARED, fmt.Sprintf("identifier '%s' does not exist", left.Name))
 					return
 				}
 
@@ -2,6 +2,7 @@ package actions
 
 import (
 	. "github.com/skycoin/cx/cx"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
 )
 
 // used for selection_statement to layout its outputs
@@ -9,6 +10,10 @@ type SelectStatement struct {
 	Condition []*CXExpression
 	Then      []*CXExpression
 	Else      []*CXExpression
+	// the types of a type switch clause
+	Types []*CXArgument
+	// the code of the keyword of a switch clause
+	Span Span
 }
 
 func SelectionStatement(predExprs []*CXExpression, thenExprs []*CXExpression, elseifExprs []SelectStatement, elseExprs []*CXExpression, op int) []*CXExpression {
@@ -39,3 +44,333 @@ func SelectionStatement(predExprs []*CXExpression, thenExprs []*CXExpression, el
 
 	panic("")
 }
+
+// SwitchStatement lowers a switch statement to a chain of selection
+// statements. If tagExprs is nil, it's a tagless switch and each case
+// value is used as a predicate
+func SwitchStatement(tagExprs []*CXExpression, clauses []SelectStatement) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	var exprs []*CXExpression
+	var tagName string
+
+	if tagExprs != nil {
+		// the tag is evaluated only once, so we save it in a temporary variable
+		tagExpr := tagExprs[len(tagExprs)-1]
+
+		tag := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo)
+		tag.Package = pkg
+		tag.PreviouslyDeclared = true
+
+		if tagExpr.Operator == nil {
+			// then it's a literal or an identifier
+			// we can't know its type until we compile the full function
+			tag.AddType(TypeNames[tagExpr.Outputs[0].Type])
+			tag.PassBy = tagExpr.Outputs[0].PassBy
+
+			expr := MakeExpression(Natives[OP_IDENTITY], CurrentFile, LineNo)
+			expr.Package = pkg
+			expr.IsUndType = true
+
+			expr.AddInput(tagExpr.Outputs[0])
+			expr.AddOutput(tag)
+
+			tagExprs = append(tagExprs[:len(tagExprs)-1], expr)
+		} else if len(tagExpr.Outputs) < 1 {
+			// then it's an expression
+			if tagExpr.Operator.Outputs[0].Type == TYPE_UNDEFINED {
+				tag.AddType(TypeNames[tagExpr.Inputs[0].Type])
+			} else {
+				tag.AddType(TypeNames[tagExpr.Operator.Outputs[0].Type])
+				tag.CustomType = tagExpr.Operator.Outputs[0].CustomType
+			}
+			tag.Size = tagExpr.Operator.Outputs[0].Size
+			tag.TotalSize = tagExpr.Operator.Outputs[0].Size
+
+			tagExpr.AddOutput(tag)
+		} else {
+			tag = tagExpr.Outputs[0]
+		}
+
+		tagName = tag.Name
+		exprs = append(exprs, tagExprs...)
+	}
+
+	var cases []SelectStatement
+	var defaultExprs []*CXExpression
+	var foundDefault bool
+
+	for _, clause := range clauses {
+		if clause.Condition == nil {
+			if foundDefault {
+				CompilationErrorAt(CurrentFile, LineNo, clause.Span, DIAG_INVALID, "multiple defaults in switch statement")
+			}
+			foundDefault = true
+			defaultExprs = clause.Then
+			continue
+		}
+
+		// a case with several values is matched if any of them is matched
+		var predExprs []*CXExpression
+		for _, value := range valueExpressions(clause.Condition) {
+			if tagName != "" {
+				value = UndefinedTypeOperation(PrimaryIdentifier(tagName), value, Natives[OP_UND_EQUAL])
+				value[len(value)-1].IsSwitchCase = true
+			}
+			if predExprs == nil {
+				predExprs = value
+			} else {
+				predExprs = UndefinedTypeOperation(predExprs, value, Natives[OP_BOOL_OR])
+			}
+		}
+
+		cases = append(cases, SelectStatement{
+			Condition: predExprs,
+			Then: clause.Then,
+		})
+	}
+
+	var lastElse []*CXExpression = defaultExprs
+	for c := len(cases) - 1; c >= 0; c-- {
+		lastElse = SelectionExpressions(cases[c].Condition, cases[c].Then, lastElse)
+	}
+
+	exprs = append(exprs, lastElse...)
+
+	// processing possible breaks, which jump to the end of the switch statement
+	for i, expr := range exprs {
+		if expr.IsBreak {
+			expr.ThenLines = len(exprs) - i - 1
+			expr.IsBreak = false
+		}
+	}
+
+	return exprs
+}
+
+// TypeSwitchStatement lowers a type switch statement. The interface value is
+// saved in a temporary variable, and the type assertions of all the cases
+// are made before a chain of selection statements choosing the first case
+// whose assertion succeeded. If `ident` is not empty, each clause gets its
+// own variable for it, holding the asserted value if the clause has a single
+// type, or the interface value otherwise
+func TypeSwitchStatement(ident string, subjectExprs []*CXExpression, clauses []SelectStatement) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	subject := MakeGenSym(LOCAL_PREFIX)
+	exprs := Assignment(PrimaryIdentifier(subject), ":=", subjectExprs)
+
+	var cases []SelectStatement
+	var defaultExprs []*CXExpression
+	var foundDefault bool
+
+	for _, clause := range clauses {
+		var binding string
+		if ident != "" {
+			binding = MakeGenSym(LOCAL_PREFIX)
+		}
+
+		if clause.Types == nil {
+			if foundDefault {
+				CompilationErrorAt(CurrentFile, LineNo, clause.Span, DIAG_INVALID, "multiple defaults in switch statement")
+			}
+			foundDefault = true
+			if binding != "" {
+				renameVariable(clause.Then, ident, binding)
+				defaultExprs = append(Assignment(PrimaryIdentifier(binding), ":=", PrimaryIdentifier(subject)), clause.Then...)
+			} else {
+				defaultExprs = clause.Then
+			}
+			continue
+		}
+
+		// a case with several types is matched if any of them is matched
+		var predExprs []*CXExpression
+		for _, typ := range clause.Types {
+			name := binding
+			if name == "" || len(clause.Types) > 1 {
+				name = MakeGenSym(LOCAL_PREFIX)
+			}
+			val := *typ
+			exprs = append(exprs, DeclareLocal(MakeArgument(name, CurrentFile, LineNo), &val, nil, false)...)
+
+			ok := MakeGenSym(LOCAL_PREFIX)
+			exprs = append(exprs, DeclareLocal(MakeArgument(ok, CurrentFile, LineNo), DeclarationSpecifiersBasic(TYPE_BOOL), nil, false)...)
+
+			holder := *typ
+			holder.Package = pkg
+
+			expr := MakeExpression(Natives[OP_TYPE_ASSERT], CurrentFile, LineNo)
+			expr.Package = pkg
+			expr.AddInput(&holder)
+			expr.AddInput(PrimaryIdentifier(subject)[0].Outputs[0])
+			expr.AddOutput(PrimaryIdentifier(name)[0].Outputs[0])
+			expr.AddOutput(PrimaryIdentifier(ok)[0].Outputs[0])
+			exprs = append(exprs, expr)
+
+			if predExprs == nil {
+				predExprs = PrimaryIdentifier(ok)
+			} else {
+				predExprs = UndefinedTypeOperation(predExprs, PrimaryIdentifier(ok), Natives[OP_BOOL_OR])
+			}
+		}
+
+		thenExprs := clause.Then
+		if binding != "" {
+			renameVariable(thenExprs, ident, binding)
+			if len(clause.Types) > 1 {
+				thenExprs = append(Assignment(PrimaryIdentifier(binding), ":=", PrimaryIdentifier(subject)), thenExprs...)
+			}
+		}
+
+		cases = append(cases, SelectStatement{
+			Condition: predExprs,
+			Then: thenExprs,
+		})
+	}
+
+	var lastElse []*CXExpression = defaultExprs
+	for c := len(cases) - 1; c >= 0; c-- {
+		lastElse = SelectionExpressions(cases[c].Condition, cases[c].Then, lastElse)
+	}
+
+	exprs = append(exprs, lastElse...)
+
+	// processing possible breaks, which jump to the end of the switch statement
+	for i, expr := range exprs {
+		if expr.IsBreak {
+			expr.ThenLines = len(exprs) - i - 1
+			expr.IsBreak = false
+		}
+	}
+
+	return exprs
+}
+
+// renames the variable `name` used by `exprs`, and by the function literals
+// in them, to `newName`
+func renameVariable(exprs []*CXExpression, name, newName string) {
+	for _, expr := range exprs {
+		for _, arg := range append(expr.Inputs, expr.Outputs...) {
+			renameArgument(arg, name, newName)
+		}
+		if lit, found := functionLiterals[expr]; found {
+			renameVariable(lit.body, name, newName)
+		}
+	}
+}
+
+func renameArgument(arg *CXArgument, name, newName string) {
+	if arg.Name == name {
+		arg.Name = newName
+	}
+	for _, idx := range arg.Indexes {
+		renameArgument(idx, name, newName)
+	}
+	for _, fld := range arg.Fields {
+		for _, idx := range fld.Indexes {
+			renameArgument(idx, name, newName)
+		}
+	}
+}
+
+// SelectExpressions lowers a select statement. The select operator chooses
+// one of the cases, or waits until one of them can proceed, and it's followed
+// by an if-else chain that runs the chosen case's send or receive and its
+// statements. A clause with no condition is the default case
+func SelectExpressions(clauses []SelectStatement) []*CXExpression {
+	pkg, err := PRGRM.GetCurrentPackage()
+	if err != nil {
+		panic(err)
+	}
+
+	var exprs []*CXExpression
+
+	// the line of the first case, as the parser is past the statement
+	line := LineNo
+	if len(clauses) > 0 && len(clauses[0].Condition) > 0 {
+		line = clauses[0].Condition[0].FileLine
+	}
+
+	selectExpr := MakeExpression(Natives[OP_SELECT], CurrentFile, line)
+	selectExpr.Package = pkg
+
+	var cases []SelectStatement
+	var defaultExprs []*CXExpression
+	var foundDefault bool
+
+	for _, clause := range clauses {
+		if clause.Condition == nil {
+			if foundDefault {
+				CompilationErrorAt(CurrentFile, LineNo, clause.Span, DIAG_INVALID, "multiple defaults in select statement")
+			}
+			foundDefault = true
+			defaultExprs = clause.Then
+			continue
+		}
+
+		// the channel operation is the last send or receive of the case
+		commIdx := -1
+		for i, expr := range clause.Condition {
+			if expr.Operator == Natives[OP_SEND] || expr.Operator == Natives[OP_RECV] {
+				commIdx = i
+			}
+		}
+		if commIdx < 0 {
+			CompilationErrorAt(clause.Condition[0].FileName, clause.Condition[0].FileLine, clause.Condition[0].Span, DIAG_INVALID, "select case must be receive, send or assign recv")
+			continue
+		}
+		comm := clause.Condition[commIdx]
+
+		// the declarations of a short declaration stay next to the receive
+		start := commIdx
+		for start > 0 && clause.Condition[start - 1].Operator == nil {
+			start--
+		}
+
+		// the channel and the value to send are evaluated before choosing
+		exprs = append(exprs, clause.Condition[:start]...)
+
+		isSend := WritePrimary(TYPE_BOOL, encoder.Serialize(comm.Operator == Natives[OP_SEND]), false)
+		selectExpr.AddInput(isSend[0].Outputs[0])
+		selectExpr.AddInput(copyRangeArgument(comm.Inputs[0], -1, ""))
+
+		cases = append(cases, SelectStatement{
+			Then: append(clause.Condition[start:], clause.Then...),
+		})
+	}
+
+	hasDefault := WritePrimary(TYPE_BOOL, encoder.Serialize(foundDefault), false)
+	selectExpr.Inputs = append([]*CXArgument{hasDefault[0].Outputs[0]}, selectExpr.Inputs...)
+
+	idx := MakeArgument(MakeGenSym(LOCAL_PREFIX), CurrentFile, LineNo).AddType(TypeNames[TYPE_I32])
+	idx.Package = pkg
+	idx.PreviouslyDeclared = true
+	selectExpr.AddOutput(idx)
+
+	exprs = append(exprs, selectExpr)
+
+	var lastElse []*CXExpression = defaultExprs
+	for c := len(cases) - 1; c >= 0; c-- {
+		predExprs := UndefinedTypeOperation(PrimaryIdentifier(idx.Name), WritePrimary(TYPE_I32, encoder.SerializeAtomic(int32(c)), false), Natives[OP_UND_EQUAL])
+		lastElse = SelectionExpressions(predExprs, cases[c].Then, lastElse)
+	}
+
+	exprs = append(exprs, lastElse...)
+
+	// processing possible breaks, which jump to the end of the select statement
+	for i, expr := range exprs {
+		if expr.IsBreak {
+			expr.ThenLines = len(exprs) - i - 1
+			expr.IsBreak = false
+		}
+	}
+
+	return exprs
+}
@@ -9,6 +9,16 @@
 	)
 
 	// var PRGRM = MakeProgram(CALLSTACK_SIZE, STACK_SIZE, INIT_HEAP_SIZE)
+
+	// ruleSpan returns the code of the rule being reduced, from the start of
+	// its first symbol to its last token. The last token is the one before
+	// the lookahead if the parser has already read it
+	func ruleSpan (first Span, lookahead int) Span {
+		if lookahead >= 0 {
+			return JoinSpans(first, lastTokenSpans[0])
+		}
+		return JoinSpans(first, lastTokenSpans[1])
+	}
 	
 %}
 
@@ -17,6 +27,11 @@
 	byt byte
 	i32 int32
 	i64 int64
+	i16 int16
+	ui8 uint8
+	ui16 uint16
+	ui32 uint32
+	ui64 uint64
 	f32 float32
 	f64 float64
 	tok string
@@ -25,6 +40,7 @@
 	stringA []string
 
 	line int
+	span Span
 
 	argument *CXArgument
 	arguments []*CXArgument
@@ -44,12 +60,17 @@
 %token  <bool>          BOOLEAN_LITERAL
 %token  <i32>           INT_LITERAL
 %token  <i64>           LONG_LITERAL
+%token  <i16>           SHORT_LITERAL
+%token  <ui8>           UNSIGNED_BYTE_LITERAL
+%token  <ui16>          UNSIGNED_SHORT_LITERAL
+%token  <ui32>          UNSIGNED_INT_LITERAL
+%token  <ui64>          UNSIGNED_LONG_LITERAL
 %token  <f32>           FLOAT_LITERAL
 %token  <f64>           DOUBLE_LITERAL
 %token  <tok>           FUNC OP LPAREN RPAREN LBRACE RBRACE LBRACK RBRACK IDENTIFIER
-                        VAR COMMA PERIOD COMMENT STRING_LITERAL PACKAGE IF ELSE FOR TYPSTRUCT STRUCT
+                        VAR COMMA PERIOD ELLIPSIS COMMENT STRING_LITERAL PACKAGE IF ELSE FOR TYPSTRUCT STRUCT
                         SEMICOLON NEWLINE
-                        ASSIGN CASSIGN IMPORT RETURN GOTO GT_OP LT_OP GTEQ_OP LTEQ_OP EQUAL COLON NEW
+                        ASSIGN CASSIGN IMPORT RETURN GOTO GO DEFER GT_OP LT_OP GTEQ_OP LTEQ_OP EQUAL COLON NEW
                         EQUALWORD GTHANWORD LTHANWORD
                         GTHANEQ LTHANEQ UNEQUAL AND OR
                         ADD_OP SUB_OP MUL_OP DIV_OP MOD_OP REF_OP NEG_OP AFFVAR
@@ -69,7 +90,7 @@
                         STR
                         UI8 UI16 UI32 UI64
                         UNION ENUM CONST CASE DEFAULT SWITCH BREAK CONTINUE
-                        TYPE
+                        TYPE MAP RANGE CHAN MAKE SELECT ARROW INTERFACE
                         
                         /* Types */
                         BASICTYPE
@@ -96,13 +117,23 @@
 %type   <argument>      parameter_declaration
 %type   <arguments>     parameter_type_list
 %type   <arguments>     function_parameters
+%type   <arguments>     function_results
+%type   <arguments>     function_type_parameters
+%type   <arguments>     function_type_results
+%type   <arguments>     type_list
+%type   <argument>      variadic_parameter
+%type   <argument>      embedded_field
 %type   <arguments>     parameter_list
 %type   <arguments>     fields
 %type   <arguments>     struct_fields
+%type   <argument>      method_signature
+%type   <arguments>     method_signatures
+%type   <arguments>     interface_methods
 
 /* %type   <stringA>       package_identifier */
                                 
 %type   <expressions>   assignment_expression
+%type   <expressions>   slice_bound
 %type   <expressions>   constant_expression
 %type   <expressions>   conditional_expression
 %type   <expressions>   logical_or_expression
@@ -128,11 +159,20 @@
 %type   <expressions>   slice_literal_expression_list
 %type   <expressions>   slice_literal_expression
 
+%type   <arrayArguments>   map_literal_entries
+%type   <expressions>   map_literal_expression
+
 %type   <expressions>   selector
 
 %type   <expressions>   struct_literal_fields
 %type   <SelectStatement>   elseif
 %type   <SelectStatements>   elseif_list
+%type   <SelectStatement>   switch_clause
+%type   <SelectStatements>   switch_clause_list
+%type   <SelectStatement>   type_switch_clause
+%type   <SelectStatements>   type_switch_clause_list
+%type   <SelectStatement>   select_clause
+%type   <SelectStatements>   select_clause_list
 
 %type   <expressions>   declaration
 //                      %type   <expressions>   init_declarator_list
@@ -150,9 +190,13 @@
 %type   <expressions>   selection_statement
 %type   <expressions>   iteration_statement
 %type   <expressions>   jump_statement
+%type   <expressions>   go_statement
+%type   <expressions>   defer_statement
+%type   <expressions>   send_expression
 %type   <expressions>   statement
 
 %type   <function>      function_header
+%type   <function>      function_literal_header
 
 //                      %type   <stringA>       infer_action, infer_actions
 %type   <string>        infer_action_arg
@@ -176,10 +220,14 @@ translation_unit:
 external_declaration:
                 package_declaration
         |       global_declaration
+        |       constant_declaration
         |       function_declaration
         |       import_declaration
         |       struct_declaration
+        |       interface_declaration
+        |       named_type_declaration
                 
+        |       error SEMICOLON
         |       stepping
         |       selector
         |       debugging
@@ -266,6 +314,33 @@ selector:
                 }
         ;
 
+// constants are declared in the first pass (cxgo0)
+constant_declaration:
+                CONST constant_specification
+        |       CONST LPAREN constant_specification_list RPAREN SEMICOLON
+        |       ENUM LBRACE enum_list RBRACE SEMICOLON
+                ;
+
+constant_specification:
+                IDENTIFIER ASSIGN constant_expression SEMICOLON
+        |       IDENTIFIER type_specifier ASSIGN constant_expression SEMICOLON
+                ;
+
+constant_specification_list:
+                constant_specification
+        |       constant_specification_list constant_specification
+                ;
+
+enum_list:
+                enum_specification
+        |       enum_list enum_specification
+                ;
+
+enum_specification:
+                IDENTIFIER SEMICOLON
+        |       IDENTIFIER ASSIGN constant_expression SEMICOLON
+                ;
+
 global_declaration:
                 VAR declarator declaration_specifiers SEMICOLON
                 {
@@ -280,6 +355,7 @@ global_declaration:
 struct_declaration:
                 TYPE IDENTIFIER STRUCT struct_fields
                 {
+			CheckEmbeddedFields($2, $4)
 			DeclareStruct($2, $4)
                 }
                 ;
@@ -295,10 +371,86 @@ fields:         parameter_declaration SEMICOLON
                 {
 			$$ = []*CXArgument{$1}
                 }
+        |       embedded_field SEMICOLON
+                {
+			$$ = []*CXArgument{$1}
+                }
         |       fields parameter_declaration SEMICOLON
                 {
 			$$ = append($1, $2)
                 }
+        |       fields embedded_field SEMICOLON
+                {
+			$$ = append($1, $2)
+                }
+        ;
+
+embedded_field:
+                IDENTIFIER
+                {
+			$$ = EmbeddedField(DeclarationSpecifiersStruct($1, "", false))
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        |       IDENTIFIER PERIOD IDENTIFIER
+                {
+			$$ = EmbeddedField(DeclarationSpecifiersStruct($3, $1, true))
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        ;
+
+interface_declaration:
+                TYPE IDENTIFIER INTERFACE interface_methods
+                {
+			CheckInterfaceMethods($2, $4)
+			DeclareInterface($2, $4)
+                }
+                ;
+
+named_type_declaration:
+                TYPE IDENTIFIER declaration_specifiers SEMICOLON
+                {
+			DeclareNamedType($2, $3, false)
+                }
+        |       TYPE IDENTIFIER ASSIGN declaration_specifiers SEMICOLON
+                {
+			DeclareNamedType($2, $4, true)
+                }
+                ;
+
+interface_methods:
+                LBRACE RBRACE SEMICOLON
+                { $$ = nil }
+        |       LBRACE method_signatures RBRACE SEMICOLON
+                { $$ = $2 }
+        ;
+
+method_signatures:
+                method_signature SEMICOLON
+                {
+			$$ = []*CXArgument{$1}
+                }
+        |       method_signatures method_signature SEMICOLON
+                {
+			$$ = append($1, $2)
+                }
+        ;
+
+method_signature:
+                IDENTIFIER function_type_parameters
+                {
+			$$ = MethodSignature($1, $2, nil)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        |       IDENTIFIER function_type_parameters function_type_results
+                {
+			$$ = MethodSignature($1, $2, $3)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        |       IDENTIFIER function_type_parameters declaration_specifiers
+                {
+			$$ = MethodSignature($1, $2, []*CXArgument{$3})
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
         ;
 
 package_declaration:
@@ -322,7 +474,7 @@ function_header:
 			$$ = FunctionHeader($2, nil, false)
 			InFn = true
                 }
-        |       FUNC LPAREN parameter_type_list RPAREN IDENTIFIER
+        |       FUNC LPAREN parameter_list RPAREN IDENTIFIER
                 {
 			$$ = FunctionHeader($5, $3, true)
 			InFn = true
@@ -336,13 +488,31 @@ function_parameters:
                 { $$ = $2 }
                 ;
 
+function_results:
+                LPAREN RPAREN
+                { $$ = nil }
+        |       LPAREN parameter_list RPAREN
+                { $$ = $2 }
+                ;
+
+function_literal_header:
+                FUNC function_parameters
+                {
+			$$ = FunctionLiteralHeader($2, nil)
+                }
+        |       FUNC function_parameters function_results
+                {
+			$$ = FunctionLiteralHeader($2, $3)
+                }
+        ;
+
 function_declaration:
                 function_header function_parameters compound_statement
                 {
 			FunctionDeclaration($1, $2, nil, $3)
 			InFn = false
                 }
-        |       function_header function_parameters function_parameters compound_statement
+        |       function_header function_parameters function_results compound_statement
                 {
 			FunctionDeclaration($1, $2, $3, $4)
 			InFn = false
@@ -351,6 +521,24 @@ function_declaration:
 
 parameter_type_list:
 		parameter_list
+        |       variadic_parameter
+                {
+			$$ = []*CXArgument{$1}
+                }
+        |       parameter_list COMMA variadic_parameter
+                {
+			$$ = append($1, $3)
+                }
+                ;
+
+variadic_parameter:
+                declarator ELLIPSIS declaration_specifiers
+                {
+			$3.Name = $1.Name
+			$3.Package = $1.Package
+			$$ = VariadicParameter($3)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
                 ;
 
 parameter_list:
@@ -388,6 +576,7 @@ direct_declarator:
 			} else {
 				panic(err)
 			}
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
                 }
 	|       LPAREN declarator RPAREN
                 { $$ = $2 }
@@ -429,30 +618,67 @@ declaration_specifiers:
                 MUL_OP declaration_specifiers
                 {
 			$$ = DeclarationSpecifiers($2, 0, DECL_POINTER)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
                 }
         |       LBRACK INT_LITERAL RBRACK declaration_specifiers
                 {
 			$$ = DeclarationSpecifiers($4, int($2), DECL_ARRAY)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        |       LBRACK IDENTIFIER RBRACK declaration_specifiers
+                {
+			$$ = DeclarationSpecifiers($4, ArrayLengthAt($2, $<span>2), DECL_ARRAY)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
                 }
         |       LBRACK RBRACK declaration_specifiers
                 {
 			$$ = DeclarationSpecifiers($3, 0, DECL_SLICE)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        |       MAP LBRACK type_specifier RBRACK declaration_specifiers
+                {
+			$$ = DeclarationSpecifiersMap($3, $5)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        |       CHAN declaration_specifiers
+                {
+			$$ = DeclarationSpecifiersChan($2)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        |       FUNC function_type_parameters
+                {
+			$$ = DeclarationSpecifiersFunc($2, nil)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        |       FUNC function_type_parameters function_type_results
+                {
+			$$ = DeclarationSpecifiersFunc($2, $3)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
+                }
+        |       FUNC function_type_parameters declaration_specifiers
+                {
+			$$ = DeclarationSpecifiersFunc($2, []*CXArgument{$3})
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
                 }
         |       type_specifier
                 {
 			$$ = DeclarationSpecifiersBasic($1)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
                 }
         |       IDENTIFIER
                 {
 			$$ = DeclarationSpecifiersStruct($1, "", false)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
                 }
         |       IDENTIFIER PERIOD IDENTIFIER
                 {
 			$$ = DeclarationSpecifiersStruct($3, $1, true)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
                 }
 	|       type_specifier PERIOD IDENTIFIER
                 {
 			$$ = DeclarationSpecifiersStruct($3, TypeNames[$1], true)
+			SetArgumentSpans(ruleSpan($<span>1, yyrcvr.char), $$)
                 }
         /* |       package_identifier */
         /*         { */
@@ -464,6 +690,45 @@ declaration_specifiers:
 	/* |       type_qualifier */
                 ;
 
+function_type_parameters:
+                LPAREN RPAREN
+                { $$ = nil }
+        |       LPAREN type_list RPAREN
+                { $$ = $2 }
+        |       LPAREN ELLIPSIS declaration_specifiers RPAREN
+                { $$ = []*CXArgument{VariadicParameter($3)} }
+        |       LPAREN type_list COMMA ELLIPSIS declaration_specifiers RPAREN
+                { $$ = append($2, VariadicParameter($5)) }
+        |       LPAREN parameter_type_list RPAREN
+                {
+			$$ = $2
+			NamedTypeParameters(ruleSpan($<span>1, yyrcvr.char))
+                }
+                ;
+
+function_type_results:
+                LPAREN RPAREN
+                { $$ = nil }
+        |       LPAREN type_list RPAREN
+                { $$ = $2 }
+        |       LPAREN parameter_list RPAREN
+                {
+			$$ = $2
+			NamedTypeParameters(ruleSpan($<span>1, yyrcvr.char))
+                }
+                ;
+
+type_list:
+                declaration_specifiers
+                {
+			$$ = []*CXArgument{$1}
+                }
+        |       type_list COMMA declaration_specifiers
+                {
+			$$ = append($1, $3)
+                }
+                ;
+
 type_specifier:
                 AFF
                 { $$ = TYPE_AFF }
@@ -506,6 +771,7 @@ struct_literal_fields:
 			} else {
 				$$ = Assignment([]*CXExpression{StructLiteralFields($1)}, "=", $3)
 			}
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       struct_literal_fields COMMA IDENTIFIER COLON constant_expression
                 {
@@ -514,6 +780,7 @@ struct_literal_fields:
 			} else {
 				$$ = append($1, Assignment([]*CXExpression{StructLiteralFields($3)}, "=", $5)...)
 			}
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -543,6 +810,7 @@ array_literal_expression:
         |       LBRACK INT_LITERAL RBRACK type_specifier LBRACE array_literal_expression_list RBRACE
                 {
 			$$ = ArrayLiteralExpression(int($2), $4, $6)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       LBRACK INT_LITERAL RBRACK type_specifier LBRACE RBRACE
                 {
@@ -558,6 +826,7 @@ array_literal_expression:
 			}
 
 			$$ = $4
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -592,6 +861,7 @@ slice_literal_expression:
         |       LBRACK RBRACK type_specifier LBRACE slice_literal_expression_list RBRACE
                 {
 			$$ = SliceLiteralExpression($3, $5)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       LBRACK RBRACK type_specifier LBRACE RBRACE
                 {
@@ -607,6 +877,36 @@ slice_literal_expression:
 			}
 
 			$$ = $3
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+                ;
+
+map_literal_entries:
+                assignment_expression COLON assignment_expression
+                {
+			$$ = [][]*CXExpression{$1, $3}
+                }
+	|       map_literal_entries COMMA assignment_expression COLON assignment_expression
+                {
+			$$ = append($1, $3, $5)
+                }
+                ;
+
+map_literal_expression:
+                MAP LBRACK type_specifier RBRACK declaration_specifiers LBRACE map_literal_entries RBRACE
+                {
+			$$ = MapLiteralExpression($3, $5, $7)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       MAP LBRACK type_specifier RBRACK declaration_specifiers LBRACE map_literal_entries COMMA RBRACE
+                {
+			$$ = MapLiteralExpression($3, $5, $7)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       MAP LBRACK type_specifier RBRACK declaration_specifiers LBRACE RBRACE
+                {
+			$$ = MapLiteralExpression($3, $5, nil)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -714,6 +1014,7 @@ infer_clauses:
 			}
 			
 			$$ = SliceLiteralExpression(TYPE_AFF, exprs)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         /* |       infer_targets */
         /*         { */
@@ -736,15 +1037,23 @@ primary_expression:
                 IDENTIFIER
                 {
 			$$ = PrimaryIdentifier($1)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         /* |       IDENTIFIER LBRACE struct_literal_fields RBRACE */
         /*         { */
 	/* 		$$ = PrimaryStructLiteral($1, $3) */
         /*         } */
-	|	FUNC LPAREN RPAREN
-		{
-			$$ = nil
-		}
+        |       function_literal_header LBRACE RBRACE
+                {
+			$$ = FunctionLiteral($1, nil)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       function_literal_header LBRACE block_item_list RBRACE
+                {
+			DeclareInBlock($3, ruleSpan($<span>2, yyrcvr.char))
+			$$ = FunctionLiteral($1, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
         |       INFER LBRACE infer_clauses RBRACE
                 {
 			$$ = $3
@@ -752,31 +1061,63 @@ primary_expression:
         |       STRING_LITERAL
                 {
 			$$ = WritePrimary(TYPE_STR, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       BOOLEAN_LITERAL
                 {
 			exprs := WritePrimary(TYPE_BOOL, encoder.Serialize($1), false)
 			$$ = exprs
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       BYTE_LITERAL
                 {
 			$$ = WritePrimary(TYPE_BYTE, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       INT_LITERAL
                 {
 			$$ = WritePrimary(TYPE_I32, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       FLOAT_LITERAL
                 {
 			$$ = WritePrimary(TYPE_F32, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       DOUBLE_LITERAL
                 {
 			$$ = WritePrimary(TYPE_F64, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       LONG_LITERAL
                 {
 			$$ = WritePrimary(TYPE_I64, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       SHORT_LITERAL
+                {
+			$$ = WritePrimary(TYPE_I16, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       UNSIGNED_BYTE_LITERAL
+                {
+			$$ = WritePrimary(TYPE_UI8, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       UNSIGNED_SHORT_LITERAL
+                {
+			$$ = WritePrimary(TYPE_UI16, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       UNSIGNED_INT_LITERAL
+                {
+			$$ = WritePrimary(TYPE_UI32, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       UNSIGNED_LONG_LITERAL
+                {
+			$$ = WritePrimary(TYPE_UI64, encoder.Serialize($1), false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       LPAREN expression RPAREN
                 { $$ = $2 }
@@ -788,6 +1129,17 @@ primary_expression:
                 {
 			$$ = $1
                 }
+        |       map_literal_expression
+        |       MAKE LPAREN declaration_specifiers RPAREN
+                {
+			$$ = MakeChanExpression($3, nil)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       MAKE LPAREN declaration_specifiers COMMA assignment_expression RPAREN
+                {
+			$$ = MakeChanExpression($3, $5)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
                 ;
 
 after_period:   type_specifier
@@ -802,31 +1154,68 @@ postfix_expression:
 	|       postfix_expression LBRACK expression RBRACK
                 {
 			$$ = PostfixExpressionArray($1, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       postfix_expression LBRACK slice_bound COLON slice_bound RBRACK
+                {
+			$$ = PostfixExpressionSlice($1, $3, $5, nil)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       postfix_expression LBRACK slice_bound COLON expression COLON expression RBRACK
+                {
+			$$ = PostfixExpressionSlice($1, $3, $5, $7)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       type_specifier LPAREN assignment_expression RPAREN
+                {
+			$$ = PostfixExpressionConversion($1, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       LBRACK RBRACK type_specifier LPAREN assignment_expression RPAREN
+                {
+			$$ = PostfixExpressionSliceConversion($3, $5)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       type_specifier PERIOD after_period
                 {
 			$$ = PostfixExpressionNative(int($1), $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
 	|       postfix_expression LPAREN RPAREN
                 {
 			$$ = PostfixExpressionEmptyFunCall($1)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
 	|       postfix_expression LPAREN argument_expression_list RPAREN
                 {
 			$$ = PostfixExpressionFunCall($1, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       postfix_expression LPAREN argument_expression_list ELLIPSIS RPAREN
+                {
+			$$ = PostfixExpressionSpreadCall($1, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
 	|       postfix_expression INC_OP
                 {
 			$$ = PostfixExpressionIncDec($1, true)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       postfix_expression DEC_OP
                 {
 			$$ = PostfixExpressionIncDec($1, false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
 
         |       postfix_expression PERIOD IDENTIFIER
                 {
 			PostfixExpressionField($1, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       postfix_expression PERIOD LPAREN declaration_specifiers RPAREN
+                {
+			$$ = TypeAssertion($1, $4)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         // |       postfix_expression PERIOD IDENTIFIER LBRACE struct_literal_fields RBRACE
         //         {
@@ -834,6 +1223,14 @@ postfix_expression:
         //         }
                 ;
 
+slice_bound:
+                /* empty */
+                {
+			$$ = nil
+                }
+        |       expression
+                ;
+
 argument_expression_list:
                 assignment_expression
 	|       argument_expression_list COMMA assignment_expression
@@ -857,6 +1254,12 @@ unary_expression:
 	|       unary_operator unary_expression
                 {
 			$$ = UnaryExpression($1, $2)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       ARROW unary_expression
+                {
+			$$ = ReceiveExpression($2)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -873,14 +1276,17 @@ multiplicative_expression:
         |       multiplicative_expression MUL_OP unary_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_MUL)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       multiplicative_expression DIV_OP unary_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_DIV)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       multiplicative_expression MOD_OP unary_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_MOD)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -889,10 +1295,12 @@ additive_expression:
         |       additive_expression ADD_OP multiplicative_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_ADD)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
 	|       additive_expression SUB_OP multiplicative_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_SUB)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -901,14 +1309,17 @@ shift_expression:
         |       shift_expression LEFT_OP additive_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_BITSHL)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       shift_expression RIGHT_OP additive_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_BITSHR)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       shift_expression BITCLEAR_OP additive_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_BITCLEAR)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -917,18 +1328,22 @@ relational_expression:
         |       relational_expression LT_OP shift_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_LT)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       relational_expression GT_OP shift_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_GT)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       relational_expression LTEQ_OP shift_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_LTEQ)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       relational_expression GTEQ_OP shift_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_GTEQ)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -937,10 +1352,12 @@ equality_expression:
         |       equality_expression EQ_OP relational_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_EQUAL)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       equality_expression NE_OP relational_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_UNEQUAL)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -948,6 +1365,7 @@ and_expression: equality_expression
         |       and_expression REF_OP equality_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_BITAND)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -956,6 +1374,7 @@ exclusive_or_expression:
         |       exclusive_or_expression BITXOR_OP and_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_BITXOR)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -964,6 +1383,7 @@ inclusive_or_expression:
         |       inclusive_or_expression BITOR_OP exclusive_or_expression
                 {
 			$$ = ShorthandExpression($1, $3, OP_BITOR)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -972,6 +1392,7 @@ logical_and_expression:
 	|       logical_and_expression AND_OP inclusive_or_expression
                 {
 			$$ = UndefinedTypeOperation($1, $3, Natives[OP_BOOL_AND])
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -980,6 +1401,7 @@ logical_or_expression:
 	|       logical_or_expression OR_OP logical_and_expression
                 {
 			$$ = UndefinedTypeOperation($1, $3, Natives[OP_BOOL_OR])
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -993,10 +1415,12 @@ struct_literal_expression:
 	|       IDENTIFIER LBRACE struct_literal_fields RBRACE
                 {
 			$$ = PrimaryStructLiteral($1, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       postfix_expression PERIOD IDENTIFIER LBRACE struct_literal_fields RBRACE
                 {
 			$$ = PrimaryStructLiteralExternal($1[0].Outputs[0].Name, $3, $5)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -1030,6 +1454,12 @@ assignment_expression:
 			} else {
 				$$ = Assignment($1, $2, $3)
 			}
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+			if len($$) > 0 && $$[len($$) - 1].Operator == Natives[OP_IDENTITY] {
+				// then it only copies the value, so its code is
+				// the whole assignment
+				$$[len($$) - 1].Span = ruleSpan($<span>1, yyrcvr.char)
+			}
                 }
                 ;
 
@@ -1064,10 +1494,12 @@ declaration:
                 VAR declarator declaration_specifiers SEMICOLON
                 {
 			$$ = DeclareLocal($2, $3, nil, false)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       VAR declarator declaration_specifiers ASSIGN initializer SEMICOLON
                 {
 			$$ = DeclareLocal($2, $3, $5, true)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -1084,6 +1516,8 @@ statement:      labeled_statement
         |       debugging
                 { $$ = nil }
 	|       jump_statement
+	|       go_statement
+	|       defer_statement
                 ;
 
 labeled_statement:
@@ -1099,10 +1533,6 @@ labeled_statement:
 
 			$$ = $3
                 }
-	|       CASE constant_expression COLON statement
-                { $$ = nil }
-	|       DEFAULT COLON statement
-                { $$ = nil }
                 ;
 
 compound_statement:
@@ -1111,7 +1541,15 @@ compound_statement:
 	|       LBRACE block_item_list RBRACE SEMICOLON
                 {
                     $$ = $2
+                    DeclareInBlock($$, ruleSpan($<span>1, yyrcvr.char))
                 }
+	|       LBRACE block_item_list error RBRACE SEMICOLON
+                {
+                    $$ = $2
+                    DeclareInBlock($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       LBRACE error RBRACE SEMICOLON
+                { $$ = nil }
                 ;
 
 block_item_list:
@@ -1126,6 +1564,8 @@ block_item:     declaration
         |       statement
         |       stepping
                 { $$ = nil }
+        |       error SEMICOLON
+                { $$ = nil }
         /* |       debugging */
         /*         { $$ = nil } */
         /* |       selector */
@@ -1144,40 +1584,218 @@ expression_statement:
 			}
 			// $$ = $1
                 }
+	|       send_expression SEMICOLON
+                ;
+
+send_expression:
+                unary_expression ARROW assignment_expression
+                {
+			$$ = SendExpressions($1, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
                 ;
 
 selection_statement:
                 IF conditional_expression LBRACE block_item_list RBRACE elseif_list else_statement SEMICOLON
                 {
+			DeclareInBlock($4, JoinSpans($<span>3, $<span>5))
 			$$ = SelectionStatement($2, $4, $6, $7, SEL_ELSEIFELSE)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       IF conditional_expression LBRACE block_item_list RBRACE else_statement SEMICOLON
                 {
+			DeclareInBlock($4, JoinSpans($<span>3, $<span>5))
 			$$ = SelectionExpressions($2, $4, $6)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       IF conditional_expression LBRACE RBRACE else_statement SEMICOLON
                 {
 			$$ = SelectionExpressions($2, nil, $5)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       IF conditional_expression LBRACE block_item_list RBRACE elseif_list SEMICOLON
                 {
+			DeclareInBlock($4, JoinSpans($<span>3, $<span>5))
 			$$ = SelectionStatement($2, $4, $6, nil, SEL_ELSEIF)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       IF conditional_expression LBRACE RBRACE elseif_list SEMICOLON
                 {
 			//
 			$$ = SelectionStatement($2, nil, $5, nil, SEL_ELSEIF)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       IF conditional_expression compound_statement
                 {
 			$$ = SelectionExpressions($2, $3, nil)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       SWITCH conditional_expression LBRACE switch_clause_list RBRACE SEMICOLON
+                {
+			$$ = SwitchStatement($2, $4)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       SWITCH LBRACE switch_clause_list RBRACE SEMICOLON
+                {
+			$$ = SwitchStatement(nil, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       SWITCH postfix_expression PERIOD LPAREN TYPE RPAREN LBRACE type_switch_clause_list RBRACE SEMICOLON
+                {
+			$$ = TypeSwitchStatement("", $2, $8)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       SWITCH IDENTIFIER CASSIGN postfix_expression PERIOD LPAREN TYPE RPAREN LBRACE type_switch_clause_list RBRACE SEMICOLON
+                {
+			$$ = TypeSwitchStatement($2, $4, $10)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       SELECT LBRACE select_clause_list RBRACE SEMICOLON
+                {
+			$$ = SelectExpressions($3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       SELECT LBRACE RBRACE SEMICOLON
+                {
+			$$ = SelectExpressions(nil)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+                ;
+
+switch_clause:  CASE argument_expression_list COLON block_item_list
+                {
+			$$ = SelectStatement{
+				Condition: $2,
+				Then: $4,
+			}
+                }
+        |       CASE argument_expression_list COLON
+                {
+			$$ = SelectStatement{
+				Condition: $2,
+			}
+                }
+        |       DEFAULT COLON block_item_list
+                {
+			$$ = SelectStatement{
+				Then: $3,
+				Span: $<span>1,
+			}
+                }
+        |       DEFAULT COLON
+                {
+			$$ = SelectStatement{
+				Span: $<span>1,
+			}
                 }
-	|       SWITCH LPAREN expression RPAREN statement
-                { $$ = nil }
                 ;
 
+switch_clause_list:
+                switch_clause
+                {
+			$$ = []SelectStatement{$1}
+                }
+        |       switch_clause_list switch_clause
+                {
+			$$ = append($1, $2)
+                }
+        ;
+
+type_switch_clause:
+                CASE type_list COLON block_item_list
+                {
+			$$ = SelectStatement{
+				Types: $2,
+				Then: $4,
+			}
+                }
+        |       CASE type_list COLON
+                {
+			$$ = SelectStatement{
+				Types: $2,
+			}
+                }
+        |       DEFAULT COLON block_item_list
+                {
+			$$ = SelectStatement{
+				Then: $3,
+				Span: $<span>1,
+			}
+                }
+        |       DEFAULT COLON
+                {
+			$$ = SelectStatement{
+				Span: $<span>1,
+			}
+                }
+                ;
+
+type_switch_clause_list:
+                type_switch_clause
+                {
+			$$ = []SelectStatement{$1}
+                }
+        |       type_switch_clause_list type_switch_clause
+                {
+			$$ = append($1, $2)
+                }
+        ;
+
+select_clause:  CASE expression COLON block_item_list
+                {
+			$$ = SelectStatement{
+				Condition: $2,
+				Then: $4,
+			}
+                }
+        |       CASE expression COLON
+                {
+			$$ = SelectStatement{
+				Condition: $2,
+			}
+                }
+        |       CASE send_expression COLON block_item_list
+                {
+			$$ = SelectStatement{
+				Condition: $2,
+				Then: $4,
+			}
+                }
+        |       CASE send_expression COLON
+                {
+			$$ = SelectStatement{
+				Condition: $2,
+			}
+                }
+        |       DEFAULT COLON block_item_list
+                {
+			$$ = SelectStatement{
+				Then: $3,
+				Span: $<span>1,
+			}
+                }
+        |       DEFAULT COLON
+                {
+			$$ = SelectStatement{
+				Span: $<span>1,
+			}
+                }
+                ;
+
+select_clause_list:
+                select_clause
+                {
+			$$ = []SelectStatement{$1}
+                }
+        |       select_clause_list select_clause
+                {
+			$$ = append($1, $2)
+                }
+        ;
+
 elseif:         ELSE IF expression LBRACE block_item_list RBRACE
                 {
+			DeclareInBlock($5, JoinSpans($<span>4, $<span>6))
 			$$ = SelectStatement{
 				Condition: $3,
 				Then: $5,
@@ -1198,6 +1816,7 @@ elseif_list:    elseif
 else_statement:
                 ELSE LBRACE block_item_list RBRACE
                 {
+			DeclareInBlock($3, JoinSpans($<span>2, $<span>4))
 			$$ = $3
                 }
         ;
@@ -1206,14 +1825,32 @@ iteration_statement:
                 FOR expression compound_statement
                 {
 			$$ = IterationExpressions(nil, $2, nil, $3)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+			DeclareInBlock($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       FOR expression_statement expression_statement compound_statement
                 {			
 			$$ = IterationExpressions($2, $3, nil, $4)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+			DeclareInBlock($$, ruleSpan($<span>1, yyrcvr.char))
                 }
         |       FOR expression_statement expression_statement expression compound_statement
                 {
 			$$ = IterationExpressions($2, $3, $4, $5)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+			DeclareInBlock($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       FOR unary_expression CASSIGN RANGE postfix_expression compound_statement
+                {
+			$$ = RangeExpressions($2, nil, $5, $6)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+			DeclareInBlock($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+        |       FOR expression COMMA unary_expression CASSIGN RANGE postfix_expression compound_statement
+                {
+			$$ = RangeExpressions($2, $4, $7, $8)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+			DeclareInBlock($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 
@@ -1233,38 +1870,42 @@ jump_statement: GOTO IDENTIFIER SEMICOLON
 			} else {
 				panic(err)
 			}
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
 	|       CONTINUE SEMICOLON
 		{
 			$$ = ContinueExpressions()
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
 		}
 	|       BREAK SEMICOLON
 		{
 			$$ = BreakExpressions()
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
 		}
 	|       RETURN SEMICOLON
                 {
-			if pkg, err := PRGRM.GetCurrentPackage(); err == nil {
-				expr := MakeExpression(Natives[OP_JMP], CurrentFile, LineNo)
-
-				// simulating a label so it gets executed without evaluating a predicate
-				expr.Label = MakeGenSym(LABEL_PREFIX)
-				expr.ThenLines = MAX_INT32
-				expr.Package = pkg
-
-				arg := MakeArgument("", CurrentFile, LineNo).AddType("bool")
-				arg.Package = pkg
-
-				expr.AddInput(arg)
+			$$ = ReturnExpressions(nil)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+	|       RETURN argument_expression_list SEMICOLON
+                {
+			$$ = ReturnExpressions($2)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
+                }
+                ;
 
-				$$ = []*CXExpression{expr}
-			} else {
-				panic(err)
-			}
+go_statement:   GO postfix_expression SEMICOLON
+                {
+			$$ = GoExpressions($2)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
-	|       RETURN expression SEMICOLON
+                ;
+
+defer_statement:
+                DEFER postfix_expression SEMICOLON
                 {
-			$$ = nil
+			$$ = DeferExpressions($2)
+			SetSpans($$, ruleSpan($<span>1, yyrcvr.char))
                 }
                 ;
 %%
@@ -1,9 +1,10 @@
 %{
 	package cxgo0
 	import (
-		// "fmt"
+		"fmt"
 		"bytes"
 		// "os"
+		"github.com/skycoin/skycoin/src/cipher/encoder"
 		. "github.com/skycoin/cx/cx"
 		. "github.com/skycoin/cx/cxgo/actions"
 	)
@@ -65,7 +66,16 @@
 		}
 	}
 	
-	func Parse (code string) int {
+	func Parse (code string) (result int) {
+		lineNo = 0
+		// a failure of the first pass is reported as an error of the
+		// line it was parsing
+		defer func() {
+			if r := recover(); r != nil {
+				CompilationError(CurrentFileName, lineNo, DIAG_INTERNAL, fmt.Sprintf("%v", r))
+				result = 1
+			}
+		}()
 		codeBuf := bytes.NewBufferString(code)
 		return yyParse(NewLexer(codeBuf))
 	}
@@ -76,6 +86,11 @@
 	byt byte
 	i32 int32
 	i64 int64
+	i16 int16
+	ui8 uint8
+	ui16 uint16
+	ui32 uint32
+	ui64 uint64
 	f32 float32
 	f64 float64
 	tok string
@@ -92,6 +107,8 @@
 	expressions []*CXExpression
 
         function *CXFunction
+
+	constant *CXConstant
 }
 
 %token  <byt>           BYTENUM
@@ -100,14 +117,20 @@
 %token  <f32>           FLOAT
 %token  <f64>           DOUBLE
 %token  <byt>           BYTE_LITERAL
-%token  <i32>           INT_LITERAL BOOLEAN_LITERAL
+%token  <i32>           INT_LITERAL
+%token  <bool>          BOOLEAN_LITERAL
 %token  <i64>           LONG_LITERAL
+%token  <i16>           SHORT_LITERAL
+%token  <ui8>           UNSIGNED_BYTE_LITERAL
+%token  <ui16>          UNSIGNED_SHORT_LITERAL
+%token  <ui32>          UNSIGNED_INT_LITERAL
+%token  <ui64>          UNSIGNED_LONG_LITERAL
 %token  <f32>           FLOAT_LITERAL
 %token  <f64>           DOUBLE_LITERAL
 %token  <tok>           FUNC OP LPAREN RPAREN LBRACE RBRACE LBRACK RBRACK IDENTIFIER
-                        VAR COMMA PERIOD COMMENT STRING_LITERAL PACKAGE IF ELSE FOR TYPSTRUCT STRUCT
+                        VAR COMMA PERIOD ELLIPSIS COMMENT STRING_LITERAL PACKAGE IF ELSE FOR TYPSTRUCT STRUCT
                         SEMICOLON NEWLINE
-                        ASSIGN CASSIGN IMPORT RETURN GOTO GT_OP LT_OP GTEQ_OP LTEQ_OP EQUAL COLON NEW
+                        ASSIGN CASSIGN IMPORT RETURN GOTO GO DEFER GT_OP LT_OP GTEQ_OP LTEQ_OP EQUAL COLON NEW
                         EQUALWORD GTHANWORD LTHANWORD
                         GTHANEQ LTHANEQ UNEQUAL AND OR
                         ADD_OP SUB_OP MUL_OP DIV_OP MOD_OP REF_OP NEG_OP AFFVAR
@@ -127,7 +150,7 @@
                         STR
                         UI8 UI16 UI32 UI64
                         UNION ENUM CONST CASE DEFAULT SWITCH BREAK CONTINUE
-                        TYPE
+                        TYPE MAP RANGE CHAN MAKE SELECT ARROW INTERFACE
                         
                         /* Types */
                         BASICTYPE
@@ -152,12 +175,42 @@
 %type   <argument>      parameter_declaration
 %type   <arguments>     parameter_type_list
 %type   <arguments>     function_parameters
+%type   <arguments>     function_results
+%type   <arguments>     function_type_parameters
+%type   <arguments>     function_type_results
+%type   <arguments>     type_list
+%type   <argument>      variadic_parameter
+%type   <argument>      embedded_field
 %type   <arguments>     parameter_list
 %type   <arguments>     fields
 %type   <arguments>     struct_fields
+%type   <argument>      method_signature
+%type   <arguments>     method_signatures
+%type   <arguments>     interface_methods
                                                 
 %type   <function>      function_header
 
+%type   <constant>      primary_expression
+%type   <constant>      postfix_expression
+%type   <constant>      unary_expression
+%type   <constant>      multiplicative_expression
+%type   <constant>      additive_expression
+%type   <constant>      shift_expression
+%type   <constant>      relational_expression
+%type   <constant>      equality_expression
+%type   <constant>      and_expression
+%type   <constant>      exclusive_or_expression
+%type   <constant>      inclusive_or_expression
+%type   <constant>      logical_and_expression
+%type   <constant>      logical_or_expression
+%type   <constant>      conditional_expression
+%type   <constant>      struct_literal_expression
+%type   <constant>      assignment_expression
+%type   <constant>      slice_bound
+%type   <constant>      expression
+%type   <constant>      constant_expression
+%type   <constant>      send_expression
+
                         // for struct literals
 %right                  IDENTIFIER LBRACE
 
@@ -173,10 +226,14 @@ translation_unit:
 external_declaration:
                 package_declaration
         |       global_declaration
+        |       constant_declaration
         |       function_declaration
         |       import_declaration
         |       struct_declaration
+        |       interface_declaration
+        |       named_type_declaration
 
+        |       error SEMICOLON
         |       stepping
         ;
 
@@ -184,6 +241,47 @@ stepping:       TSTEP INT_LITERAL INT_LITERAL
         |       STEP INT_LITERAL
         ;
 
+constant_declaration:
+                CONST constant_specification
+        |       CONST LPAREN constant_specification_list RPAREN SEMICOLON
+        |       ENUM LBRACE enum_list RBRACE SEMICOLON
+                {
+			EndEnumDeclaration()
+                }
+                ;
+
+constant_specification:
+                IDENTIFIER ASSIGN constant_expression SEMICOLON
+                {
+			DeclareConstant($1, TYPE_UNDEFINED, $3, CurrentFileName, lineNo)
+                }
+        |       IDENTIFIER type_specifier ASSIGN constant_expression SEMICOLON
+                {
+			DeclareConstant($1, $2, $4, CurrentFileName, lineNo)
+                }
+                ;
+
+constant_specification_list:
+                constant_specification
+        |       constant_specification_list constant_specification
+                ;
+
+enum_list:
+                enum_specification
+        |       enum_list enum_specification
+                ;
+
+enum_specification:
+                IDENTIFIER SEMICOLON
+                {
+			DeclareEnumConstant($1, nil, CurrentFileName, lineNo)
+                }
+        |       IDENTIFIER ASSIGN constant_expression SEMICOLON
+                {
+			DeclareEnumConstant($1, $3, CurrentFileName, lineNo)
+                }
+                ;
+
 global_declaration:
                 VAR declarator declaration_specifiers SEMICOLON
                 {
@@ -231,6 +329,57 @@ global_declaration:
                 }
                 ;
 
+interface_declaration:
+                TYPE IDENTIFIER INTERFACE interface_methods
+                {
+			DeclareInterface($2, $4)
+                }
+                ;
+
+named_type_declaration:
+                TYPE IDENTIFIER declaration_specifiers SEMICOLON
+                {
+			DeclareNamedType($2, $3, false)
+                }
+        |       TYPE IDENTIFIER ASSIGN declaration_specifiers SEMICOLON
+                {
+			DeclareNamedType($2, $4, true)
+                }
+                ;
+
+interface_methods:
+                LBRACE RBRACE SEMICOLON
+                { $$ = nil }
+        |       LBRACE method_signatures RBRACE SEMICOLON
+                { $$ = $2 }
+        ;
+
+method_signatures:
+                method_signature SEMICOLON
+                {
+			$$ = []*CXArgument{$1}
+                }
+        |       method_signatures method_signature SEMICOLON
+                {
+			$$ = append($1, $2)
+                }
+        ;
+
+method_signature:
+                IDENTIFIER function_type_parameters
+                {
+			$$ = MethodSignature($1, $2, nil)
+                }
+        |       IDENTIFIER function_type_parameters function_type_results
+                {
+			$$ = MethodSignature($1, $2, $3)
+                }
+        |       IDENTIFIER function_type_parameters declaration_specifiers
+                {
+			$$ = MethodSignature($1, $2, []*CXArgument{$3})
+                }
+        ;
+
 struct_declaration:
                 TYPE IDENTIFIER STRUCT struct_fields
                 {
@@ -278,10 +427,29 @@ fields:         parameter_declaration SEMICOLON
                 {
 			$$ = []*CXArgument{$1}
                 }
+        |       embedded_field SEMICOLON
+                {
+			$$ = []*CXArgument{$1}
+                }
         |       fields parameter_declaration SEMICOLON
                 {
 			$$ = append($1, $2)
                 }
+        |       fields embedded_field SEMICOLON
+                {
+			$$ = append($1, $2)
+                }
+        ;
+
+embedded_field:
+                IDENTIFIER
+                {
+			$$ = EmbeddedField(DeclarationSpecifiersStruct($1, "", false))
+                }
+        |       IDENTIFIER PERIOD IDENTIFIER
+                {
+			$$ = EmbeddedField(DeclarationSpecifiersStruct($3, $1, true))
+                }
         ;
 
 package_declaration:
@@ -344,13 +512,19 @@ function_header:
 				panic(err)
 			}
                 }
-        |       FUNC LPAREN parameter_type_list RPAREN IDENTIFIER
+        |       FUNC LPAREN parameter_list RPAREN IDENTIFIER
                 {
 			if len($3) > 1 {
 				panic("method has multiple receivers")
 			}
 
-			fnName := $3[0].CustomType.Name + "." + $5
+			var fnName string
+			if rcvrType := ReceiverType($3[0]); rcvrType != nil {
+				fnName = rcvrType.Name + "." + $5
+			} else {
+				// the second pass reports the invalid receiver
+				fnName = TypeNames[$3[0].Type] + "." + $5
+			}
 
 			if pkg, err := PRGRM0.GetCurrentPackage(); err == nil {
 				fn := MakeFunction(fnName)
@@ -372,12 +546,24 @@ function_parameters:
                 { $$ = $2 }
                 ;
 
+function_results:
+                LPAREN RPAREN
+                { $$ = nil }
+        |       LPAREN parameter_list RPAREN
+                { $$ = $2 }
+                ;
+
+function_literal_header:
+                FUNC function_parameters
+        |       FUNC function_parameters function_results
+        ;
+
 function_declaration:
                 function_header function_parameters compound_statement
                 {
 			PreFunctionDeclaration($1, $2, nil, nil)
                 }
-        |       function_header function_parameters function_parameters compound_statement
+        |       function_header function_parameters function_results compound_statement
                 {
 			PreFunctionDeclaration($1, $2, $3, nil)
                 }
@@ -385,8 +571,24 @@ function_declaration:
 
 // parameter_type_list
 parameter_type_list:
-                //parameter_list COMMA ELLIPSIS
 		parameter_list
+        |       variadic_parameter
+                {
+			$$ = []*CXArgument{$1}
+                }
+        |       parameter_list COMMA variadic_parameter
+                {
+			$$ = append($1, $3)
+                }
+                ;
+
+variadic_parameter:
+                declarator ELLIPSIS declaration_specifiers
+                {
+			$3.Name = $1.Name
+			$3.Package = $1.Package
+			$$ = VariadicParameter($3)
+                }
                 ;
 
 parameter_list:
@@ -498,6 +700,32 @@ declaration_specifiers:
 			// arg.Size = TYPE_POINTER_SIZE
 			// $$ = arg
                 }
+        |       LBRACK IDENTIFIER RBRACK declaration_specifiers
+                {
+			// an invalid length is reported by the second pass
+			length, _ := ConstantArrayLength($2)
+			$$ = DeclarationSpecifiers($4, length, DECL_ARRAY)
+                }
+        |       MAP LBRACK type_specifier RBRACK declaration_specifiers
+                {
+			$$ = DeclarationSpecifiersMap($3, $5)
+                }
+        |       CHAN declaration_specifiers
+                {
+			$$ = DeclarationSpecifiersChan($2)
+                }
+        |       FUNC function_type_parameters
+                {
+			$$ = DeclarationSpecifiersFunc($2, nil)
+                }
+        |       FUNC function_type_parameters function_type_results
+                {
+			$$ = DeclarationSpecifiersFunc($2, $3)
+                }
+        |       FUNC function_type_parameters declaration_specifiers
+                {
+			$$ = DeclarationSpecifiersFunc($2, []*CXArgument{$3})
+                }
         |       LBRACK RBRACK declaration_specifiers
                 {
 			$$ = DeclarationSpecifiers($3, 0, DECL_SLICE)
@@ -603,6 +831,39 @@ declaration_specifiers:
 	/* |       type_qualifier */
                 ;
 
+function_type_parameters:
+                LPAREN RPAREN
+                { $$ = nil }
+        |       LPAREN type_list RPAREN
+                { $$ = $2 }
+        |       LPAREN ELLIPSIS declaration_specifiers RPAREN
+                { $$ = []*CXArgument{VariadicParameter($3)} }
+        |       LPAREN type_list COMMA ELLIPSIS declaration_specifiers RPAREN
+                { $$ = append($2, VariadicParameter($5)) }
+        |       LPAREN parameter_type_list RPAREN
+                { $$ = $2 }
+                ;
+
+function_type_results:
+                LPAREN RPAREN
+                { $$ = nil }
+        |       LPAREN type_list RPAREN
+                { $$ = $2 }
+        |       LPAREN parameter_list RPAREN
+                { $$ = $2 }
+                ;
+
+type_list:
+                declaration_specifiers
+                {
+			$$ = []*CXArgument{$1}
+                }
+        |       type_list COMMA declaration_specifiers
+                {
+			$$ = append($1, $3)
+                }
+                ;
+
 type_specifier:
                 AFF
                 { $$ = TYPE_AFF }
@@ -669,6 +930,17 @@ slice_literal_expression:
         |       LBRACK RBRACK slice_literal_expression
                 ;
 
+map_literal_entries:
+                assignment_expression COLON assignment_expression
+        |       map_literal_entries COMMA assignment_expression COLON assignment_expression
+                ;
+
+map_literal_expression:
+                MAP LBRACK type_specifier RBRACK declaration_specifiers LBRACE map_literal_entries RBRACE
+        |       MAP LBRACK type_specifier RBRACK declaration_specifiers LBRACE map_literal_entries COMMA RBRACE
+        |       MAP LBRACK type_specifier RBRACK declaration_specifiers LBRACE RBRACE
+                ;
+
 
 
 /* infer_action_arg: */
@@ -711,18 +983,76 @@ infer_clauses:
 
 primary_expression:
                 IDENTIFIER
+                {
+			$$ = ConstantIdentifier($1)
+                }
         /* |       IDENTIFIER LBRACE struct_literal_fields RBRACE */
         |       INFER LBRACE infer_clauses RBRACE
+                { $$ = nil }
+        |       function_literal_header LBRACE RBRACE
+                { $$ = nil }
+        |       function_literal_header LBRACE block_item_list RBRACE
+                { $$ = nil }
         |       STRING_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_STR, encoder.Serialize($1))
+                }
         |       BOOLEAN_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_BOOL, encoder.Serialize($1))
+                }
         |       BYTE_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_BYTE, encoder.Serialize($1))
+                }
         |       INT_LITERAL
+                {
+			$$ = UntypedConstant(ConstantPrimary(TYPE_I32, encoder.Serialize($1)))
+                }
         |       FLOAT_LITERAL
+                {
+			$$ = UntypedConstant(ConstantPrimary(TYPE_F32, encoder.Serialize($1)))
+                }
         |       DOUBLE_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_F64, encoder.Serialize($1))
+                }
         |       LONG_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_I64, encoder.Serialize($1))
+                }
+        |       SHORT_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_I16, encoder.Serialize($1))
+                }
+        |       UNSIGNED_BYTE_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_UI8, encoder.Serialize($1))
+                }
+        |       UNSIGNED_SHORT_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_UI16, encoder.Serialize($1))
+                }
+        |       UNSIGNED_INT_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_UI32, encoder.Serialize($1))
+                }
+        |       UNSIGNED_LONG_LITERAL
+                {
+			$$ = ConstantPrimary(TYPE_UI64, encoder.Serialize($1))
+                }
         |       LPAREN expression RPAREN
+                { $$ = $2 }
         |       array_literal_expression
+                { $$ = nil }
         |       slice_literal_expression
+                { $$ = nil }
+        |       map_literal_expression
+                { $$ = nil }
+        |       MAKE LPAREN declaration_specifiers RPAREN
+                { $$ = nil }
+        |       MAKE LPAREN declaration_specifiers COMMA assignment_expression RPAREN
+                { $$ = nil }
                 ;
 
 after_period:   type_specifier
@@ -732,15 +1062,38 @@ after_period:   type_specifier
 postfix_expression:
                 primary_expression
 	|       postfix_expression LBRACK expression RBRACK
+                { $$ = nil }
+	|       postfix_expression LBRACK slice_bound COLON slice_bound RBRACK
+                { $$ = nil }
+	|       postfix_expression LBRACK slice_bound COLON expression COLON expression RBRACK
+                { $$ = nil }
+        |       type_specifier LPAREN assignment_expression RPAREN
+                { $$ = nil }
         |       type_specifier PERIOD after_period
+                { $$ = nil }
 	|       postfix_expression LPAREN RPAREN
+                { $$ = nil }
 	|       postfix_expression LPAREN argument_expression_list RPAREN
+                { $$ = nil }
+	|       postfix_expression LPAREN argument_expression_list ELLIPSIS RPAREN
+                { $$ = nil }
 	|       postfix_expression INC_OP
+                { $$ = nil }
         |       postfix_expression DEC_OP
+                { $$ = nil }
         |       postfix_expression PERIOD IDENTIFIER
+                { $$ = nil }
+        |       postfix_expression PERIOD LPAREN declaration_specifiers RPAREN
+                { $$ = nil }
         /* |       postfix_expression PERIOD IDENTIFIER LBRACE struct_literal_fields RBRACE */
                 ;
 
+slice_bound:
+                /* empty */
+                { $$ = nil }
+        |       expression
+                ;
+
 argument_expression_list:
                 assignment_expression
 	|       argument_expression_list COMMA assignment_expression
@@ -749,8 +1102,15 @@ argument_expression_list:
 unary_expression:
                 postfix_expression
 	|       INC_OP unary_expression
+                { $$ = nil }
 	|       DEC_OP unary_expression
+                { $$ = nil }
 	|       unary_operator unary_expression // check
+                {
+			$$ = ConstantUnaryOperation($1, $2)
+                }
+	|       ARROW unary_expression
+                { $$ = nil }
                 ;
 
 unary_operator:
@@ -764,76 +1124,137 @@ unary_operator:
 multiplicative_expression:
                 unary_expression
 	|       multiplicative_expression MUL_OP unary_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_MUL)
+                }
 	|       multiplicative_expression DIV_OP unary_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_DIV)
+                }
 	|       multiplicative_expression MOD_OP unary_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_MOD)
+                }
                 ;
 
 additive_expression:
                 multiplicative_expression
 	|       additive_expression ADD_OP multiplicative_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_ADD)
+                }
 	|       additive_expression SUB_OP multiplicative_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_SUB)
+                }
                 ;
 
 shift_expression:
                 additive_expression
 	|       shift_expression LEFT_OP additive_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_BITSHL)
+                }
 	|       shift_expression RIGHT_OP additive_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_BITSHR)
+                }
         |       shift_expression BITCLEAR_OP additive_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_BITCLEAR)
+                }
                 ;
 
 relational_expression:
                 shift_expression
 	|       relational_expression LT_OP shift_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_LT)
+                }
 	|       relational_expression GT_OP shift_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_GT)
+                }
 	|       relational_expression LTEQ_OP shift_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_LTEQ)
+                }
 	|       relational_expression GTEQ_OP shift_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_GTEQ)
+                }
                 ;
 
 equality_expression:
                 relational_expression
 	|       equality_expression EQ_OP relational_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_EQUAL)
+                }
 	|       equality_expression NE_OP relational_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_UNEQUAL)
+                }
                 ;
 
 and_expression: equality_expression
 	|       and_expression REF_OP equality_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_BITAND)
+                }
                 ;
 
 exclusive_or_expression:
                 and_expression
 	|       exclusive_or_expression BITXOR_OP and_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_BITXOR)
+                }
                 ;
 
 inclusive_or_expression:
                 exclusive_or_expression
 	|       inclusive_or_expression BITOR_OP exclusive_or_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_BITOR)
+                }
                 ;
 
 logical_and_expression:
                 inclusive_or_expression
 	|       logical_and_expression AND_OP inclusive_or_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_LOGICAL_AND)
+                }
                 ;
 
 logical_or_expression:
                 logical_and_expression
 	|       logical_or_expression OR_OP logical_and_expression
+                {
+			$$ = ConstantOperation($1, $3, OP_LOGICAL_OR)
+                }
                 ;
 
 conditional_expression:
                 logical_or_expression
 	|       logical_or_expression '?' expression COLON conditional_expression
+                { $$ = nil }
                 ;
 
 struct_literal_expression:
                 conditional_expression
 	|       IDENTIFIER LBRACE struct_literal_fields RBRACE
+                { $$ = nil }
         |       postfix_expression PERIOD IDENTIFIER LBRACE struct_literal_fields RBRACE
+                { $$ = nil }
         ;
 
 assignment_expression:
                 /* conditional_expression */
                 struct_literal_expression
 	|       unary_expression assignment_operator assignment_expression
+                { $$ = nil }
                 ;
 
 assignment_operator:
@@ -853,6 +1274,7 @@ assignment_operator:
 
 expression:     assignment_expression
 	|       expression COMMA assignment_expression
+                { $$ = nil }
                 ;
 
 constant_expression:
@@ -881,17 +1303,19 @@ statement:      labeled_statement
 	|       selection_statement
 	|       iteration_statement
         |       jump_statement
+	|       go_statement
+	|       defer_statement
                 ;
 
 labeled_statement:
                 IDENTIFIER COLON block_item
-	|       CASE constant_expression COLON statement
-	|       DEFAULT COLON statement
                 ;
 
 compound_statement:
                 LBRACE RBRACE SEMICOLON
 	|       LBRACE block_item_list RBRACE SEMICOLON
+	|       LBRACE block_item_list error RBRACE SEMICOLON
+	|       LBRACE error RBRACE SEMICOLON
                 ;
 
 block_item_list:
@@ -901,11 +1325,18 @@ block_item_list:
 
 block_item:     declaration
         |       statement
+        |       error SEMICOLON
                 ;
 
 expression_statement:
                 SEMICOLON
 	|       expression SEMICOLON
+	|       send_expression SEMICOLON
+                ;
+
+send_expression:
+                unary_expression ARROW assignment_expression
+                { $$ = nil }
                 ;
 
 selection_statement:
@@ -915,9 +1346,50 @@ selection_statement:
         |       IF conditional_expression LBRACE block_item_list RBRACE elseif_list SEMICOLON
         |       IF conditional_expression LBRACE RBRACE elseif_list SEMICOLON
         |       IF conditional_expression compound_statement
-	|       SWITCH LPAREN conditional_expression RPAREN statement
+	|       SWITCH conditional_expression LBRACE switch_clause_list RBRACE SEMICOLON
+	|       SWITCH LBRACE switch_clause_list RBRACE SEMICOLON
+	|       SWITCH postfix_expression PERIOD LPAREN TYPE RPAREN LBRACE type_switch_clause_list RBRACE SEMICOLON
+	|       SWITCH IDENTIFIER CASSIGN postfix_expression PERIOD LPAREN TYPE RPAREN LBRACE type_switch_clause_list RBRACE SEMICOLON
+	|       SELECT LBRACE select_clause_list RBRACE SEMICOLON
+	|       SELECT LBRACE RBRACE SEMICOLON
+                ;
+
+switch_clause:  CASE argument_expression_list COLON block_item_list
+        |       CASE argument_expression_list COLON
+        |       DEFAULT COLON block_item_list
+        |       DEFAULT COLON
+                ;
+
+switch_clause_list:
+                switch_clause
+        |       switch_clause_list switch_clause
+        ;
+
+type_switch_clause:
+                CASE type_list COLON block_item_list
+        |       CASE type_list COLON
+        |       DEFAULT COLON block_item_list
+        |       DEFAULT COLON
                 ;
 
+type_switch_clause_list:
+                type_switch_clause
+        |       type_switch_clause_list type_switch_clause
+        ;
+
+select_clause:  CASE expression COLON block_item_list
+        |       CASE expression COLON
+        |       CASE send_expression COLON block_item_list
+        |       CASE send_expression COLON
+        |       DEFAULT COLON block_item_list
+        |       DEFAULT COLON
+                ;
+
+select_clause_list:
+                select_clause
+        |       select_clause_list select_clause
+        ;
+
 elseif:         ELSE IF expression LBRACE block_item_list RBRACE
         ;
 
@@ -936,6 +1408,8 @@ iteration_statement:
         |       FOR expression_statement expression_statement expression compound_statement
         |       FOR declaration expression_statement compound_statement
         |       FOR declaration expression_statement expression compound_statement
+        |       FOR unary_expression CASSIGN RANGE postfix_expression compound_statement
+        |       FOR expression COMMA unary_expression CASSIGN RANGE postfix_expression compound_statement
                 ;
 
 jump_statement: GOTO IDENTIFIER SEMICOLON
@@ -945,4 +1419,11 @@ jump_statement: GOTO IDENTIFIER SEMICOLON
 	|       RETURN expression SEMICOLON
                 ;
 
+go_statement:   GO postfix_expression SEMICOLON
+                ;
+
+defer_statement:
+                DEFER postfix_expression SEMICOLON
+                ;
+
 %%